@@ -0,0 +1,40 @@
+package entitystore
+
+import (
+	"errors"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// AckChanges removes the processed changes from the outbox
+func (st *storeImplementation) AckChanges(changeIDs []string) (err error) {
+	defer wrapOpError(&err, "AckChanges", "")
+
+	if st.outboxTableName == "" {
+		return errNotEnabled("outbox")
+	}
+
+	if st.tenantScoped {
+		return errors.New("the outbox spans all tenants, it is not available in a tenant view")
+	}
+
+	if len(changeIDs) < 1 {
+		return nil
+	}
+
+	q := goqu.Dialect(st.dbDriverName).From(st.outboxTableName).Where(goqu.C(COLUMN_ID).In(changeIDs)).Delete()
+
+	sqlStr, _, errSql := q.ToSQL()
+
+	if errSql != nil {
+		return errSql
+	}
+
+	_, err = st.exec("AckChanges", sqlStr)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -83,18 +83,51 @@ func (a *Attribute) SetUpdatedAt(updatedAt time.Time) *Attribute {
 
 // GetInt returns the value as int
 func (a *Attribute) GetInt() (int64, error) {
-	return strconv.ParseInt(a.AttributeValue(), 10, 64)
+	value, err := a.value()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(value, 10, 64)
 }
 
 // GetFloat returns the value as float
 func (a *Attribute) GetFloat() (float64, error) {
-	f64Value, err := strconv.ParseFloat(a.AttributeValue(), 64)
+	value, err := a.value()
+
+	if err != nil {
+		return 0, err
+	}
+
+	f64Value, err := strconv.ParseFloat(value, 64)
 	return f64Value, err
 }
 
-// GetString returns the value as string
+// GetString returns the value as string. An encoded value which
+// cannot be decoded is returned as an empty string
 func (a *Attribute) GetString() string {
-	return a.AttributeValue()
+	value, err := a.value()
+
+	if err != nil {
+		if a.st != nil {
+			a.st.logError("attribute value could not be decoded", err)
+		}
+		return ""
+	}
+
+	return value
+}
+
+// Value returns the value as string, failing if it is an
+// encoded value which cannot be decoded
+func (a *Attribute) Value() (string, error) {
+	return a.value()
+}
+
+// value returns the value, decoded if it is compressed, encrypted or offloaded
+func (a *Attribute) value() (string, error) {
+	return a.st.attributeValueDecode(a.AttributeKey(), a.AttributeValue())
 }
 
 // SetFloat sets a float value
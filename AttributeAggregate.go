@@ -0,0 +1,286 @@
+package entitystore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/georgysavva/scany/sqlscan"
+)
+
+const AGGREGATE_AVG = "avg"
+const AGGREGATE_COUNT = "count"
+const AGGREGATE_MAX = "max"
+const AGGREGATE_MIN = "min"
+const AGGREGATE_SUM = "sum"
+
+// AggregateOptions configures an aggregation over the values of an attribute
+type AggregateOptions struct {
+	// Func is one of AGGREGATE_SUM, AGGREGATE_AVG, AGGREGATE_MIN,
+	// AGGREGATE_MAX or AGGREGATE_COUNT
+	Func string
+
+	// GroupByAttribute groups the entities by the value of this attribute,
+	// the entities without it fall in the group with an empty value
+	GroupByAttribute string
+
+	// Where limits the aggregation to the entities having
+	// all these attribute values
+	Where map[string]string
+}
+
+// AggregateResult is the aggregated value of one group of entities
+type AggregateResult struct {
+	// Group is the value of the GroupByAttribute, empty if not grouped
+	Group string
+
+	// Value is the result of the aggregate function
+	Value float64
+
+	// Count is the number of entities in the group having the attribute
+	Count int64
+}
+
+// AttributeAggregate aggregates the numeric values of an attribute over the
+// entities of a type, optionally grouped by the value of another attribute.
+// Values which are not numbers are left out of SUM, AVG, MIN and MAX,
+// but counted. The results are sorted by group
+func (st *storeImplementation) AttributeAggregate(entityType string, attributeKey string, options AggregateOptions) (results []AggregateResult, err error) {
+	defer wrapOpError(&err, "AttributeAggregate", "")
+
+	if entityType == "" {
+		return nil, errInvalidArgument("entity type cannot be empty")
+	}
+
+	if attributeKey == "" {
+		return nil, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	if !contains([]string{AGGREGATE_SUM, AGGREGATE_AVG, AGGREGATE_MIN, AGGREGATE_MAX, AGGREGATE_COUNT}, options.Func) {
+		return nil, errInvalidArgument("unsupported aggregate function " + options.Func)
+	}
+
+	keys := []string{attributeKey}
+
+	if options.GroupByAttribute != "" {
+		keys = append(keys, options.GroupByAttribute)
+	}
+
+	for key := range options.Where {
+		keys = append(keys, key)
+	}
+
+	// sealed values are random bytes to the database
+	for _, key := range keys {
+		if st.attributeEncrypted(key) {
+			return nil, errInvalidArgument("attribute " + key + " is encrypted and cannot be aggregated")
+		}
+	}
+
+	value := goqu.I("v." + COLUMN_ATTRIBUTE_VALUE)
+
+	q := goqu.Dialect(st.dbDriverName).
+		From(goqu.T(st.attributeTableName).As("v")).
+		InnerJoin(goqu.T(st.entityTableName).As("e"), goqu.On(goqu.I("e."+COLUMN_ID).Eq(goqu.I("v."+COLUMN_ENTITY_ID)))).
+		Where(goqu.I("e."+COLUMN_ENTITY_TYPE).Eq(entityType), goqu.I("v."+COLUMN_ATTRIBUTE_KEY).Eq(attributeKey))
+
+	if st.tenantScoped {
+		q = q.Where(goqu.I("e." + COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
+
+	whereKeys := make([]string, 0, len(options.Where))
+
+	for key := range options.Where {
+		whereKeys = append(whereKeys, key)
+	}
+
+	sort.Strings(whereKeys)
+
+	for i, key := range whereKeys {
+		alias := "w" + strconv.Itoa(i)
+
+		q = q.InnerJoin(goqu.T(st.attributeTableName).As(alias), goqu.On(
+			goqu.I(alias+"."+COLUMN_ENTITY_ID).Eq(goqu.I("v."+COLUMN_ENTITY_ID)),
+			goqu.I(alias+"."+COLUMN_ATTRIBUTE_KEY).Eq(key),
+			goqu.I(alias+"."+COLUMN_ATTRIBUTE_VALUE).Eq(st.valueEscape(options.Where[key])),
+		))
+	}
+
+	groupExpression := any(goqu.L("''"))
+
+	if options.GroupByAttribute != "" {
+		q = q.LeftJoin(goqu.T(st.attributeTableName).As("g"), goqu.On(
+			goqu.I("g."+COLUMN_ENTITY_ID).Eq(goqu.I("v."+COLUMN_ENTITY_ID)),
+			goqu.I("g."+COLUMN_ATTRIBUTE_KEY).Eq(options.GroupByAttribute),
+		))
+
+		groupExpression = goqu.I("g." + COLUMN_ATTRIBUTE_VALUE)
+	}
+
+	countExpression := goqu.COUNT(goqu.I("v." + COLUMN_ID))
+	valueExpression := countExpression
+	numbersExpression := countExpression
+
+	if options.Func != AGGREGATE_COUNT {
+		number, err := st.aggregateNumber(value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the average is computed from the sum, once the groups are merged
+		function := options.Func
+
+		if function == AGGREGATE_AVG {
+			function = AGGREGATE_SUM
+		}
+
+		valueExpression = goqu.Func(function, number)
+		numbersExpression = goqu.COUNT(number)
+	}
+
+	if options.GroupByAttribute != "" {
+		q = q.GroupBy(groupExpression)
+	}
+
+	sqlStr, _, err := q.Select(
+		goqu.L("?", groupExpression).As("aggregate_group"),
+		valueExpression.As("aggregate_value"),
+		numbersExpression.As("aggregate_numbers"),
+		countExpression.As("aggregate_count"),
+	).ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// scanned into typed columns, as the string maps
+	// would round the aggregated numbers
+	type aggregateRow struct {
+		Group   sql.NullString  `db:"aggregate_group"`
+		Value   sql.NullFloat64 `db:"aggregate_value"`
+		Numbers int64           `db:"aggregate_numbers"`
+		Count   int64           `db:"aggregate_count"`
+	}
+
+	var rows []aggregateRow
+	var querier sqlscan.Querier = st.database.DB()
+
+	if tx := st.database.Tx(); tx != nil {
+		querier = tx
+	}
+
+	start := time.Now()
+	err = sqlscan.Select(context.Background(), querier, &rows, sqlStr)
+	st.queryObserve("AttributeAggregate", sqlStr, nil, start, int64(len(rows)), err)
+
+	if err != nil && !sqlscan.NotFound(err) {
+		return nil, err
+	}
+
+	// the groups are decoded, merging the values stored encoded
+	// differently (e.g. compressed above a former threshold)
+	groups := map[string]*AggregateResult{}
+	numbers := map[string]int64{}
+
+	for _, row := range rows {
+		group := ""
+
+		if row.Group.Valid {
+			group, err = st.attributeValueDecode(options.GroupByAttribute, row.Group.String)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result := groups[group]
+
+		if result == nil {
+			result = &AggregateResult{Group: group}
+			groups[group] = result
+		}
+
+		result.Count += row.Count
+
+		// the aggregate of a group without numbers is NULL, read as 0
+		if row.Numbers > 0 && row.Value.Valid {
+			switch {
+			case options.Func == AGGREGATE_MIN && numbers[group] > 0:
+				result.Value = min(result.Value, row.Value.Float64)
+			case options.Func == AGGREGATE_MAX && numbers[group] > 0:
+				result.Value = max(result.Value, row.Value.Float64)
+			case options.Func == AGGREGATE_MIN, options.Func == AGGREGATE_MAX:
+				result.Value = row.Value.Float64
+			default:
+				result.Value += row.Value.Float64
+			}
+		}
+
+		numbers[group] += row.Numbers
+	}
+
+	results = []AggregateResult{}
+
+	for group, result := range groups {
+		if options.Func == AGGREGATE_AVG && numbers[group] > 0 {
+			result.Value /= float64(numbers[group])
+		}
+
+		results = append(results, *result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Group < results[j].Group
+	})
+
+	return results, nil
+}
+
+// aggregateNumber casts the attribute value to a number for the dialect,
+// values which do not look like numbers become NULL
+func (st *storeImplementation) aggregateNumber(value exp.IdentifierExpression) (exp.LiteralExpression, error) {
+	switch st.dbDriverName {
+	case "mysql":
+		return goqu.L("CASE WHEN ? REGEXP '^[[:space:]]*[-+]{0,1}[0-9]*[.]{0,1}[0-9]+([eE][-+]{0,1}[0-9]+){0,1}[[:space:]]*$' THEN CAST(? AS DECIMAL(65,30)) END", value, value), nil
+	case "postgres":
+		return goqu.L("CASE WHEN ? ~ '^[[:space:]]*[-+]{0,1}[0-9]*[.]{0,1}[0-9]+([eE][-+]{0,1}[0-9]+){0,1}[[:space:]]*$' THEN CAST(? AS NUMERIC) END", value, value), nil
+	case "sqlite":
+		return aggregateNumberSQLite(value), nil
+	}
+
+	return nil, errors.New("unsupported driver " + st.dbDriverName)
+}
+
+// aggregateNumberSQLite casts the attribute value to a number for SQLite,
+// which has no regular expressions: the trimmed value is split at the
+// exponent, and both parts are matched with GLOB like the expressions above
+func aggregateNumberSQLite(value exp.IdentifierExpression) exp.LiteralExpression {
+	text := "TRIM(?, char(9, 10, 12, 13, 32))"
+	exponentAt := "instr(lower(" + text + "), 'e')"
+	mantissa := "(CASE WHEN " + exponentAt + " > 0 THEN substr(" + text + ", 1, " + exponentAt + " - 1) ELSE " + text + " END)"
+	exponent := "(CASE WHEN " + exponentAt + " > 0 THEN substr(" + text + ", " + exponentAt + " + 1) ELSE '0' END)"
+	mantissaDigits := "(CASE WHEN " + mantissa + " GLOB '[-+]*' THEN substr(" + mantissa + ", 2) ELSE " + mantissa + " END)"
+	exponentDigits := "(CASE WHEN " + exponent + " GLOB '[-+]*' THEN substr(" + exponent + ", 2) ELSE " + exponent + " END)"
+
+	isNumber := mantissaDigits + " GLOB '*[0-9]' AND " +
+		mantissaDigits + " NOT GLOB '*[^0-9.]*' AND " +
+		mantissaDigits + " NOT GLOB '*.*.*' AND " +
+		exponentDigits + " GLOB '[0-9]*' AND " +
+		exponentDigits + " NOT GLOB '*[^0-9]*'"
+
+	sqlStr := "CASE WHEN " + isNumber + " THEN CAST(" + text + " AS REAL) END"
+	args := make([]any, strings.Count(sqlStr, "?"))
+
+	for i := range args {
+		args[i] = value
+	}
+
+	return goqu.L(sqlStr, args...)
+}
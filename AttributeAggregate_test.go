@@ -0,0 +1,135 @@
+package entitystore
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAttributeAggregate(t *testing.T) {
+	db := InitDB("test_attribute_aggregate.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	orders := []map[string]string{
+		{"status": "paid", "amount": "10.5", "currency": "EUR"},
+		{"status": "paid", "amount": "20", "currency": "EUR"},
+		{"status": "pending", "amount": "5", "currency": "EUR"},
+		{"status": "pending", "amount": "n/a", "currency": "EUR"},
+		{"status": "paid", "amount": "100", "currency": "USD"},
+		{"amount": "1", "currency": "EUR"},
+	}
+
+	for _, order := range orders {
+		_, err := store.EntityCreateWithTypeAndAttributes("order", order)
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	results, err := store.AttributeAggregate("order", "amount", AggregateOptions{
+		Func:             AGGREGATE_SUM,
+		GroupByAttribute: "status",
+		Where:            map[string]string{"currency": "EUR"},
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	expected := []AggregateResult{
+		{Group: "", Value: 1, Count: 1},
+		{Group: "paid", Value: 30.5, Count: 2},
+		{Group: "pending", Value: 5, Count: 2},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatal("Must have", len(expected), "groups, found:", results)
+	}
+
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatal("Group", i, "must be", expected[i], "found:", results[i])
+		}
+	}
+
+	cases := map[string]float64{
+		AGGREGATE_AVG:   136.5 / 5,
+		AGGREGATE_MIN:   1,
+		AGGREGATE_MAX:   100,
+		AGGREGATE_COUNT: 6,
+	}
+
+	for function, value := range cases {
+		results, err := store.AttributeAggregate("order", "amount", AggregateOptions{Func: function})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if len(results) != 1 || results[0].Value != value {
+			t.Fatal("The", function, "must be", value, "found:", results)
+		}
+	}
+
+	_, err = store.AttributeAggregate("order", "amount", AggregateOptions{Func: "median"})
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("An unsupported function must be an invalid argument, found:", err)
+	}
+}
+
+func TestAttributeAggregateEncodedGroups(t *testing.T) {
+	db := InitDB("test_attribute_aggregate_encoded.db")
+
+	newStore := func(compressionThreshold int) StoreInterface {
+		store, err := NewStore(NewStoreOptions{
+			DB:                        db,
+			EntityTableName:           "cms_entity",
+			AttributeTableName:        "cms_attribute",
+			ValueCompression:          VALUE_COMPRESSION_GZIP,
+			ValueCompressionThreshold: compressionThreshold,
+			AutomigrateEnabled:        true,
+		})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		return store
+	}
+
+	status := strings.Repeat("awaiting the payment, ", 10)
+
+	// the same status stored plain, then compressed after lowering the threshold
+	for i, store := range []StoreInterface{newStore(1000), newStore(10)} {
+		_, err := store.EntityCreateWithTypeAndAttributes("order", map[string]string{"status": status, "amount": strconv.Itoa(10 * (i + 1))})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	for function, value := range map[string]float64{AGGREGATE_SUM: 30, AGGREGATE_AVG: 15, AGGREGATE_MIN: 10, AGGREGATE_MAX: 20, AGGREGATE_COUNT: 2} {
+		results, err := newStore(10).AttributeAggregate("order", "amount", AggregateOptions{Func: function, GroupByAttribute: "status"})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if len(results) != 1 || results[0].Group != status || results[0].Value != value || results[0].Count != 2 {
+			t.Fatal("The", function, "must merge the decoded groups into", value, "found:", results)
+		}
+	}
+}
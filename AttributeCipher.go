@@ -0,0 +1,160 @@
+package entitystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// sealedValuePrefix marks the attribute values sealed by the AES-GCM cipher,
+// the format is enc:<key ID>:<base64 of nonce and ciphertext>
+const sealedValuePrefix = "enc:"
+
+// AttributeCipher seals (encrypts) and opens (decrypts) attribute values.
+// The sealed values carry the ID of the key used, so keys can be rotated
+type AttributeCipher interface {
+	// Seal encrypts the value with the current key
+	Seal(value string) (string, error)
+	// Open decrypts a value sealed with any of the known keys
+	Open(sealed string) (string, error)
+	// KeyID returns the ID of the key the value was sealed with,
+	// or an empty string if the value is not sealed
+	KeyID(value string) string
+	// CurrentKeyID returns the ID of the key used for sealing
+	CurrentKeyID() string
+}
+
+type aesGCMCipher struct {
+	keys         map[string]cipher.AEAD
+	currentKeyID string
+}
+
+var _ AttributeCipher = (*aesGCMCipher)(nil)
+
+// NewAESGCMCipher creates an AES-GCM attribute cipher. The keys are mapped
+// by key ID and must be 16, 24 or 32 bytes long. New values are sealed with
+// the current key, while the other keys are kept to open older values
+func NewAESGCMCipher(keys map[string][]byte, currentKeyID string) (AttributeCipher, error) {
+	if _, exists := keys[currentKeyID]; !exists {
+		return nil, errors.New("entity store: current key " + currentKeyID + " is not among the keys")
+	}
+
+	c := &aesGCMCipher{
+		keys:         map[string]cipher.AEAD{},
+		currentKeyID: currentKeyID,
+	}
+
+	for keyID, key := range keys {
+		if keyID == "" || strings.Contains(keyID, ":") {
+			return nil, errors.New("entity store: key ID cannot be empty or contain a colon")
+		}
+
+		block, err := aes.NewCipher(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		aead, err := cipher.NewGCM(block)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.keys[keyID] = aead
+	}
+
+	return c, nil
+}
+
+func (c *aesGCMCipher) Seal(value string) (string, error) {
+	aead := c.keys[c.currentKeyID]
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(c.currentKeyID))
+
+	return sealedValuePrefix + c.currentKeyID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *aesGCMCipher) Open(sealed string) (string, error) {
+	keyID := c.KeyID(sealed)
+
+	if keyID == "" {
+		return "", errors.New("entity store: value is not sealed")
+	}
+
+	aead, exists := c.keys[keyID]
+
+	if !exists {
+		return "", errors.New("entity store: unknown key " + keyID)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed[len(sealedValuePrefix)+len(keyID)+1:])
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("entity store: sealed value is too short")
+	}
+
+	value, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(keyID))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+func (c *aesGCMCipher) KeyID(value string) string {
+	if !strings.HasPrefix(value, sealedValuePrefix) {
+		return ""
+	}
+
+	keyID, _, found := strings.Cut(value[len(sealedValuePrefix):], ":")
+
+	if !found {
+		return ""
+	}
+
+	return keyID
+}
+
+func (c *aesGCMCipher) CurrentKeyID() string {
+	return c.currentKeyID
+}
+
+// attributeEncrypted returns true if the values of the attribute key are encrypted
+func (st *storeImplementation) attributeEncrypted(attributeKey string) bool {
+	if st.attributeCipher == nil {
+		return false
+	}
+
+	if st.encryptAttributeKey != nil {
+		return st.encryptAttributeKey(attributeKey)
+	}
+
+	return contains(st.encryptedAttributeKeys, attributeKey)
+}
+
+// attributeValueOpen opens the sealed value of an encrypted attribute key.
+// The values of the other keys are returned as they are, whatever they look
+// like, as are the values kept before the key was encrypted, not yet resealed
+func (st *storeImplementation) attributeValueOpen(attributeKey string, value string) (string, error) {
+	if !st.attributeEncrypted(attributeKey) || st.attributeCipher.KeyID(value) == "" {
+		return value, nil
+	}
+
+	return st.attributeCipher.Open(value)
+}
@@ -0,0 +1,314 @@
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAttributeCipher(t *testing.T) {
+	db := InitDB("test_attribute_cipher.db")
+
+	keys := map[string][]byte{
+		"k1": []byte("0123456789abcdef0123456789abcdef"),
+		"k2": []byte("fedcba9876543210fedcba9876543210"),
+	}
+
+	cipher1, err := NewAESGCMCipher(keys, "k1")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                        db,
+		EntityTableName:           "cms_entity",
+		AttributeTableName:        "cms_attribute",
+		AttributeHistoryTableName: "cms_attribute_history",
+		AttributeCipher:           cipher1,
+		EncryptedAttributeKeys:    []string{"ssn"},
+		AutomigrateEnabled:        true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	person, err := store.EntityCreateWithTypeAndAttributes("person", map[string]string{
+		"name": "John",
+		"ssn":  "123-45-6789",
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	rawValue := func(key string) string {
+		var value string
+		err := db.QueryRow(`SELECT attribute_value FROM cms_attribute WHERE entity_id = ? AND attribute_key = ?`, person.ID(), key).Scan(&value)
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+		return value
+	}
+
+	if !strings.HasPrefix(rawValue("ssn"), "enc:k1:") {
+		t.Fatal("SSN must be stored encrypted with k1, found:", rawValue("ssn"))
+	}
+
+	if rawValue("name") != "John" {
+		t.Fatal("Name must be stored as plain text, found:", rawValue("name"))
+	}
+
+	ssn, err := person.GetString("ssn", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if ssn != "123-45-6789" {
+		t.Fatal("SSN must be decrypted, found:", ssn)
+	}
+
+	err = person.SetString("ssn", "987-65-4321")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	history, err := store.AttributeHistory(person.ID(), "ssn")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(history) != 2 || history[1].OldValue != "123-45-6789" || history[1].NewValue != "987-65-4321" {
+		t.Fatal("History must be decrypted:", history)
+	}
+
+	keyList, err := store.AttributeKeyList("person")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(keyList) != 2 || keyList[0].SampleValue != "John" || keyList[1].AttributeKey != "ssn" || keyList[1].SampleValue != "" {
+		t.Fatal("Sample of the encrypted key must be empty, found:", keyList)
+	}
+
+	// rotate to k2
+	cipher2, err := NewAESGCMCipher(keys, "k2")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	rotated, err := NewStore(NewStoreOptions{
+		DB:                        db,
+		EntityTableName:           "cms_entity",
+		AttributeTableName:        "cms_attribute",
+		AttributeHistoryTableName: "cms_attribute_history",
+		AttributeCipher:           cipher2,
+		EncryptAttributeKey:       func(key string) bool { return key == "ssn" },
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	count, err := rotated.ReencryptAll()
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// the attribute, plus the create and update history entries
+	if count != 4 {
+		t.Fatal("Re-encrypted values must be 4, found:", count)
+	}
+
+	if !strings.HasPrefix(rawValue("ssn"), "enc:k2:") {
+		t.Fatal("SSN must be stored encrypted with k2, found:", rawValue("ssn"))
+	}
+
+	attr, err := rotated.AttributeFind(person.ID(), "ssn")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if attr == nil || attr.GetString() != "987-65-4321" {
+		t.Fatal("SSN must be decrypted after rotation")
+	}
+
+	count, err = rotated.ReencryptAll()
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 0 {
+		t.Fatal("Nothing must be left to re-encrypt, found:", count)
+	}
+}
+
+func TestAttributeCipherSealedLookingValues(t *testing.T) {
+	db := InitDB("test_attribute_cipher_sealed_looking.db")
+
+	cipher, err := NewAESGCMCipher(map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")}, "k1")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                     db,
+		EntityTableName:        "cms_entity",
+		AttributeTableName:     "cms_attribute",
+		AttributeCipher:        cipher,
+		EncryptedAttributeKeys: []string{"ssn"},
+		AutomigrateEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	sealedSSN, err := cipher.Seal("123-45-6789")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	person, err := store.EntityCreateWithTypeAndAttributes("person", map[string]string{
+		"name": "enc:k1:hello",
+		"ssn":  sealedSSN,
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	rawValue := func(key string) string {
+		var value string
+		err := db.QueryRow(`SELECT attribute_value FROM cms_attribute WHERE entity_id = ? AND attribute_key = ?`, person.ID(), key).Scan(&value)
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+		return value
+	}
+
+	// the values of the other keys are never opened
+	name, err := person.GetString("name", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if name != "enc:k1:hello" || rawValue("name") != "enc:k1:hello" {
+		t.Fatal("Name must be kept as it is, found:", name, rawValue("name"))
+	}
+
+	// the values of the encrypted keys are always sealed
+	if rawValue("ssn") == sealedSSN {
+		t.Fatal("SSN must be sealed again")
+	}
+
+	ssn, err := person.GetString("ssn", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if ssn != sealedSSN {
+		t.Fatal("SSN must read back as it was set, found:", ssn)
+	}
+}
+
+func TestAttributeCipherQueries(t *testing.T) {
+	cipher, err := NewAESGCMCipher(map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")}, "k1")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                     InitDB("test_attribute_cipher_queries.db"),
+		EntityTableName:        "cms_entity",
+		AttributeTableName:     "cms_attribute",
+		AttributeCipher:        cipher,
+		EncryptedAttributeKeys: []string{"ssn"},
+		AutomigrateEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	person, err := store.EntityCreateWithType("person")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// the first write is rolled back after the value was sealed
+	failed := false
+
+	store.AfterAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		if attribute.AttributeValue() != "123-45-6789" {
+			t.Fatal("The hooks must receive the plain value, found:", attribute.AttributeValue())
+		}
+
+		if !failed {
+			failed = true
+			return errors.New("failed")
+		}
+
+		return nil
+	})
+
+	attribute := store.NewAttribute(NewAttributeOptions{EntityID: person.ID(), AttributeKey: "ssn", AttributeValue: "123-45-6789"})
+
+	if err := store.AttributeCreate(&attribute); err == nil {
+		t.Fatal("The first write must fail")
+	}
+
+	if err := store.AttributeCreate(&attribute); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	ssn, err := person.GetString("ssn", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if ssn != "123-45-6789" {
+		t.Fatal("A retried write must seal the value once, found:", ssn)
+	}
+
+	_, err = store.EntityFindByAttribute("person", "ssn", "123-45-6789")
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument:", err)
+	}
+
+	_, err = store.EntityListByAttribute("person", "ssn", "123-45-6789")
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument:", err)
+	}
+
+	_, err = store.EntityList(EntityQueryOptions{EntityType: "person", Where: map[string]string{"ssn": "123-45-6789"}})
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument:", err)
+	}
+
+	_, err = store.EntityCount(EntityQueryOptions{EntityType: "person", SortByAttribute: "ssn"})
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument:", err)
+	}
+}
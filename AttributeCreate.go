@@ -1,8 +1,6 @@
 package entitystore
 
 import (
-	"errors"
-	"log"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
@@ -10,13 +8,23 @@ import (
 )
 
 // AttributeCreate creates a new attribute
-func (st *storeImplementation) AttributeCreate(attr *Attribute) error {
+func (st *storeImplementation) AttributeCreate(attr *Attribute) (err error) {
+	defer func() {
+		entityID := ""
+
+		if attr != nil {
+			entityID = attr.EntityID()
+		}
+
+		wrapOpError(&err, "AttributeCreate", entityID)
+	}()
+
 	if attr == nil {
-		return errors.New("attribute is required")
+		return errInvalidArgument("attribute is required")
 	}
 
 	if attr.AttributeKey() == "" {
-		return errors.New("attribute key is required field")
+		return errInvalidArgument("attribute key is required")
 	}
 
 	if attr.ID() == "" {
@@ -31,22 +39,55 @@ func (st *storeImplementation) AttributeCreate(attr *Attribute) error {
 		attr.SetUpdatedAt(time.Now())
 	}
 
-	q := goqu.Dialect(st.dbDriverName).Insert(st.attributeTableName)
-	q = q.Rows(attr.ToMap())
-	sqlStr, _, _ := q.ToSQL()
+	return st.inTransaction(func(tx *storeImplementation) error {
+		if err := tx.tenantEntityCheck(attr.EntityID()); err != nil {
+			return err
+		}
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
+		if err := tx.attributeHooksRun(hookBeforeAttributeSet, attr); err != nil {
+			return err
+		}
 
-	_, err := st.database.Exec(sqlStr)
+		row, err := tx.attributeEncode(*attr)
 
-	if err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if err != nil {
+			return err
+		}
+
+		q := goqu.Dialect(tx.dbDriverName).Insert(tx.attributeTableName)
+		q = q.Rows(row.ToMap())
+		sqlStr, _, _ := q.ToSQL()
+
+		_, err = tx.exec("AttributeCreate", sqlStr)
+
+		if err != nil {
+			return err
+		}
+
+		tx.cacheInvalidate(cacheKeyAttribute(attr.EntityID(), attr.AttributeKey()))
+
+		if err := tx.projectionSync(attr.EntityID()); err != nil {
+			return err
+		}
+
+		err = tx.attributeHistoryRecord(attr.EntityID(), attr.AttributeKey(), OPERATION_CREATE, "", row.AttributeValue())
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.entityVersionIncrement(attr.EntityID())
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.changeRecordAttribute(CHANGE_ATTRIBUTE_SET, &row)
+
+		if err != nil {
+			return err
 		}
-		return err
-	}
 
-	return nil
+		return tx.attributeHooksRun(hookAfterAttributeSet, attr)
+	})
 }
@@ -9,7 +9,9 @@ import (
 // AttributeCreateWithKeyAndValue shortcut to create a new attribute
 // by providing only the key and value
 // NN. The ID will be auto-assigned
-func (st *storeImplementation) AttributeCreateWithKeyAndValue(entityID string, attributeKey string, attributeValue string) (*Attribute, error) {
+func (st *storeImplementation) AttributeCreateWithKeyAndValue(entityID string, attributeKey string, attributeValue string) (attr *Attribute, err error) {
+	defer wrapOpError(&err, "AttributeCreateWithKeyAndValue", entityID)
+
 	newAttribute := st.NewAttribute(NewAttributeOptions{
 		ID:             uid.HumanUid(),
 		EntityID:       entityID,
@@ -19,7 +21,7 @@ func (st *storeImplementation) AttributeCreateWithKeyAndValue(entityID string, a
 		UpdatedAt:      time.Now(),
 	})
 
-	err := st.AttributeCreate(&newAttribute)
+	err = st.AttributeCreate(&newAttribute)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,81 @@
+package entitystore
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// AttributeDelete deletes an attribute of an entity.
+// Returns ErrAttributeNotFound if the attribute does not exist
+func (st *storeImplementation) AttributeDelete(entityID string, attributeKey string) (isDeleted bool, err error) {
+	defer wrapOpError(&err, "AttributeDelete", entityID)
+
+	if entityID == "" {
+		return false, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if attributeKey == "" {
+		return false, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	attr, err := st.AttributeFind(entityID, attributeKey)
+
+	if err != nil {
+		return false, err
+	}
+
+	if attr == nil {
+		return false, fmt.Errorf("%w: attribute %s does not exist", ErrAttributeNotFound, attributeKey)
+	}
+
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		if err := tx.attributeHooksRun(hookBeforeAttributeDelete, attr); err != nil {
+			return err
+		}
+
+		q := goqu.Dialect(tx.dbDriverName).From(tx.attributeTableName).Where(goqu.C(COLUMN_ID).Eq(attr.ID())).Delete()
+
+		sqlStr, _, errSql := q.ToSQL()
+
+		if errSql != nil {
+			return errSql
+		}
+
+		if _, err := tx.exec("AttributeDelete", sqlStr); err != nil {
+			return err
+		}
+
+		tx.cacheInvalidate(cacheKeyAttribute(entityID, attributeKey))
+
+		if err := tx.projectionSync(entityID); err != nil {
+			return err
+		}
+
+		err := tx.attributeHistoryRecord(entityID, attributeKey, OPERATION_DELETE, attr.AttributeValue(), "")
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.entityVersionIncrement(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.changeRecordAttribute(CHANGE_ATTRIBUTE_DELETE, attr)
+
+		if err != nil {
+			return err
+		}
+
+		return tx.attributeHooksRun(hookAfterAttributeDelete, attr)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
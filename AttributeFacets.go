@@ -0,0 +1,116 @@
+package entitystore
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// FacetOptions configures the facet counts of AttributeFacets
+type FacetOptions struct {
+	// Filter selects the entities counted, like in EntityList.
+	// The limit and the offset are ignored, the entity type is
+	// the one given to AttributeFacets
+	Filter EntityQueryOptions
+
+	// Limit keeps the most frequent values of each attribute, all if 0
+	Limit uint64
+}
+
+// FacetValue is a distinct value of an attribute with the number of entities having it
+type FacetValue struct {
+	Value string
+	Count int64
+}
+
+// AttributeFacets counts the entities of a type having each distinct value
+// of the attributes, among the entities matching the filter. The values
+// of each attribute key are sorted by count, the most frequent first
+func (st *storeImplementation) AttributeFacets(entityType string, attributeKeys []string, options FacetOptions) (facets map[string][]FacetValue, err error) {
+	defer wrapOpError(&err, "AttributeFacets", "")
+
+	if entityType == "" {
+		return nil, errInvalidArgument("entity type cannot be empty")
+	}
+
+	for _, key := range attributeKeys {
+		if key == "" {
+			return nil, errInvalidArgument("attribute key cannot be empty")
+		}
+
+		// sealed values are random bytes to the database
+		if st.attributeEncrypted(key) {
+			return nil, errInvalidArgument("attribute " + key + " is encrypted and cannot be faceted")
+		}
+	}
+
+	filter := options.Filter
+	filter.EntityType = entityType
+	filter.CountOnly = true
+
+	if err := st.entityQueryCheck(filter); err != nil {
+		return nil, err
+	}
+
+	entityIDs := st.EntityQuery(filter).ClearOrder().Select(goqu.C(COLUMN_ID))
+
+	facets = map[string][]FacetValue{}
+
+	for _, key := range attributeKeys {
+		q := goqu.Dialect(st.dbDriverName).
+			From(st.attributeTableName).
+			Where(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(key), goqu.C(COLUMN_ENTITY_ID).In(entityIDs)).
+			GroupBy(goqu.C(COLUMN_ATTRIBUTE_VALUE)).
+			Select(goqu.C(COLUMN_ATTRIBUTE_VALUE).As("facet_value"), goqu.COUNT(goqu.C(COLUMN_ENTITY_ID)).As("facet_count")).
+			Order(goqu.C("facet_count").Desc(), goqu.C("facet_value").Asc())
+
+		if options.Limit > 0 {
+			q = q.Limit(uint(options.Limit))
+		}
+
+		sqlStr, _, err := q.ToSQL()
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := st.selectToMapString("AttributeFacets", sqlStr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values := []FacetValue{}
+
+		for _, row := range rows {
+			value, err := st.attributeValueDecode(key, row["facet_value"])
+
+			if err != nil {
+				return nil, err
+			}
+
+			count, err := strconv.ParseInt(row["facet_count"], 10, 64)
+
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, FacetValue{Value: value, Count: count})
+		}
+
+		// sorted again, as the decoded values of long texts
+		// may not be in the order of the stored ones
+		sort.SliceStable(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+
+			return values[i].Value < values[j].Value
+		})
+
+		facets[key] = values
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,86 @@
+package entitystore
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAttributeFacets(t *testing.T) {
+	db := InitDB("test_attribute_facets.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	products := []map[string]string{
+		{"status": "active", "color": "red"},
+		{"status": "active", "color": "blue"},
+		{"status": "active", "color": "red"},
+		{"status": "pending", "color": "green"},
+		{"status": "draft"},
+	}
+
+	for _, product := range products {
+		_, err := store.EntityCreateWithTypeAndAttributes("product", product)
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	_, err = store.EntityCreateWithTypeAndAttributes("post", map[string]string{"status": "active"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	facets, err := store.AttributeFacets("product", []string{"status", "color"}, FacetOptions{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	expected := map[string][]FacetValue{
+		"status": {{"active", 3}, {"draft", 1}, {"pending", 1}},
+		"color":  {{"red", 2}, {"blue", 1}, {"green", 1}},
+	}
+
+	if !reflect.DeepEqual(facets, expected) {
+		t.Fatal("Facets must be", expected, "found:", facets)
+	}
+
+	facets, err = store.AttributeFacets("product", []string{"status"}, FacetOptions{Limit: 2})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !reflect.DeepEqual(facets["status"], []FacetValue{{"active", 3}, {"draft", 1}}) {
+		t.Fatal("Must keep the 2 most frequent values, found:", facets["status"])
+	}
+
+	archived, err := store.EntityCreateWithTypeAndAttributes("product", map[string]string{"status": "archived"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	facets, err = store.AttributeFacets("product", []string{"status"}, FacetOptions{
+		Filter: EntityQueryOptions{ID: archived.ID(), Limit: 1, Offset: 5},
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !reflect.DeepEqual(facets["status"], []FacetValue{{"archived", 1}}) {
+		t.Fatal("Must count the entities matching the filter, found:", facets["status"])
+	}
+}
@@ -1,17 +1,35 @@
 package entitystore
 
-import "errors"
-
 // AttributeFind finds an entity by ID
-func (st *storeImplementation) AttributeFind(entityID string, attributeKey string) (*Attribute, error) {
+func (st *storeImplementation) AttributeFind(entityID string, attributeKey string) (attr *Attribute, err error) {
+	defer wrapOpError(&err, "AttributeFind", entityID)
+
 	if entityID == "" {
-		return nil, errors.New("entity id cannot be empty")
+		return nil, errInvalidArgument("entity id cannot be empty")
 	}
 
 	if attributeKey == "" {
-		return nil, errors.New("attribute key cannot be empty")
+		return nil, errInvalidArgument("attribute key cannot be empty")
 	}
 
+	if cached, exists := st.cacheGet(cacheKeyAttribute(entityID, attributeKey)); exists {
+		attr := cached.(Attribute)
+
+		if st.tenantScoped {
+			entity, err := st.EntityFindByID(entityID)
+
+			if err != nil || entity == nil {
+				return nil, err
+			}
+		}
+
+		attr.st = st
+
+		return &attr, nil
+	}
+
+	generation := st.cacheGeneration()
+
 	list, err := st.AttributeList(AttributeQueryOptions{
 		EntityID:     entityID,
 		AttributeKey: attributeKey,
@@ -23,6 +41,7 @@ func (st *storeImplementation) AttributeFind(entityID string, attributeKey strin
 	}
 
 	if len(list) > 0 {
+		st.cacheSet(cacheKeyAttribute(entityID, attributeKey), list[0], generation)
 		return &list[0], nil
 	}
 
@@ -1,19 +1,19 @@
 package entitystore
 
-import "errors"
-
 // AttributeFind finds an entity by ID
-func (st *storeImplementation) AttributeFindByHandle(entityType string, entityHandle string, attributeKey string) (*Attribute, error) {
+func (st *storeImplementation) AttributeFindByHandle(entityType string, entityHandle string, attributeKey string) (attr *Attribute, err error) {
+	defer wrapOpError(&err, "AttributeFindByHandle", "")
+
 	if entityType == "" {
-		return nil, errors.New("entity type cannot be empty")
+		return nil, errInvalidArgument("entity type cannot be empty")
 	}
 
 	if entityHandle == "" {
-		return nil, errors.New("entity handle cannot be empty")
+		return nil, errInvalidArgument("entity handle cannot be empty")
 	}
 
 	if attributeKey == "" {
-		return nil, errors.New("attribute key cannot be empty")
+		return nil, errInvalidArgument("attribute key cannot be empty")
 	}
 
 	list, err := st.AttributeList(AttributeQueryOptions{
@@ -0,0 +1,14 @@
+package entitystore
+
+// AttributeHistory lists the changes of an entity attribute, oldest first
+func (st *storeImplementation) AttributeHistory(entityID string, attributeKey string) (entries []AttributeHistoryEntry, err error) {
+	defer wrapOpError(&err, "AttributeHistory", entityID)
+
+	if attributeKey == "" {
+		return nil, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	return st.EntityHistory(entityID, EntityHistoryOptions{
+		AttributeKey: attributeKey,
+	})
+}
@@ -0,0 +1,32 @@
+package entitystore
+
+import (
+	"time"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// AttributeHistoryEntry is a single change of an attribute value
+type AttributeHistoryEntry struct {
+	ID           string    `db:"id"`
+	EntityID     string    `db:"entity_id"`
+	AttributeKey string    `db:"attribute_key"`
+	Operation    string    `db:"operation"` // create / update / delete
+	OldValue     string    `db:"old_value"`
+	NewValue     string    `db:"new_value"`
+	ChangedAt    time.Time `db:"changed_at"`
+	ChangedBy    string    `db:"changed_by"`
+}
+
+func newAttributeHistoryEntryFromMap(entryMap map[string]string) AttributeHistoryEntry {
+	return AttributeHistoryEntry{
+		ID:           entryMap[COLUMN_ID],
+		EntityID:     entryMap[COLUMN_ENTITY_ID],
+		AttributeKey: entryMap[COLUMN_ATTRIBUTE_KEY],
+		Operation:    entryMap[COLUMN_OPERATION],
+		OldValue:     entryMap[COLUMN_OLD_VALUE],
+		NewValue:     entryMap[COLUMN_NEW_VALUE],
+		ChangedAt:    carbon.Parse(entryMap[COLUMN_CHANGED_AT], carbon.UTC).StdTime(),
+		ChangedBy:    entryMap[COLUMN_CHANGED_BY],
+	}
+}
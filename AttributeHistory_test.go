@@ -0,0 +1,171 @@
+package entitystore
+
+import (
+	"database/sql"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestAttributeHistory(t *testing.T) {
+	db := InitDB("test_attribute_history.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                        db,
+		EntityTableName:           "cms_entity",
+		AttributeTableName:        "cms_attribute",
+		AttributeHistoryTableName: "cms_attribute_history",
+		AutomigrateEnabled:        true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.WithActor("USER_01").EntityCreateWithType("post")
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	err = entity.SetString("title", "Title 1")
+
+	if err != nil {
+		t.Fatal("Entity title could not be set: " + err.Error())
+	}
+
+	err = store.AttributesSet(entity.ID(), map[string]string{"title": "Title 2", "text": "Text 1"})
+
+	if err != nil {
+		t.Fatal("Entity attributes could not be set: " + err.Error())
+	}
+
+	isDeleted, err := store.AttributeDelete(entity.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Attribute could not be deleted: " + err.Error())
+	}
+
+	if !isDeleted {
+		t.Fatal("Attribute must be deleted")
+	}
+
+	history, err := store.AttributeHistory(entity.ID(), "title")
+
+	if err != nil {
+		t.Fatal("History could not be listed: " + err.Error())
+	}
+
+	if len(history) != 3 {
+		t.Fatal("History must have 3 entries, found: ", len(history))
+	}
+
+	expected := []AttributeHistoryEntry{
+		{Operation: OPERATION_CREATE, OldValue: "", NewValue: "Title 1", ChangedBy: "USER_01"},
+		{Operation: OPERATION_UPDATE, OldValue: "Title 1", NewValue: "Title 2"},
+		{Operation: OPERATION_DELETE, OldValue: "Title 2", NewValue: ""},
+	}
+
+	for i, entry := range history {
+		if entry.Operation != expected[i].Operation {
+			t.Fatal("Operation mismatch at", i, ":", entry.Operation)
+		}
+
+		if entry.OldValue != expected[i].OldValue || entry.NewValue != expected[i].NewValue {
+			t.Fatal("Value mismatch at", i, ":", entry.OldValue, "->", entry.NewValue)
+		}
+
+		if entry.ChangedBy != expected[i].ChangedBy {
+			t.Fatal("Actor mismatch at", i, ":", entry.ChangedBy)
+		}
+
+		if entry.ChangedAt.IsZero() {
+			t.Fatal("ChangedAt must be set at", i)
+		}
+	}
+
+	entityHistory, err := store.EntityHistory(entity.ID(), EntityHistoryOptions{})
+
+	if err != nil {
+		t.Fatal("History could not be listed: " + err.Error())
+	}
+
+	if len(entityHistory) != 4 {
+		t.Fatal("Entity history must have 4 entries, found: ", len(entityHistory))
+	}
+}
+
+func TestAttributeHistoryConcurrentWrites(t *testing.T) {
+	_ = InitDB("test_attribute_history_concurrent.db").Close()
+
+	// the writers wait for each other, instead of failing to upgrade their locks
+	db, err := sql.Open("sqlite3", "test_attribute_history_concurrent.db?_txlock=immediate")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                        db,
+		EntityTableName:           "cms_entity",
+		AttributeTableName:        "cms_attribute",
+		AttributeHistoryTableName: "cms_attribute_history",
+		AutomigrateEnabled:        true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	workers := 10
+	entityIDs := make([]string, workers)
+	errs := make(chan error, workers)
+	wg := sync.WaitGroup{}
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			entity, err := store.EntityCreateWithType("post")
+
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			entityIDs[worker] = entity.ID()
+
+			for i := 0; i < 10; i++ {
+				if err := entity.SetString("title", "Title "+strconv.Itoa(worker)+" "+strconv.Itoa(i)); err != nil {
+					errs <- err
+					return
+				}
+			}
+
+			if _, err := store.AttributeDelete(entity.ID(), "title"); err != nil {
+				errs <- err
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	for _, entityID := range entityIDs {
+		history, err := store.AttributeHistory(entityID, "title")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if len(history) != 11 {
+			t.Fatal("History must have 11 entries, found: ", len(history))
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package entitystore
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// attributeKeySampleLength the sample values are cut to this number of characters
+const attributeKeySampleLength = 100
+
+// attributeKeyListBatchSize the number of attributes read at once by AttributeKeyList
+const attributeKeyListBatchSize = 1000
+
+// AttributeKeyInfo describes an attribute key in use
+type AttributeKeyInfo struct {
+	AttributeKey string
+
+	// Count is the number of entities having the attribute
+	Count int64
+
+	// SampleValue is the value of the oldest attribute,
+	// cut to 100 characters, empty for the encrypted keys
+	SampleValue string
+
+	// ValueType is the type all the values fit, one of VALUE_TYPE_INT,
+	// VALUE_TYPE_FLOAT, VALUE_TYPE_BOOL, VALUE_TYPE_TIME, VALUE_TYPE_JSON
+	// or VALUE_TYPE_STRING
+	ValueType string
+
+	// MaxLength is the length of the longest value, in characters
+	MaxLength int64
+}
+
+// AttributeKeyList lists the attribute keys used by the entities of a type
+// (all the types if empty), sorted by key. The values are read decoded,
+// so it reads all the attributes of the type, a batch at a time
+func (st *storeImplementation) AttributeKeyList(entityType string) (keys []AttributeKeyInfo, err error) {
+	defer wrapOpError(&err, "AttributeKeyList", "")
+
+	entityIDs := st.EntityQuery(EntityQueryOptions{EntityType: entityType, CountOnly: true}).
+		ClearOrder().
+		Select(goqu.C(COLUMN_ID))
+
+	keysByName := map[string]*AttributeKeyInfo{}
+	lastID := ""
+
+	for {
+		q := goqu.Dialect(st.dbDriverName).
+			From(st.attributeTableName).
+			Where(goqu.C(COLUMN_ENTITY_ID).In(entityIDs)).
+			Select(goqu.C(COLUMN_ID), goqu.C(COLUMN_ATTRIBUTE_KEY), goqu.C(COLUMN_ATTRIBUTE_VALUE)).
+			Order(goqu.C(COLUMN_ID).Asc()).
+			Limit(attributeKeyListBatchSize)
+
+		if lastID != "" {
+			q = q.Where(goqu.C(COLUMN_ID).Gt(lastID))
+		}
+
+		sqlStr, _, err := q.ToSQL()
+
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := st.selectToMapString("AttributeKeyList", sqlStr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			key := row[COLUMN_ATTRIBUTE_KEY]
+
+			value, err := st.attributeValueDecode(key, row[COLUMN_ATTRIBUTE_VALUE])
+
+			if err != nil {
+				return nil, err
+			}
+
+			if keysByName[key] == nil {
+				keysByName[key] = &AttributeKeyInfo{AttributeKey: key}
+			}
+
+			keysByName[key].add(value)
+		}
+
+		if len(rows) < attributeKeyListBatchSize {
+			break
+		}
+
+		lastID = rows[len(rows)-1][COLUMN_ID]
+	}
+
+	keys = []AttributeKeyInfo{}
+
+	for _, info := range keysByName {
+		if info.ValueType == "" {
+			info.ValueType = VALUE_TYPE_STRING
+		}
+
+		// the sample would disclose the encrypted values
+		if st.attributeEncrypted(info.AttributeKey) {
+			info.SampleValue = ""
+		}
+
+		keys = append(keys, *info)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].AttributeKey < keys[j].AttributeKey
+	})
+
+	return keys, nil
+}
+
+// add accounts for one more value of the attribute key. The values
+// must be added oldest first, the first one is kept as the sample
+func (info *AttributeKeyInfo) add(value string) {
+	if info.Count == 0 {
+		info.SampleValue = value
+
+		if utf8.RuneCountInString(value) > attributeKeySampleLength {
+			info.SampleValue = string([]rune(value)[:attributeKeySampleLength])
+		}
+	}
+
+	info.Count++
+
+	if length := int64(utf8.RuneCountInString(value)); length > info.MaxLength {
+		info.MaxLength = length
+	}
+
+	// the empty values fit any type
+	if value == "" {
+		return
+	}
+
+	valueType := InferValueType(value)
+
+	switch {
+	case info.ValueType == "" || info.ValueType == valueType:
+		info.ValueType = valueType
+	case info.ValueType == VALUE_TYPE_INT && valueType == VALUE_TYPE_FLOAT:
+		info.ValueType = VALUE_TYPE_FLOAT
+	case info.ValueType == VALUE_TYPE_FLOAT && valueType == VALUE_TYPE_INT:
+	default:
+		info.ValueType = VALUE_TYPE_STRING
+	}
+}
+
+// valueTimeLayouts the layouts of the values inferred as times
+var valueTimeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+// InferValueType returns the narrowest type of the value, one of
+// VALUE_TYPE_INT, VALUE_TYPE_FLOAT, VALUE_TYPE_BOOL, VALUE_TYPE_TIME,
+// VALUE_TYPE_JSON (objects and arrays) or VALUE_TYPE_STRING
+func InferValueType(value string) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return VALUE_TYPE_INT
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(number, 0) && !math.IsNaN(number) {
+		return VALUE_TYPE_FLOAT
+	}
+
+	if value == "true" || value == "false" {
+		return VALUE_TYPE_BOOL
+	}
+
+	for _, layout := range valueTimeLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return VALUE_TYPE_TIME
+		}
+	}
+
+	if (strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[")) && json.Valid([]byte(value)) {
+		return VALUE_TYPE_JSON
+	}
+
+	return VALUE_TYPE_STRING
+}
@@ -0,0 +1,95 @@
+package entitystore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttributeKeyList(t *testing.T) {
+	db := InitDB("test_attribute_key_list.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	products := []map[string]string{
+		{"name": "Apple", "stock": "10", "price": "1", "active": "true", "released": "2024-01-02", "tags": `["fruit"]`},
+		{"name": "Pear", "stock": "", "price": "0.5", "active": "false", "released": "2024-01-02 10:00:00", "tags": `[]`},
+		{"name": strings.Repeat("x", 150), "stock": "7", "price": "2", "active": "1"},
+	}
+
+	for _, product := range products {
+		if _, err := store.EntityCreateWithTypeAndAttributes("product", product); err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	if _, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Hello"}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	types, err := store.EntityTypeList()
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(types) != 2 || types[0].EntityType != "post" || types[0].Count != 1 || types[1].EntityType != "product" || types[1].Count != 3 {
+		t.Fatal("Must list the post and product types, found:", types)
+	}
+
+	if types[1].FirstCreatedAt.IsZero() || types[1].LastCreatedAt.Before(types[1].FirstCreatedAt) {
+		t.Fatal("Must have the creation times, found:", types[1])
+	}
+
+	keys, err := store.AttributeKeyList("product")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	expected := []struct {
+		key       string
+		count     int64
+		valueType string
+		maxLength int64
+	}{
+		{"active", 3, VALUE_TYPE_STRING, 5},
+		{"name", 3, VALUE_TYPE_STRING, 150},
+		{"price", 3, VALUE_TYPE_FLOAT, 3},
+		{"released", 2, VALUE_TYPE_TIME, 19},
+		{"stock", 3, VALUE_TYPE_INT, 2},
+		{"tags", 2, VALUE_TYPE_JSON, 9},
+	}
+
+	if len(keys) != len(expected) {
+		t.Fatal("Must list", len(expected), "keys, found:", keys)
+	}
+
+	for i, e := range expected {
+		if keys[i].AttributeKey != e.key || keys[i].Count != e.count || keys[i].ValueType != e.valueType || keys[i].MaxLength != e.maxLength {
+			t.Fatal("Key", e.key, "must be", e, "found:", keys[i])
+		}
+	}
+
+	if keys[0].SampleValue != "true" {
+		t.Fatal("The sample must be the oldest value, found:", keys[0].SampleValue)
+	}
+
+	all, err := store.AttributeKeyList("")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(all) != len(expected)+1 {
+		t.Fatal("Must list the keys of all types, found:", all)
+	}
+}
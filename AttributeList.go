@@ -1,11 +1,9 @@
 package entitystore
 
-import (
-	"log"
-)
-
 // AttributeList lists attributes
 func (st *storeImplementation) AttributeList(options AttributeQueryOptions) (attributeList []Attribute, err error) {
+	defer wrapOpError(&err, "AttributeList", options.EntityID)
+
 	q := st.AttributeQuery(options)
 
 	sqlStr, _, errSql := q.ToSQL()
@@ -14,14 +12,10 @@ func (st *storeImplementation) AttributeList(options AttributeQueryOptions) (att
 		return attributeList, errSql
 	}
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
-
-	attributeMaps, errSelect := st.database.SelectToMapString(sqlStr)
+	attributeMaps, errSelect := st.selectToMapString("AttributeList", sqlStr)
 
 	if errSelect != nil {
-		return nil, err
+		return nil, errSelect
 	}
 
 	// attributeMaps := []map[string]string{}
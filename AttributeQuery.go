@@ -19,16 +19,20 @@ type AttributeQueryOptions struct {
 func (st *storeImplementation) AttributeQuery(options AttributeQueryOptions) *goqu.SelectDataset {
 	q := goqu.Dialect(st.dbDriverName).From(st.attributeTableName)
 
+	// the columns are qualified, as the entity table may be joined
+	attributeTable := goqu.T(st.attributeTableName)
+	entityTable := goqu.T(st.entityTableName)
+
 	if options.EntityType != "" && options.EntityHandle != "" {
-		q = q.LeftJoin(goqu.I(st.entityTableName), goqu.On(goqu.Ex{st.attributeTableName + ".entity_id": goqu.I(st.entityTableName + ".id")}))
+		q = q.LeftJoin(entityTable, goqu.On(attributeTable.Col(COLUMN_ENTITY_ID).Eq(entityTable.Col(COLUMN_ID))))
 	}
 
 	if len(options.IDs) > 0 {
-		q = q.Where(goqu.C(COLUMN_ID).In(options.IDs))
+		q = q.Where(attributeTable.Col(COLUMN_ID).In(options.IDs))
 	}
 
 	if options.ID != "" {
-		q = q.Where(goqu.C(COLUMN_ID).Eq(options.ID))
+		q = q.Where(attributeTable.Col(COLUMN_ID).Eq(options.ID))
 	}
 
 	sortByColumn := COLUMN_ID
@@ -43,28 +47,26 @@ func (st *storeImplementation) AttributeQuery(options AttributeQueryOptions) *go
 	}
 
 	if sortOrder == "asc" {
-		q = q.Order(goqu.I(sortByColumn).Asc())
+		q = q.Order(attributeTable.Col(sortByColumn).Asc())
 	} else {
-		q = q.Order(goqu.I(sortByColumn).Desc())
+		q = q.Order(attributeTable.Col(sortByColumn).Desc())
 	}
 
 	if options.EntityID != "" {
-		q = q.Where(goqu.C(COLUMN_ENTITY_ID).Eq(options.EntityID))
+		q = q.Where(attributeTable.Col(COLUMN_ENTITY_ID).Eq(options.EntityID))
 	}
 
 	if options.EntityType != "" && options.EntityHandle != "" {
-		q = q.Where(goqu.C(COLUMN_ENTITY_TYPE).Eq(options.EntityType))
-		q = q.Where(goqu.C(COLUMN_ENTITY_HANDLE).Eq(options.EntityHandle))
+		q = q.Where(entityTable.Col(COLUMN_ENTITY_TYPE).Eq(options.EntityType))
+		q = q.Where(entityTable.Col(COLUMN_ENTITY_HANDLE).Eq(options.EntityHandle))
 	}
 
-	if options.AttributeKey != "" {
-		q = q.Where(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(options.AttributeKey))
+	if st.tenantScoped {
+		q = q.Where(attributeTable.Col(COLUMN_ENTITY_ID).In(st.tenantEntityIDsQuery()))
 	}
 
-	q = q.Offset(uint(options.Offset))
-
-	if options.Limit != 0 {
-		q = q.Limit(uint(options.Limit))
+	if options.AttributeKey != "" {
+		q = q.Where(attributeTable.Col(COLUMN_ATTRIBUTE_KEY).Eq(options.AttributeKey))
 	}
 
 	if !options.CountOnly {
@@ -77,5 +79,5 @@ func (st *storeImplementation) AttributeQuery(options AttributeQueryOptions) *go
 		}
 	}
 
-	return q.Select()
+	return q.Select(attributeTable.All())
 }
@@ -3,7 +3,9 @@ package entitystore
 import "strconv"
 
 // AttributeSetFloat creates a new attribute or updates existing
-func (st *storeImplementation) AttributeSetFloat(entityID string, attributeKey string, attributeValue float64) error {
+func (st *storeImplementation) AttributeSetFloat(entityID string, attributeKey string, attributeValue float64) (err error) {
+	defer wrapOpError(&err, "AttributeSetFloat", entityID)
+
 	attributeValueAsString := strconv.FormatFloat(attributeValue, 'f', 30, 64)
 	return st.AttributeSetString(entityID, attributeKey, attributeValueAsString)
 }
@@ -3,7 +3,9 @@ package entitystore
 import "strconv"
 
 // AttributeSetInt creates a new attribute or updates existing
-func (st *storeImplementation) AttributeSetInt(entityID string, attributeKey string, attributeValue int64) error {
+func (st *storeImplementation) AttributeSetInt(entityID string, attributeKey string, attributeValue int64) (err error) {
+	defer wrapOpError(&err, "AttributeSetInt", entityID)
+
 	attributeValueAsString := strconv.FormatInt(attributeValue, 10)
 	return st.AttributeSetString(entityID, attributeKey, attributeValueAsString)
 }
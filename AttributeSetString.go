@@ -1,7 +1,9 @@
 package entitystore
 
 // AttributeSetString creates a new entity
-func (st *storeImplementation) AttributeSetString(entityID string, attributeKey string, attributeValue string) error {
+func (st *storeImplementation) AttributeSetString(entityID string, attributeKey string, attributeValue string) (err error) {
+	defer wrapOpError(&err, "AttributeSetString", entityID)
+
 	attr, err := st.AttributeFind(entityID, attributeKey)
 
 	if err != nil {
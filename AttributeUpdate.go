@@ -1,39 +1,90 @@
 package entitystore
 
 import (
-	"log"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
 // AttributeUpdate updates an attribute
-func (st *storeImplementation) AttributeUpdate(attr Attribute) error {
+func (st *storeImplementation) AttributeUpdate(attr Attribute) (err error) {
+	defer wrapOpError(&err, "AttributeUpdate", attr.EntityID())
+
 	attr.SetUpdatedAt(time.Now())
 
-	q := goqu.Dialect(st.dbDriverName).Update(st.attributeTableName)
-	q = q.Where(goqu.C(COLUMN_ID).Eq(attr.ID()))
-	q = q.Set(attr.ToMap())
+	return st.inTransaction(func(tx *storeImplementation) error {
+		if err := tx.tenantEntityCheck(attr.EntityID()); err != nil {
+			return err
+		}
+
+		if err := tx.attributeHooksRun(hookBeforeAttributeSet, &attr); err != nil {
+			return err
+		}
+
+		row, err := tx.attributeEncode(attr)
+
+		if err != nil {
+			return err
+		}
+
+		q := goqu.Dialect(tx.dbDriverName).Update(tx.attributeTableName)
+		q = q.Where(goqu.C(COLUMN_ID).Eq(attr.ID()))
+		q = q.Set(row.ToMap())
+
+		if tx.tenantScoped {
+			q = q.Where(goqu.C(COLUMN_ENTITY_ID).In(tx.tenantEntityIDsQuery()))
+		}
+
+		sqlStr, _, errSql := q.ToSQL()
+
+		if errSql != nil {
+			return errSql
+		}
+
+		oldValue := ""
 
-	sqlStr, _, errSql := q.ToSQL()
+		if tx.attributeHistoryTableName != "" {
+			list, err := tx.AttributeList(AttributeQueryOptions{ID: attr.ID(), Limit: 1})
 
-	if errSql != nil {
-		return errSql
-	}
+			if err != nil {
+				return err
+			}
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
+			if len(list) > 0 {
+				oldValue = list[0].AttributeValue()
+			}
+		}
 
-	_, err := st.database.Exec(sqlStr)
+		_, err = tx.exec("AttributeUpdate", sqlStr)
 
-	if err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if err != nil {
+			return err
 		}
 
-		return err
-	}
+		tx.cacheInvalidate(cacheKeyAttribute(attr.EntityID(), attr.AttributeKey()))
+
+		if err := tx.projectionSync(attr.EntityID()); err != nil {
+			return err
+		}
+
+		err = tx.attributeHistoryRecord(attr.EntityID(), attr.AttributeKey(), OPERATION_UPDATE, oldValue, row.AttributeValue())
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.entityVersionIncrement(attr.EntityID())
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.changeRecordAttribute(CHANGE_ATTRIBUTE_SET, &row)
+
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return tx.attributeHooksRun(hookAfterAttributeSet, &attr)
+	})
 }
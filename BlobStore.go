@@ -0,0 +1,105 @@
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// BlobStore keeps the large attribute values offloaded from the attribute
+// table. The store only references blobs by key, deleting the ones no longer
+// referenced (by attributes, trash or history) is left to the application
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type fileBlobStore struct {
+	dir string
+}
+
+var _ BlobStore = (*fileBlobStore)(nil)
+
+// NewFileBlobStore creates a blob store keeping each blob
+// as a file in the directory, which is created if missing
+func NewFileBlobStore(dir string) (BlobStore, error) {
+	if dir == "" {
+		return nil, errors.New("entity store: blob directory is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &fileBlobStore{dir: dir}, nil
+}
+
+func (s *fileBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	// written aside and renamed, so readers never see a partial blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".blob-*")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *fileBlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+func (s *fileBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path returns the file of the blob, spread over subdirectories
+// named after the first characters of the key
+func (s *fileBlobStore) path(key string) (string, error) {
+	if len(key) < 3 || key != filepath.Base(key) || key[0] == '.' {
+		return "", errors.New("entity store: invalid blob key " + key)
+	}
+
+	return filepath.Join(s.dir, key[:2], key), nil
+}
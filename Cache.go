@@ -0,0 +1,266 @@
+package entitystore
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache keeps the entities and attributes read by the store.
+// Implementations must be safe for concurrent use
+type Cache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
+	Delete(key string)
+	Clear()
+}
+
+// CacheStats reports how effective the cache of the store is
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	HitRate float64 // hits / (hits + misses), 0 if nothing was read yet
+}
+
+type memoryCacheEntry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	recency    *list.List // most recently used first
+}
+
+var _ Cache = (*memoryCache)(nil)
+
+// NewMemoryCache creates an in-memory cache, evicting the least recently
+// used entries above maxEntries, and expiring the entries after the ttl.
+// Zero maxEntries or ttl mean no limit
+func NewMemoryCache(maxEntries int, ttl time.Duration) Cache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    map[string]*list.Element{},
+		recency:    list.New(),
+	}
+}
+
+func (c *memoryCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+
+	if !exists {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		return nil, false
+	}
+
+	c.recency.MoveToFront(element)
+
+	return entry.value, true
+}
+
+func (c *memoryCache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Time{}
+
+	if c.ttl > 0 {
+		expiresAt = time.Now().Add(c.ttl)
+	}
+
+	if element, exists := c.entries[key]; exists {
+		entry := element.Value.(*memoryCacheEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.recency.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.recency.PushFront(&memoryCacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiresAt,
+	})
+
+	if c.maxEntries > 0 && c.recency.Len() > c.maxEntries {
+		c.remove(c.recency.Back())
+	}
+}
+
+func (c *memoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.entries[key]; exists {
+		c.remove(element)
+	}
+}
+
+func (c *memoryCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.recency.Init()
+}
+
+func (c *memoryCache) remove(element *list.Element) {
+	c.recency.Remove(element)
+	delete(c.entries, element.Value.(*memoryCacheEntry).key)
+}
+
+// storeCache wraps the cache with the statistics, shared by all views of a store
+type storeCache struct {
+	cache  Cache
+	hits   atomic.Uint64
+	misses atomic.Uint64
+
+	// generation is bumped by every invalidation, under mu, so a value
+	// read from the database before an invalidation is not cached after it
+	mu         sync.Mutex
+	generation uint64
+}
+
+// CacheStats returns the hits and misses of the cache since the store was created
+func (st *storeImplementation) CacheStats() CacheStats {
+	if st.cache == nil {
+		return CacheStats{}
+	}
+
+	stats := CacheStats{
+		Hits:   st.cache.hits.Load(),
+		Misses: st.cache.misses.Load(),
+	}
+
+	if stats.Hits+stats.Misses > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+	}
+
+	return stats
+}
+
+func cacheKeyEntity(entityID string) string {
+	return "entity:" + entityID
+}
+
+func cacheKeyAttribute(entityID string, attributeKey string) string {
+	return "attribute:" + entityID + ":" + attributeKey
+}
+
+// cacheGet reads from the cache, counting the hits and misses
+func (st *storeImplementation) cacheGet(key string) (any, bool) {
+	if st.cache == nil {
+		return nil, false
+	}
+
+	value, exists := st.cache.cache.Get(key)
+
+	if exists {
+		st.cache.hits.Add(1)
+	} else {
+		st.cache.misses.Add(1)
+	}
+
+	return value, exists
+}
+
+// cacheGeneration returns the generation of the cache, to be taken
+// before reading the value to cache from the database
+func (st *storeImplementation) cacheGeneration() uint64 {
+	if st.cache == nil {
+		return 0
+	}
+
+	st.cache.mu.Lock()
+	defer st.cache.mu.Unlock()
+
+	return st.cache.generation
+}
+
+// cacheSet writes to the cache the value read at the generation. Nothing
+// is cached if something was invalidated since, as the value may be stale,
+// nor while a transaction is in progress, as it may still be rolled back
+func (st *storeImplementation) cacheSet(key string, value any, generation uint64) {
+	if st.cache == nil || st.database.Tx() != nil {
+		return
+	}
+
+	st.cache.mu.Lock()
+	defer st.cache.mu.Unlock()
+
+	if st.cache.generation != generation {
+		return
+	}
+
+	st.cache.cache.Set(key, value)
+}
+
+// cacheDelete removes the keys from the cache, starting a new generation
+func (st *storeImplementation) cacheDelete(keys []string) {
+	st.cache.mu.Lock()
+	defer st.cache.mu.Unlock()
+
+	st.cache.generation++
+
+	for _, key := range keys {
+		st.cache.cache.Delete(key)
+	}
+}
+
+// cacheInvalidate removes the keys from the cache. Within a transaction
+// they are removed once more when it ends, in case they were read
+// (outside of the transaction) before it was committed
+func (st *storeImplementation) cacheInvalidate(keys ...string) {
+	if st.cache == nil {
+		return
+	}
+
+	st.cacheDelete(keys)
+
+	if st.cacheInvalidated == nil {
+		return
+	}
+
+	*st.cacheInvalidated = append(*st.cacheInvalidated, keys...)
+}
+
+// cacheInvalidatePending removes the keys invalidated during
+// the transaction running on this view, which has just ended
+func (st *storeImplementation) cacheInvalidatePending() {
+	if st.cache == nil || st.cacheInvalidated == nil {
+		return
+	}
+
+	keys := *st.cacheInvalidated
+	*st.cacheInvalidated = nil
+
+	st.cacheDelete(keys)
+}
+
+// cacheClear empties the cache
+func (st *storeImplementation) cacheClear() {
+	if st.cache == nil {
+		return
+	}
+
+	st.cache.mu.Lock()
+	defer st.cache.mu.Unlock()
+
+	st.cache.generation++
+	st.cache.cache.Clear()
+}
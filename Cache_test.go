@@ -0,0 +1,249 @@
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2, 50*time.Millisecond)
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Set("c", 3)
+
+	if _, exists := cache.Get("b"); exists {
+		t.Fatal("Least recently used entry must be evicted")
+	}
+
+	if value, exists := cache.Get("a"); !exists || value != 1 {
+		t.Fatal("Recently used entry must be kept")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, exists := cache.Get("c"); exists {
+		t.Fatal("Expired entry must not be returned")
+	}
+}
+
+func TestStoreCache(t *testing.T) {
+	db := InitDB("test_store_cache.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		Cache:              NewMemoryCache(100, time.Minute),
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Title 1"})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	for i := 0; i < 3; i++ {
+		attr, err := store.AttributeFind(entity.ID(), "title")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if attr == nil || attr.GetString() != "Title 1" {
+			t.Fatal("Attribute mismatch")
+		}
+	}
+
+	stats := store.CacheStats()
+
+	if stats.Hits < 2 || stats.Misses < 1 {
+		t.Fatal("Cache must be hit after the first read:", stats)
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	versionBefore := found.Version()
+
+	err = store.AttributeSetString(entity.ID(), "title", "Title 2")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	attr, err := store.AttributeFind(entity.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if attr == nil || attr.GetString() != "Title 2" {
+		t.Fatal("Cached attribute must be invalidated on write")
+	}
+
+	found, err = store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if found.Version() != versionBefore+1 {
+		t.Fatal("Cached entity must be invalidated on attribute write")
+	}
+
+	_, err = store.EntityTrash(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	found, err = store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if found != nil {
+		t.Fatal("Cached entity must be invalidated on trash")
+	}
+
+	attr, err = store.AttributeFind(entity.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if attr != nil {
+		t.Fatal("Cached attribute must be invalidated on trash")
+	}
+}
+
+func TestStoreCacheConcurrentTransactions(t *testing.T) {
+	db := InitDB("test_store_cache_concurrent.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		Cache:              NewMemoryCache(100, time.Minute),
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	post, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Title 1"})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	other, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Other"})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	store.BeforeAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		if attribute.EntityID() == other.ID() {
+			return errors.New("vetoed")
+		}
+
+		return nil
+	})
+
+	store.AfterAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		if attribute.EntityID() != post.ID() {
+			return nil
+		}
+
+		// another transaction ends while the post is being updated
+		if err := store.AttributeSetString(other.ID(), "title", "Other 2"); err == nil {
+			return errors.New("other transaction must be vetoed")
+		}
+
+		// and the title before the update is read into the cache
+		_, err := store.AttributeFind(post.ID(), "title")
+
+		return err
+	})
+
+	if err := store.AttributeSetString(post.ID(), "title", "Title 2"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	attr, err := store.AttributeFind(post.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if attr == nil || attr.GetString() != "Title 2" {
+		t.Fatal("Title read before the commit must not stay cached")
+	}
+}
+
+func TestStoreCacheStaleRead(t *testing.T) {
+	var store StoreInterface
+	var post *Entity
+	isWriting := false
+
+	// a write commits between the read of the title and its caching
+	observer := QueryObserverFunc(func(ctx context.Context, event QueryEvent) {
+		if event.Operation != "AttributeList" || isWriting || post == nil {
+			return
+		}
+
+		isWriting = true
+
+		if err := store.AttributeSetString(post.ID(), "title", "Title 2"); err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	})
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 InitDB("test_store_cache_stale.db"),
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		Cache:              NewMemoryCache(100, 0),
+		QueryObserver:      observer,
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	post, err = store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Title 1"})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	if _, err := store.AttributeFind(post.ID(), "title"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	title, err := store.AttributeFind(post.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if title.AttributeValue() != "Title 2" {
+		t.Fatal("A value read before a write must not be cached after it, found:", title.AttributeValue())
+	}
+}
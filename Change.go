@@ -0,0 +1,56 @@
+package entitystore
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// Change is a single change of an entity, as recorded in the outbox
+type Change struct {
+	ID          string
+	Operation   string // one of the CHANGE_* constants
+	EntityType  string
+	EntityID    string
+	ChangedKeys []string
+	Payload     string // JSON of the entity or attribute
+
+	// Sequence numbers the changes in the order they were committed,
+	// pass the Sequence of the last change read to ChangesSince
+	Sequence  int64
+	CreatedAt time.Time
+}
+
+func (c *Change) ToMap() map[string]any {
+	changedKeys, _ := json.Marshal(c.ChangedKeys)
+
+	entry := map[string]any{}
+	entry[COLUMN_ID] = c.ID
+	entry[COLUMN_OPERATION] = c.Operation
+	entry[COLUMN_ENTITY_TYPE] = c.EntityType
+	entry[COLUMN_ENTITY_ID] = c.EntityID
+	entry[COLUMN_CHANGED_KEYS] = string(changedKeys)
+	entry[COLUMN_PAYLOAD] = c.Payload
+	entry[COLUMN_SEQUENCE] = c.Sequence
+	entry[COLUMN_CREATED_AT] = c.CreatedAt
+	return entry
+}
+
+func newChangeFromMap(changeMap map[string]string) Change {
+	change := Change{
+		ID:          changeMap[COLUMN_ID],
+		Operation:   changeMap[COLUMN_OPERATION],
+		EntityType:  changeMap[COLUMN_ENTITY_TYPE],
+		EntityID:    changeMap[COLUMN_ENTITY_ID],
+		ChangedKeys: []string{},
+		Payload:     changeMap[COLUMN_PAYLOAD],
+		CreatedAt:   carbon.Parse(changeMap[COLUMN_CREATED_AT], carbon.UTC).StdTime(),
+	}
+
+	_ = json.Unmarshal([]byte(changeMap[COLUMN_CHANGED_KEYS]), &change.ChangedKeys)
+	change.Sequence, _ = strconv.ParseInt(changeMap[COLUMN_SEQUENCE], 10, 64)
+
+	return change
+}
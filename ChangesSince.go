@@ -0,0 +1,49 @@
+package entitystore
+
+import (
+	"errors"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// ChangesSince lists the changes in the outbox committed after the change
+// with the given Sequence, oldest first. Start from 0, and continue
+// from the Sequence of the last returned change
+func (st *storeImplementation) ChangesSince(afterSequence int64, limit uint64) (changes []Change, err error) {
+	defer wrapOpError(&err, "ChangesSince", "")
+
+	if st.outboxTableName == "" {
+		return nil, errNotEnabled("outbox")
+	}
+
+	if st.tenantScoped {
+		return nil, errors.New("the outbox spans all tenants, it is not available in a tenant view")
+	}
+
+	q := goqu.Dialect(st.dbDriverName).From(st.outboxTableName)
+
+	q = q.Where(goqu.C(COLUMN_SEQUENCE).Gt(afterSequence))
+	q = q.Order(goqu.C(COLUMN_SEQUENCE).Asc())
+
+	if limit > 0 {
+		q = q.Limit(uint(limit))
+	}
+
+	sqlStr, _, errSql := q.Select().ToSQL()
+
+	if errSql != nil {
+		return nil, errSql
+	}
+
+	changeMaps, err := st.selectToMapString("ChangesSince", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, changeMap := range changeMaps {
+		changes = append(changes, newChangeFromMap(changeMap))
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,204 @@
+package entitystore
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestChangesSince(t *testing.T) {
+	db := InitDB("test_changes_since.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		OutboxTableName:    "cms_outbox",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{
+		"title": "Hello world",
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	_, err = store.EntityTrash(entity.ID())
+
+	if err != nil {
+		t.Fatal("Entity could not be trashed: " + err.Error())
+	}
+
+	changes, err := store.ChangesSince(0, 10)
+
+	if err != nil {
+		t.Fatal("Changes could not be listed: " + err.Error())
+	}
+
+	expected := []string{CHANGE_ENTITY_CREATE, CHANGE_ATTRIBUTE_SET, CHANGE_ENTITY_TRASH}
+
+	if len(changes) != len(expected) {
+		t.Fatal("Changes must be ", len(expected), ", found: ", len(changes))
+	}
+
+	for i, change := range changes {
+		if change.Operation != expected[i] {
+			t.Fatal("Operation mismatch at", i, ":", change.Operation)
+		}
+
+		if change.EntityID != entity.ID() || change.EntityType != "post" {
+			t.Fatal("Entity mismatch at", i, ":", change.EntityID, change.EntityType)
+		}
+	}
+
+	if len(changes[1].ChangedKeys) != 1 || changes[1].ChangedKeys[0] != "title" {
+		t.Fatal("Changed keys mismatch:", changes[1].ChangedKeys)
+	}
+
+	payload := map[string]any{}
+
+	if err := json.Unmarshal([]byte(changes[1].Payload), &payload); err != nil {
+		t.Fatal("Payload must be JSON: " + err.Error())
+	}
+
+	if payload[COLUMN_ATTRIBUTE_VALUE] != "Hello world" {
+		t.Fatal("Payload mismatch:", changes[1].Payload)
+	}
+
+	next, err := store.ChangesSince(changes[0].Sequence, 10)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(next) != 2 {
+		t.Fatal("Changes after the cursor must be 2, found: ", len(next))
+	}
+
+	err = store.AckChanges([]string{changes[0].ID, changes[1].ID})
+
+	if err != nil {
+		t.Fatal("Changes could not be acknowledged: " + err.Error())
+	}
+
+	remaining, err := store.ChangesSince(0, 10)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(remaining) != 1 || remaining[0].ID != changes[2].ID {
+		t.Fatal("Only the trash change must remain, found: ", len(remaining))
+	}
+}
+
+func TestChangesSinceConcurrentWriters(t *testing.T) {
+	_ = InitDB("test_changes_since_concurrent.db").Close()
+
+	// the writers wait for each other, instead of failing to upgrade their locks
+	db, err := sql.Open("sqlite3", "test_changes_since_concurrent.db?_txlock=immediate")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// an outbox created before the changes were numbered
+	_, err = db.Exec(`CREATE TABLE "cms_outbox" ("id" varchar(40) NOT NULL PRIMARY KEY, "operation" varchar(40) NOT NULL, "entity_type" varchar(40) NOT NULL, "entity_id" varchar(40) NOT NULL, "changed_keys" text, "payload" text, "created_at" datetime NOT NULL)`)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	_, err = db.Exec(`INSERT INTO "cms_outbox" VALUES ('LEGACY', 'entity_create', 'post', 'ENTITY', '[]', '{}', '2020-01-01 00:00:00')`)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		OutboxTableName:    "cms_outbox",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	workers := 10
+	errs := make(chan error, workers)
+	wg := sync.WaitGroup{}
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			_, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Title " + strconv.Itoa(worker)})
+
+			if err != nil {
+				errs <- err
+			}
+		}(worker)
+	}
+
+	// read while the writers run, continuing from the last change read
+	seen := map[string]bool{}
+	last := int64(0)
+
+	readChanges := func() {
+		changes, err := store.ChangesSince(last, 0)
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		for _, change := range changes {
+			if seen[change.ID] {
+				t.Fatal("Change listed twice:", change.ID)
+			}
+
+			seen[change.ID] = true
+			last = change.Sequence
+		}
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			readChanges()
+		}
+	}
+
+	readChanges()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// the legacy change, numbered on migration, and an entity and an attribute change per worker
+	if len(seen) != 1+2*workers || !seen["LEGACY"] {
+		t.Fatal("All the changes must be listed, found: ", len(seen))
+	}
+}
@@ -0,0 +1,260 @@
+package entitystore
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// typeView is a view created by CreateTypeView, kept in the type view
+// table for RefreshTypeViews
+type typeView struct {
+	entityType    string
+	attributeKeys []string // nil for all the keys in use
+	tenantScoped  bool
+	tenantID      string
+}
+
+// typeViewMetadata is the definition of a type view, as kept
+// in the metadata column of the type view table
+type typeViewMetadata struct {
+	AttributeKeys []string `json:"attribute_keys"`
+	TenantScoped  bool     `json:"tenant_scoped,omitempty"`
+	TenantID      string   `json:"tenant_id,omitempty"`
+}
+
+// typeViewNamePattern the view names must be plain identifiers
+var typeViewNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CreateTypeView creates (or replaces) a database view with a row per entity
+// of the type and a column per attribute key, next to the columns of the
+// entity, for reporting tools which do not cope with the attribute rows.
+// Without attribute keys the view has a column per key in use, and
+// RefreshTypeViews adds the columns of the keys used since. The view
+// name must be a plain identifier, letters, digits and underscores
+func (st *storeImplementation) CreateTypeView(entityType string, attributeKeys []string, viewName string) (err error) {
+	defer wrapOpError(&err, "CreateTypeView", "")
+
+	if entityType == "" {
+		return errInvalidArgument("entity type cannot be empty")
+	}
+
+	if viewName == "" {
+		return errInvalidArgument("view name cannot be empty")
+	}
+
+	if !typeViewNamePattern.MatchString(viewName) {
+		return errInvalidArgument("view name " + viewName + " must be letters, digits and underscores")
+	}
+
+	view := typeView{
+		entityType:    entityType,
+		attributeKeys: attributeKeys,
+		tenantScoped:  st.tenantScoped,
+		tenantID:      st.tenantID,
+	}
+
+	if len(attributeKeys) < 1 {
+		view.attributeKeys = nil
+	}
+
+	if err := st.typeViewCreate(viewName, view); err != nil {
+		return err
+	}
+
+	return st.typeViewSave(viewName, view)
+}
+
+// typeViewTableName returns the name of the table keeping the type views
+func (st *storeImplementation) typeViewTableName() string {
+	return st.entityTableName + "_type_view"
+}
+
+// sqlCreateTypeViewTable returns the SQL to create the table keeping the type views
+func (st *storeImplementation) sqlCreateTypeViewTable() string {
+	return "CREATE TABLE IF NOT EXISTS " + st.quoteIdentifier(st.typeViewTableName()) + " (" +
+		st.quoteIdentifier(COLUMN_ID) + " varchar(255) NOT NULL PRIMARY KEY, " +
+		st.quoteIdentifier(COLUMN_ENTITY_TYPE) + " varchar(40) NOT NULL, " +
+		st.quoteIdentifier(COLUMN_METADATA) + " text);"
+}
+
+// typeViewSave replaces the definition of the view in the type view table
+func (st *storeImplementation) typeViewSave(viewName string, view typeView) error {
+	metadata, err := json.Marshal(typeViewMetadata{
+		AttributeKeys: view.attributeKeys,
+		TenantScoped:  view.tenantScoped,
+		TenantID:      view.tenantID,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	deleteSql, _, err := goqu.Dialect(st.dbDriverName).
+		From(st.typeViewTableName()).
+		Where(goqu.C(COLUMN_ID).Eq(viewName)).
+		Delete().
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	insertSql, _, err := goqu.Dialect(st.dbDriverName).
+		Insert(st.typeViewTableName()).
+		Rows(goqu.Record{
+			COLUMN_ID:          viewName,
+			COLUMN_ENTITY_TYPE: view.entityType,
+			COLUMN_METADATA:    string(metadata),
+		}).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	return st.inTransaction(func(tx *storeImplementation) error {
+		if _, err := tx.exec("CreateTypeView", deleteSql); err != nil {
+			return err
+		}
+
+		_, err := tx.exec("CreateTypeView", insertSql)
+		return err
+	})
+}
+
+// typeViewList returns the views kept in the type view table, by name
+func (st *storeImplementation) typeViewList() (map[string]typeView, error) {
+	rows, err := st.selectToMapStringFrom("RefreshTypeViews", st.typeViewTableName(), goqu.Ex{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	views := map[string]typeView{}
+
+	for _, row := range rows {
+		metadata := typeViewMetadata{}
+
+		if err := json.Unmarshal([]byte(row[COLUMN_METADATA]), &metadata); err != nil {
+			return nil, errors.New("type view " + row[COLUMN_ID] + " has invalid metadata: " + err.Error())
+		}
+
+		views[row[COLUMN_ID]] = typeView{
+			entityType:    row[COLUMN_ENTITY_TYPE],
+			attributeKeys: metadata.AttributeKeys,
+			tenantScoped:  metadata.TenantScoped,
+			tenantID:      metadata.TenantID,
+		}
+	}
+
+	return views, nil
+}
+
+// typeViewCreate runs the statements creating the view
+func (st *storeImplementation) typeViewCreate(viewName string, view typeView) error {
+	// the view would show the compressed and offloaded values as stored
+	if st.valueEncodes() {
+		return errInvalidArgument("type views are not available when the values are compressed or offloaded")
+	}
+
+	columns := []string{COLUMN_ID, COLUMN_ENTITY_HANDLE, COLUMN_VERSION, COLUMN_CREATED_AT, COLUMN_UPDATED_AT}
+
+	if st.hierarchyEnabled {
+		columns = append(columns, COLUMN_PARENT_ID)
+	}
+
+	if st.tenancyEnabled {
+		columns = append(columns, COLUMN_TENANT_ID)
+	}
+
+	attributeKeys := view.attributeKeys
+
+	if attributeKeys == nil {
+		scoped := *st
+		scoped.tenantScoped = view.tenantScoped
+		scoped.tenantID = view.tenantID
+
+		keys, err := scoped.AttributeKeyList(view.entityType)
+
+		if err != nil {
+			return err
+		}
+
+		// the encrypted keys are left out, rather than failing the view
+		for _, key := range keys {
+			if !st.attributeEncrypted(key.AttributeKey) {
+				attributeKeys = append(attributeKeys, key.AttributeKey)
+			}
+		}
+	}
+
+	selected := []any{}
+
+	for _, column := range columns {
+		selected = append(selected, goqu.I("e."+column))
+	}
+
+	q := goqu.Dialect(st.dbDriverName).
+		From(goqu.T(st.entityTableName).As("e")).
+		Where(goqu.I("e." + COLUMN_ENTITY_TYPE).Eq(view.entityType))
+
+	if view.tenantScoped {
+		q = q.Where(goqu.I("e." + COLUMN_TENANT_ID).Eq(view.tenantID))
+	}
+
+	for i, key := range attributeKeys {
+		if key == "" {
+			return errInvalidArgument("attribute key cannot be empty")
+		}
+
+		if contains(columns, key) {
+			return errInvalidArgument("attribute key " + key + " is a column of the entity")
+		}
+
+		// sealed values are random bytes to the database
+		if st.attributeEncrypted(key) {
+			return errInvalidArgument("attribute " + key + " is encrypted and cannot be viewed")
+		}
+
+		alias := "a" + strconv.Itoa(i)
+
+		q = q.LeftJoin(goqu.T(st.attributeTableName).As(alias), goqu.On(
+			goqu.I(alias+"."+COLUMN_ENTITY_ID).Eq(goqu.I("e."+COLUMN_ID)),
+			goqu.I(alias+"."+COLUMN_ATTRIBUTE_KEY).Eq(key),
+		))
+
+		selected = append(selected, goqu.I(alias+"."+COLUMN_ATTRIBUTE_VALUE).As(key))
+	}
+
+	selectSql, _, err := q.Select(selected...).ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	name := st.quoteIdentifier(viewName)
+
+	switch st.dbDriverName {
+	case "mysql":
+		// the DDL statements commit on their own in MySQL
+		_, err := st.exec("CreateTypeView", "CREATE OR REPLACE VIEW "+name+" AS "+selectSql)
+		return err
+	case "postgres", "sqlite":
+		// dropped first, as the columns of a view cannot be
+		// replaced in PostgreSQL, nor the view in SQLite
+		return st.inTransaction(func(tx *storeImplementation) error {
+			if _, err := tx.exec("CreateTypeView", "DROP VIEW IF EXISTS "+name); err != nil {
+				return err
+			}
+
+			_, err := tx.exec("CreateTypeView", "CREATE VIEW "+name+" AS "+selectSql)
+			return err
+		})
+	}
+
+	return errors.New("unsupported driver " + st.dbDriverName)
+}
@@ -0,0 +1,196 @@
+package entitystore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateTypeView(t *testing.T) {
+	db := InitDB("test_create_type_view.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	apple, err := store.EntityCreateWithTypeAndAttributes("product", map[string]string{"name": "Apple", "price": "1.20"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, err := store.EntityCreateWithTypeAndAttributes("product", map[string]string{"name": "Pear"}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"name": "Hello"}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	err = store.CreateTypeView("product", []string{"name", "price"}, "product_view")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	var name, price string
+
+	err = db.QueryRow(`SELECT name, price FROM product_view WHERE id = ?`, apple.ID()).Scan(&name, &price)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if name != "Apple" || price != "1.20" {
+		t.Fatal("The view must have a column per key, found:", name, price)
+	}
+
+	var count int
+
+	if err := db.QueryRow(`SELECT COUNT(*) FROM product_view WHERE price IS NULL`).Scan(&count); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 1 {
+		t.Fatal("The view must have a row per product, with NULL for the missing keys, found:", count)
+	}
+
+	// without keys the view follows the keys in use
+	if err := store.CreateTypeView("product", nil, "product_all"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := apple.SetString("color", "red"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := db.QueryRow(`SELECT color FROM product_all WHERE id = ?`, apple.ID()).Scan(new(string)); err == nil {
+		t.Fatal("The new key must not be a column before the refresh")
+	}
+
+	if err := store.RefreshTypeViews(); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	var color string
+
+	if err := db.QueryRow(`SELECT color FROM product_all WHERE id = ?`, apple.ID()).Scan(&color); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if color != "red" {
+		t.Fatal("The refreshed view must have the new key, found:", color)
+	}
+
+	err = store.CreateTypeView("product", []string{"id"}, "product_invalid")
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("A key named as an entity column must be an invalid argument, found:", err)
+	}
+
+	err = store.CreateTypeView("product", nil, `product_view" AS SELECT 1; DROP TABLE "cms_entity`)
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("A view name which is not an identifier must be an invalid argument, found:", err)
+	}
+
+	// a new store on the same database knows the views
+	restarted, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := restarted.AttributeSetString(apple.ID(), "size", "large"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := restarted.RefreshTypeViews(); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	var size string
+
+	if err := db.QueryRow(`SELECT size FROM product_all WHERE id = ?`, apple.ID()).Scan(&size); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if size != "large" {
+		t.Fatal("The view refreshed after a restart must have the new key, found:", size)
+	}
+
+	if err := db.QueryRow(`SELECT size FROM product_view WHERE id = ?`, apple.ID()).Scan(new(string)); err == nil {
+		t.Fatal("The view with keys must not be refreshed")
+	}
+}
+
+func TestCreateTypeViewEncodedValues(t *testing.T) {
+	db := InitDB("test_create_type_view_encoded.db")
+
+	cipher, err := NewAESGCMCipher(map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")}, "k1")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                     db,
+		EntityTableName:        "cms_entity",
+		AttributeTableName:     "cms_attribute",
+		AttributeCipher:        cipher,
+		EncryptedAttributeKeys: []string{"email"},
+		AutomigrateEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, err := store.EntityCreateWithTypeAndAttributes("user", map[string]string{"name": "Ann", "email": "ann@example.com"}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := store.CreateTypeView("user", []string{"name", "email"}, "user_view"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("A view of an encrypted key must be an invalid argument, found:", err)
+	}
+
+	if err := store.CreateTypeView("user", nil, "user_view"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	var name string
+
+	if err := db.QueryRow(`SELECT name FROM user_view`).Scan(&name); err != nil || name != "Ann" {
+		t.Fatal("The view must have the plain keys, found:", name, err)
+	}
+
+	if _, err := db.Exec(`SELECT email FROM user_view`); err == nil {
+		t.Fatal("The view must leave the encrypted keys out")
+	}
+
+	compressing, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		ValueCompression:   VALUE_COMPRESSION_GZIP,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := compressing.CreateTypeView("user", []string{"name"}, "user_view"); !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("A view of compressed values must be an invalid argument, found:", err)
+	}
+}
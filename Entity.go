@@ -1,7 +1,6 @@
 package entitystore
 
 import (
-	"log"
 	"time"
 )
 
@@ -10,9 +9,16 @@ type Entity struct {
 	id           string
 	entityType   string
 	entityHandle string
+	parentID     string
+	tenantID     string
+	version      int64
 	createdAt    time.Time
 	updatedAt    time.Time
 	st           *storeImplementation
+
+	// store serves the attribute helpers of the entities
+	// of other StoreInterface implementations, see SetStore
+	store StoreInterface
 }
 
 func (e *Entity) ToMap() map[string]any {
@@ -20,6 +26,13 @@ func (e *Entity) ToMap() map[string]any {
 	entry[COLUMN_ID] = e.ID()
 	entry[COLUMN_ENTITY_TYPE] = e.Type()
 	entry[COLUMN_ENTITY_HANDLE] = e.Handle()
+	entry[COLUMN_VERSION] = e.Version()
+	if e.ParentID() != "" || (e.st != nil && e.st.hierarchyEnabled) {
+		entry[COLUMN_PARENT_ID] = e.ParentID()
+	}
+	if e.TenantID() != "" || (e.st != nil && e.st.tenancyEnabled) {
+		entry[COLUMN_TENANT_ID] = e.TenantID()
+	}
 	entry[COLUMN_CREATED_AT] = e.CreatedAt()
 	entry[COLUMN_UPDATED_AT] = e.UpdatedAt()
 	return entry
@@ -37,6 +50,22 @@ func (e *Entity) Handle() string {
 	return e.entityHandle
 }
 
+// ParentID returns the ID of the parent entity, if the hierarchy is enabled
+func (e *Entity) ParentID() string {
+	return e.parentID
+}
+
+// TenantID returns the ID of the tenant owning the entity, if the tenancy is enabled
+func (e *Entity) TenantID() string {
+	return e.tenantID
+}
+
+// Version returns the version of the entity, as last read from the store.
+// The version is incremented on every entity or attribute mutation
+func (e *Entity) Version() int64 {
+	return e.version
+}
+
 func (e *Entity) CreatedAt() time.Time {
 	return e.createdAt
 }
@@ -60,6 +89,21 @@ func (e *Entity) SetHandle(handle string) *Entity {
 	return e
 }
 
+func (e *Entity) SetTenantID(tenantID string) *Entity {
+	e.tenantID = tenantID
+	return e
+}
+
+func (e *Entity) SetParentID(parentID string) *Entity {
+	e.parentID = parentID
+	return e
+}
+
+func (e *Entity) SetVersion(version int64) *Entity {
+	e.version = version
+	return e
+}
+
 func (e *Entity) SetCreatedAt(createdAt time.Time) *Entity {
 	e.createdAt = createdAt
 	return e
@@ -70,6 +114,23 @@ func (e *Entity) SetUpdatedAt(updatedAt time.Time) *Entity {
 	return e
 }
 
+// SetStore binds the entity to the store used by its attribute helpers
+// (GetString, SetString, Save...). The entities of this package are bound
+// already, it is meant for the other StoreInterface implementations
+func (e *Entity) SetStore(store StoreInterface) *Entity {
+	e.store = store
+	return e
+}
+
+// entityStore returns the store the entity is bound to
+func (e *Entity) entityStore() StoreInterface {
+	if e.store != nil {
+		return e.store
+	}
+
+	return e.st
+}
+
 // GetInt the value of the attribute as string or the default value if it does not exist
 func (e *Entity) GetInt(attributeKey string, defaultValue int64) (int64, error) {
 	attr, err := e.GetAttribute(attributeKey)
@@ -87,12 +148,12 @@ func (e *Entity) GetInt(attributeKey string, defaultValue int64) (int64, error)
 
 // GetAttribute return specified attribute
 func (e *Entity) GetAttribute(attributeKey string) (*Attribute, error) {
-	return e.st.AttributeFind(e.ID(), attributeKey)
+	return e.entityStore().AttributeFind(e.ID(), attributeKey)
 }
 
 // GetAttributes all the attributes of the entity
 func (e *Entity) GetAttributes() ([]Attribute, error) {
-	return e.st.EntityAttributeList(e.ID())
+	return e.entityStore().EntityAttributeList(e.ID())
 }
 
 // GetFloat the value of the attribute as float or the default value if it does not exist
@@ -100,9 +161,6 @@ func (e *Entity) GetFloat(attributeKey string, defaultValue float64) (float64, e
 	attr, err := e.GetAttribute(attributeKey)
 
 	if err != nil {
-		if e.st.GetDebug() {
-			log.Println(err)
-		}
 		return defaultValue, err
 	}
 
@@ -118,9 +176,6 @@ func (e *Entity) GetString(attributeKey string, defaultValue string) (string, er
 	attr, err := e.GetAttribute(attributeKey)
 
 	if err != nil {
-		if e.st.GetDebug() {
-			log.Println(err)
-		}
 		return defaultValue, err
 	}
 
@@ -128,25 +183,73 @@ func (e *Entity) GetString(attributeKey string, defaultValue string) (string, er
 		return defaultValue, nil
 	}
 
-	return attr.GetString(), nil
+	return attr.value()
+}
+
+// Save updates the entity, failing with ErrVersionConflict
+// if the entity was modified since it was read
+func (e *Entity) Save() error {
+	e.SetUpdatedAt(time.Now())
+
+	err := e.entityStore().EntityUpdateIfVersion(*e, e.Version())
+
+	if err != nil {
+		return err
+	}
+
+	e.SetVersion(e.Version() + 1)
+
+	return nil
 }
 
 // SetAll upserts the attributes
 func (e *Entity) SetAll(attributes map[string]string) error {
-	return e.st.AttributesSet(e.ID(), attributes)
+	err := e.entityStore().AttributesSet(e.ID(), attributes)
+
+	if err != nil {
+		return err
+	}
+
+	e.SetVersion(e.Version() + int64(len(attributes)))
+
+	return nil
 }
 
 // SetFloat sets an attribute with float value
 func (e *Entity) SetFloat(attributeKey string, attributeValue float64) error {
-	return e.st.AttributeSetFloat(e.ID(), attributeKey, attributeValue)
+	err := e.entityStore().AttributeSetFloat(e.ID(), attributeKey, attributeValue)
+
+	if err != nil {
+		return err
+	}
+
+	e.SetVersion(e.Version() + 1)
+
+	return nil
 }
 
 // SetInt sets an attribute with int value
 func (e *Entity) SetInt(attributeKey string, attributeValue int64) error {
-	return e.st.AttributeSetInt(e.ID(), attributeKey, attributeValue)
+	err := e.entityStore().AttributeSetInt(e.ID(), attributeKey, attributeValue)
+
+	if err != nil {
+		return err
+	}
+
+	e.SetVersion(e.Version() + 1)
+
+	return nil
 }
 
 // SetString sets an attribute with string value
 func (e *Entity) SetString(attributeKey string, attributeValue string) error {
-	return e.st.AttributeSetString(e.ID(), attributeKey, attributeValue)
+	err := e.entityStore().AttributeSetString(e.ID(), attributeKey, attributeValue)
+
+	if err != nil {
+		return err
+	}
+
+	e.SetVersion(e.Version() + 1)
+
+	return nil
 }
@@ -0,0 +1,14 @@
+package entitystore
+
+// EntityAncestors lists the ancestors of an entity, starting with its parent
+func (st *storeImplementation) EntityAncestors(entityID string) (ancestors []Entity, err error) {
+	defer wrapOpError(&err, "EntityAncestors", entityID)
+
+	ids, err := st.entityTreeIDs(entityID, true, 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.entityListInOrder(ids)
+}
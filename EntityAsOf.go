@@ -0,0 +1,179 @@
+package entitystore
+
+import (
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// EntitySnapshot is an entity with the attribute values
+// it had at a specific moment in time
+type EntitySnapshot struct {
+	Entity     Entity
+	Attributes map[string]string
+	AsOf       time.Time
+}
+
+// EntityAsOf returns the entity with the attribute values it had at
+// the specified moment, replayed from the attribute history.
+// Entities in the trash bin are included. Returns nil if the entity
+// does not exist, or did not exist yet at that moment
+func (st *storeImplementation) EntityAsOf(entityID string, t time.Time) (snapshot *EntitySnapshot, err error) {
+	defer wrapOpError(&err, "EntityAsOf", entityID)
+
+	if st.attributeHistoryTableName == "" {
+		return nil, errNotEnabled("attribute history")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	entity, isTrashed, err := st.entityFindIncludingTrash(entityID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if entity == nil || entity.CreatedAt().After(t) {
+		return nil, nil
+	}
+
+	history, err := st.EntityHistory(entityID, EntityHistoryOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{}
+	keysWithHistory := map[string]bool{}
+	keysReplayed := map[string]bool{}
+	firstEntriesAfter := map[string]AttributeHistoryEntry{}
+
+	for _, entry := range history {
+		keysWithHistory[entry.AttributeKey] = true
+
+		if entry.ChangedAt.After(t) {
+			if _, exists := firstEntriesAfter[entry.AttributeKey]; !exists {
+				firstEntriesAfter[entry.AttributeKey] = entry
+			}
+
+			continue
+		}
+
+		keysReplayed[entry.AttributeKey] = true
+
+		if entry.Operation == OPERATION_DELETE {
+			delete(attributes, entry.AttributeKey)
+		} else {
+			attributes[entry.AttributeKey] = entry.NewValue
+		}
+	}
+
+	// Attributes created before the history was enabled, and changed
+	// only after the moment, had the old value of their first change
+	for key, entry := range firstEntriesAfter {
+		if keysReplayed[key] || entry.Operation == OPERATION_CREATE {
+			continue
+		}
+
+		attributes[key] = entry.OldValue
+	}
+
+	// Attributes created before the history was enabled have no entries,
+	// their current value is the one they always had
+	currentAttributes, err := st.entityAttributeListIncludingTrash(entityID, isTrashed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attr := range currentAttributes {
+		if keysWithHistory[attr.AttributeKey()] || attr.CreatedAt().After(t) {
+			continue
+		}
+
+		value, err := attr.value()
+
+		if err != nil {
+			return nil, err
+		}
+
+		attributes[attr.AttributeKey()] = value
+	}
+
+	return &EntitySnapshot{
+		Entity:     *entity,
+		Attributes: attributes,
+		AsOf:       t,
+	}, nil
+}
+
+// entityFindIncludingTrash finds an entity by ID, looking in the trash bin
+// if it is not found in the entity table
+func (st *storeImplementation) entityFindIncludingTrash(entityID string) (entity *Entity, isTrashed bool, err error) {
+	entity, err = st.EntityFindByID(entityID)
+
+	if err != nil || entity != nil {
+		return entity, false, err
+	}
+
+	q := goqu.Dialect(st.dbDriverName).From(st.entityTrashTableName)
+	q = q.Where(goqu.C(COLUMN_ID).Eq(entityID))
+	q = q.Limit(1)
+
+	if st.tenantScoped {
+		q = q.Where(goqu.C(COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
+
+	sqlStr, _, errSql := q.Select().ToSQL()
+
+	if errSql != nil {
+		return nil, false, errSql
+	}
+
+	entityMaps, err := st.selectToMapString("entityFindIncludingTrash", sqlStr)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(entityMaps) < 1 {
+		return nil, false, nil
+	}
+
+	trashed := st.NewEntityFromMap(entityMaps[0])
+
+	return &trashed, true, nil
+}
+
+// entityAttributeListIncludingTrash lists the attributes of an entity,
+// from the attribute trash table if the entity is trashed
+func (st *storeImplementation) entityAttributeListIncludingTrash(entityID string, isTrashed bool) ([]Attribute, error) {
+	if !isTrashed {
+		return st.EntityAttributeList(entityID)
+	}
+
+	q := goqu.Dialect(st.dbDriverName).From(st.attributeTrashTableName)
+	q = q.Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID))
+
+	sqlStr, _, errSql := q.Select().ToSQL()
+
+	if errSql != nil {
+		return nil, errSql
+	}
+
+	attributeMaps, err := st.selectToMapString("entityAttributeListIncludingTrash", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := []Attribute{}
+
+	for _, attributeMap := range attributeMaps {
+		attributes = append(attributes, st.NewAttributeFromMap(attributeMap))
+	}
+
+	return attributes, nil
+}
@@ -0,0 +1,178 @@
+package entitystore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEntityAsOf(t *testing.T) {
+	db := InitDB("test_entity_as_of.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                        db,
+		EntityTableName:           "cms_entity",
+		AttributeTableName:        "cms_attribute",
+		AttributeHistoryTableName: "cms_attribute_history",
+		AutomigrateEnabled:        true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{
+		"title":  "Title 1",
+		"status": "draft",
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	t1 := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	err = store.AttributesSet(entity.ID(), map[string]string{"status": "published", "text": "Text 1"})
+
+	if err != nil {
+		t.Fatal("Attributes could not be set: " + err.Error())
+	}
+
+	_, err = store.AttributeDelete(entity.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Attribute could not be deleted: " + err.Error())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	t2 := time.Now()
+
+	_, err = store.EntityTrash(entity.ID())
+
+	if err != nil {
+		t.Fatal("Entity could not be trashed: " + err.Error())
+	}
+
+	snapshot, err := store.EntityAsOf(entity.ID(), t1)
+
+	if err != nil {
+		t.Fatal("Snapshot could not be created: " + err.Error())
+	}
+
+	if snapshot == nil {
+		t.Fatal("Snapshot must not be nil for a trashed entity")
+	}
+
+	if snapshot.Attributes["title"] != "Title 1" || snapshot.Attributes["status"] != "draft" {
+		t.Fatal("Snapshot attributes mismatch:", snapshot.Attributes)
+	}
+
+	if _, exists := snapshot.Attributes["text"]; exists {
+		t.Fatal("Snapshot must not contain text:", snapshot.Attributes)
+	}
+
+	snapshot, err = store.EntityAsOf(entity.ID(), entity.CreatedAt().Add(-time.Hour))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if snapshot != nil {
+		t.Fatal("Snapshot must be nil before the entity was created")
+	}
+
+	diff, err := store.EntityDiff(entity.ID(), t1, t2)
+
+	if err != nil {
+		t.Fatal("Diff could not be created: " + err.Error())
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0] != "text" {
+		t.Fatal("Added mismatch:", diff.Added)
+	}
+
+	if len(diff.Removed) != 1 || diff.Removed[0] != "title" {
+		t.Fatal("Removed mismatch:", diff.Removed)
+	}
+
+	if len(diff.Changed) != 1 || diff.Changed[0] != "status" {
+		t.Fatal("Changed mismatch:", diff.Changed)
+	}
+}
+
+func TestEntityAsOfBeforeHistoryWasEnabled(t *testing.T) {
+	db := InitDB("test_entity_as_of_before_history.db")
+
+	storeWithoutHistory, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := storeWithoutHistory.EntityCreateWithTypeAndAttributes("post", map[string]string{
+		"title":  "Title 1",
+		"status": "draft",
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                        db,
+		EntityTableName:           "cms_entity",
+		AttributeTableName:        "cms_attribute",
+		AttributeHistoryTableName: "cms_attribute_history",
+		AutomigrateEnabled:        true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	t1 := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	err = store.AttributeSetString(entity.ID(), "status", "published")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	_, err = store.AttributeDelete(entity.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	err = store.AttributeSetString(entity.ID(), "text", "Text 1")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	snapshot, err := store.EntityAsOf(entity.ID(), t1)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if snapshot == nil {
+		t.Fatal("Snapshot must not be nil")
+	}
+
+	if snapshot.Attributes["title"] != "Title 1" || snapshot.Attributes["status"] != "draft" {
+		t.Fatal("Snapshot attributes mismatch:", snapshot.Attributes)
+	}
+
+	if _, exists := snapshot.Attributes["text"]; exists {
+		t.Fatal("Snapshot must not contain text:", snapshot.Attributes)
+	}
+}
@@ -2,6 +2,8 @@ package entitystore
 
 // EntityAttributeList list all attributes of an entity
 func (st *storeImplementation) EntityAttributeList(entityID string) (attributes []Attribute, err error) {
+	defer wrapOpError(&err, "EntityAttributeList", entityID)
+
 	return st.AttributeList(AttributeQueryOptions{
 		EntityID: entityID,
 	})
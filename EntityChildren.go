@@ -0,0 +1,18 @@
+package entitystore
+
+// EntityChildren lists the direct children of an entity
+func (st *storeImplementation) EntityChildren(entityID string) (children []Entity, err error) {
+	defer wrapOpError(&err, "EntityChildren", entityID)
+
+	if !st.hierarchyEnabled {
+		return nil, errNotEnabled("hierarchy")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	return st.EntityList(EntityQueryOptions{
+		ParentID: entityID,
+	})
+}
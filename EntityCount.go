@@ -3,7 +3,7 @@ package entitystore
 import (
 	"context"
 	"database/sql"
-	"log"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/georgysavva/scany/sqlscan"
@@ -11,26 +11,30 @@ import (
 
 // EntityCount counts the entities of a specified type
 // EntityCount counts entities
-func (st *storeImplementation) EntityCount(options EntityQueryOptions) (int64, error) {
+func (st *storeImplementation) EntityCount(options EntityQueryOptions) (count int64, err error) {
+	defer wrapOpError(&err, "EntityCount", "")
+
 	options.CountOnly = true
 
+	if err := st.entityQueryCheck(options); err != nil {
+		return 0, err
+	}
+
 	q := st.EntityQuery(options)
-	sqlStr, _, errSql := q.Limit(1).Select(goqu.COUNT(goqu.Star()).As("count")).ToSQL()
+	sqlStr, _, errSql := q.ClearOrder().Limit(1).Select(goqu.COUNT(goqu.Star()).As("count")).ToSQL()
 
 	if errSql != nil {
 		return 0, errSql
 	}
 
-	if st.debugEnabled {
-		log.Println(sqlStr)
-	}
-
 	type countResult struct {
 		Count int64 `db:"count"`
 	}
 
 	var result countResult
-	err := sqlscan.Get(context.Background(), st.database.DB(), &result, sqlStr)
+	start := time.Now()
+	err = sqlscan.Get(context.Background(), st.database.DB(), &result, sqlStr)
+	st.queryObserve("EntityCount", sqlStr, nil, start, 1, err)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// sqlscan does not use this anymore
@@ -1,8 +1,6 @@
 package entitystore
 
 import (
-	"errors"
-	"log"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
@@ -10,15 +8,34 @@ import (
 )
 
 // EntityCreate creates a new entity
-func (st *storeImplementation) EntityCreate(entity *Entity) error {
+func (st *storeImplementation) EntityCreate(entity *Entity) (err error) {
+	// the ID may be assigned below, so it is read when returning
+	defer func() {
+		entityID := ""
+
+		if entity != nil {
+			entityID = entity.ID()
+		}
+
+		wrapOpError(&err, "EntityCreate", entityID)
+	}()
+
 	if entity == nil {
-		return errors.New("entity cannot be nil")
+		return errInvalidArgument("entity cannot be nil")
 	}
 
 	if entity.ID() == "" {
 		entity.SetID(uid.HumanUid())
 	}
 
+	if st.tenantScoped {
+		entity.SetTenantID(st.tenantID)
+	}
+
+	if entity.Version() < 1 {
+		entity.SetVersion(1)
+	}
+
 	if entity.CreatedAt().IsZero() {
 		entity.SetCreatedAt(time.Now())
 	}
@@ -27,24 +44,36 @@ func (st *storeImplementation) EntityCreate(entity *Entity) error {
 		entity.SetUpdatedAt(time.Now())
 	}
 
-	q := goqu.Dialect(st.dbDriverName).Insert(st.entityTableName)
-	q = q.Rows(entity.ToMap())
+	return st.inTransaction(func(tx *storeImplementation) error {
+		if err := tx.entityHooksRun(hookBeforeEntityCreate, entity); err != nil {
+			return err
+		}
 
-	sqlStr, _, errSql := q.ToSQL()
+		q := goqu.Dialect(tx.dbDriverName).Insert(tx.entityTableName)
+		q = q.Rows(entity.ToMap())
 
-	if errSql != nil {
-		return errSql
-	}
+		sqlStr, _, errSql := q.ToSQL()
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
+		if errSql != nil {
+			return errSql
+		}
 
-	_, err := st.database.Exec(sqlStr)
+		_, err := tx.exec("EntityCreate", sqlStr)
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.projectionSync(entity.ID()); err != nil {
+			return err
+		}
+
+		err = tx.changeRecordEntity(CHANGE_ENTITY_CREATE, entity, nil)
+
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return tx.entityHooksRun(hookAfterEntityCreate, entity)
+	})
 }
@@ -9,7 +9,9 @@ import (
 // EntityCreateWithType quick shortcut method
 // to create an entity by providing only the type
 // NB. The ID will be auto-assigned
-func (st *storeImplementation) EntityCreateWithType(entityType string) (*Entity, error) {
+func (st *storeImplementation) EntityCreateWithType(entityType string) (newEntity *Entity, err error) {
+	defer wrapOpError(&err, "EntityCreateWithType", "")
+
 	entity := st.NewEntity(NewEntityOptions{
 		ID:        uid.HumanUid(),
 		Type:      entityType,
@@ -18,7 +20,7 @@ func (st *storeImplementation) EntityCreateWithType(entityType string) (*Entity,
 		UpdatedAt: time.Now(),
 	})
 
-	err := st.EntityCreate(&entity)
+	err = st.EntityCreate(&entity)
 
 	if err != nil {
 		return &entity, err
@@ -1,49 +1,46 @@
 package entitystore
 
-import "log"
-
 // EntityCreateWithTypeAndAttributes quick shortcut method
 // to create an entity by providing only the type as string
 // and the attributes as map
 // NB. The IDs will be auto-assigned
-func (st *storeImplementation) EntityCreateWithTypeAndAttributes(entityType string, attributes map[string]string) (*Entity, error) {
-	err := st.database.BeginTransaction()
+func (st *storeImplementation) EntityCreateWithTypeAndAttributes(entityType string, attributes map[string]string) (newEntity *Entity, err error) {
+	defer wrapOpError(&err, "EntityCreateWithTypeAndAttributes", "")
 
-	if err != nil {
-		return nil, err
-	}
+	var entity *Entity
 
-	defer func() {
-		if r := recover(); r != nil {
-			err = st.database.RollbackTransaction()
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		var err error
+		entity, err = tx.EntityCreateWithType(entityType)
 
-			if err != nil {
-				log.Println(err)
-				return
-			}
+		if err != nil {
+			return err
 		}
-	}()
 
-	entity, err := st.EntityCreateWithType(entityType)
+		for k, v := range attributes {
+			_, err := tx.AttributeCreateWithKeyAndValue(entity.ID(), k, v)
 
-	if err != nil {
-		_ = st.database.RollbackTransaction()
-		return nil, err
-	}
+			if err != nil {
+				return err
+			}
+		}
 
-	for k, v := range attributes {
-		_, err := st.AttributeCreateWithKeyAndValue(entity.ID(), k, v)
+		// read again, each attribute has bumped the version
+		entityID := entity.ID()
+		entity, err = tx.EntityFindByID(entityID)
 
 		if err != nil {
-			_ = st.database.RollbackTransaction()
-			return nil, err
+			return err
+		}
+
+		if entity == nil {
+			return errEntityNotFound(entityID)
 		}
-	}
 
-	err = st.database.CommitTransaction()
+		return nil
+	})
 
 	if err != nil {
-		_ = st.database.RollbackTransaction()
 		return nil, err
 	}
 
@@ -1,78 +1,84 @@
 package entitystore
 
 import (
-	"errors"
-	"log"
-
 	"github.com/doug-martin/goqu/v9"
 )
 
-// EntityDelete deletes an entity and all attributes
-func (st *storeImplementation) EntityDelete(entityID string) (bool, error) {
+// EntityDelete deletes an entity and all attributes.
+// Returns ErrEntityNotFound if the entity does not exist
+func (st *storeImplementation) EntityDelete(entityID string) (isDeleted bool, err error) {
+	defer wrapOpError(&err, "EntityDelete", entityID)
+
 	if entityID == "" {
-		if st.GetDebug() {
-			log.Println("in EntityDelete entity ID cannot be empty")
-		}
-		return false, errors.New("in EntityDelete entity ID cannot be empty")
+		return false, errInvalidArgument("entity id cannot be empty")
 	}
 
-	// Note the use of tx as the database handle once you are within a transaction
-	err := st.database.BeginTransaction()
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		ent, err := tx.EntityFindByID(entityID)
 
-	if err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if err != nil {
+			return err
 		}
-		return false, err
-	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			txErr := st.database.RollbackTransaction()
-			if txErr != nil && st.GetDebug() {
-				log.Println(txErr)
-			}
+		// the entities of other tenants are not found too
+		if ent == nil {
+			return errEntityNotFound(entityID)
 		}
-	}()
 
-	sqlStr1, _, _ := goqu.Dialect(st.dbDriverName).From(st.attributeTableName).Where(goqu.C("entity_id").Eq(entityID)).Delete().ToSQL()
-
-	if _, err := st.database.Exec(sqlStr1); err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if err := tx.entityHooksRun(hookBeforeEntityDelete, ent); err != nil {
+			return err
 		}
-		txErr := st.database.RollbackTransaction()
-		if txErr != nil && st.GetDebug() {
-			log.Println(txErr)
+
+		attributeKeys := []string{}
+
+		if tx.attributeHistoryTableName != "" || tx.outboxTableName != "" || tx.cache != nil {
+			attrs, err := tx.EntityAttributeList(entityID)
+
+			if err != nil {
+				return err
+			}
+
+			for _, attr := range attrs {
+				attributeKeys = append(attributeKeys, attr.AttributeKey())
+				tx.cacheInvalidate(cacheKeyAttribute(entityID, attr.AttributeKey()))
+
+				err = tx.attributeHistoryRecord(entityID, attr.AttributeKey(), OPERATION_DELETE, attr.AttributeValue(), "")
+				if err != nil {
+					return err
+				}
+			}
 		}
-		return false, err
-	}
 
-	sqlStr2, _, _ := goqu.Dialect(st.dbDriverName).From(st.entityTableName).Where(goqu.C("id").Eq(entityID)).Delete().ToSQL()
+		sqlStr1, _, _ := goqu.Dialect(tx.dbDriverName).From(tx.attributeTableName).Where(goqu.C("entity_id").Eq(entityID)).Delete().ToSQL()
 
-	if _, err := st.database.Exec(sqlStr2); err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if _, err := tx.exec("EntityDelete", sqlStr1); err != nil {
+			return err
 		}
-		err := st.database.RollbackTransaction()
-		if err != nil && st.GetDebug() {
-			log.Println(err)
+
+		tx.cacheInvalidate(cacheKeyEntity(entityID))
+
+		if err := tx.entityLinksDelete(entityID); err != nil {
+			return err
 		}
-		return false, err
-	}
 
-	err = st.database.CommitTransaction()
+		sqlStr2, _, _ := goqu.Dialect(tx.dbDriverName).From(tx.entityTableName).Where(goqu.C("id").Eq(entityID)).Delete().ToSQL()
 
-	if err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if _, err := tx.exec("EntityDelete", sqlStr2); err != nil {
+			return err
+		}
+
+		if err := tx.projectionSync(entityID); err != nil {
+			return err
 		}
 
-		err := st.database.RollbackTransaction()
-		if err != nil && st.GetDebug() {
-			log.Println(err)
+		if err := tx.changeRecordEntity(CHANGE_ENTITY_DELETE, ent, attributeKeys); err != nil {
+			return err
 		}
 
+		return tx.entityHooksRun(hookAfterEntityDelete, ent)
+	})
+
+	if err != nil {
 		return false, err
 	}
 
@@ -0,0 +1,15 @@
+package entitystore
+
+// EntityDescendants lists the descendants of an entity, nearest first,
+// up to maxDepth levels deep (0 for all levels)
+func (st *storeImplementation) EntityDescendants(entityID string, maxDepth int) (descendants []Entity, err error) {
+	defer wrapOpError(&err, "EntityDescendants", entityID)
+
+	ids, err := st.entityTreeIDs(entityID, false, maxDepth)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return st.entityListInOrder(ids)
+}
@@ -0,0 +1,70 @@
+package entitystore
+
+import (
+	"sort"
+	"time"
+)
+
+// EntityDiffResult lists the attribute keys which differ
+// between two moments in time
+type EntityDiffResult struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// EntityDiff compares the attributes of an entity at two moments in time
+func (st *storeImplementation) EntityDiff(entityID string, t1 time.Time, t2 time.Time) (diff *EntityDiffResult, err error) {
+	defer wrapOpError(&err, "EntityDiff", entityID)
+
+	before, err := st.EntityAsOf(entityID, t1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := st.EntityAsOf(entityID, t2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	beforeAttributes := map[string]string{}
+	afterAttributes := map[string]string{}
+
+	if before != nil {
+		beforeAttributes = before.Attributes
+	}
+
+	if after != nil {
+		afterAttributes = after.Attributes
+	}
+
+	diff = &EntityDiffResult{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	for key, afterValue := range afterAttributes {
+		beforeValue, exists := beforeAttributes[key]
+
+		if !exists {
+			diff.Added = append(diff.Added, key)
+		} else if beforeValue != afterValue {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+
+	for key := range beforeAttributes {
+		if _, exists := afterAttributes[key]; !exists {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+
+	return diff, nil
+}
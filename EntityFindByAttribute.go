@@ -1,37 +1,38 @@
 package entitystore
 
-import (
-	"database/sql"
-	"log"
-
-	"github.com/doug-martin/goqu/v9"
-)
+import "github.com/doug-martin/goqu/v9"
 
 // EntityFindByAttribute finds an entity by attribute
-func (st *storeImplementation) EntityFindByAttribute(entityType string, attributeKey string, attributeValue string) (*Entity, error) {
+func (st *storeImplementation) EntityFindByAttribute(entityType string, attributeKey string, attributeValue string) (entity *Entity, err error) {
+	defer wrapOpError(&err, "EntityFindByAttribute", "")
+
+	// sealed values are random bytes to the database
+	if st.attributeEncrypted(attributeKey) {
+		return nil, errInvalidArgument("attribute " + attributeKey + " is encrypted and cannot be queried")
+	}
+
 	q := goqu.Dialect(st.dbDriverName).From(st.attributeTableName)
 	q = q.LeftJoin(goqu.I(st.entityTableName), goqu.On(goqu.Ex{st.attributeTableName + "." + COLUMN_ENTITY_ID: goqu.I(st.entityTableName + "." + COLUMN_ID)}))
 	q = q.Where(goqu.C(COLUMN_ENTITY_TYPE).Eq(entityType))
-	q = q.Where(goqu.And(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(attributeKey), goqu.C(COLUMN_ATTRIBUTE_VALUE).Eq(attributeValue)))
-	q = q.Select(COLUMN_ENTITY_ID)
+	q = q.Where(goqu.And(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(attributeKey), goqu.C(COLUMN_ATTRIBUTE_VALUE).Eq(st.valueEscape(attributeValue))))
 
-	sqlStr, _, _ := q.ToSQL()
-	if st.GetDebug() {
-		log.Println(sqlStr)
+	if st.tenantScoped {
+		q = q.Where(goqu.I(st.entityTableName + "." + COLUMN_TENANT_ID).Eq(st.tenantID))
 	}
 
-	var entityID string
-	err := st.database.DB().QueryRow(sqlStr).Scan(&entityID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	q = q.Select(COLUMN_ENTITY_ID).Limit(1)
 
-		if st.GetDebug() {
-			log.Println(err)
-		}
+	sqlStr, _, _ := q.ToSQL()
+
+	rows, err := st.selectToMapString("EntityFindByAttribute", sqlStr)
+
+	if err != nil {
 		return nil, err
 	}
 
-	return st.EntityFindByID(entityID)
+	if len(rows) < 1 {
+		return nil, nil
+	}
+
+	return st.EntityFindByID(rows[0][COLUMN_ENTITY_ID])
 }
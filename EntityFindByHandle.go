@@ -1,15 +1,15 @@
 package entitystore
 
-import "errors"
-
 // EntityFindByHandle finds an entity by handle
-func (st *storeImplementation) EntityFindByHandle(entityType string, entityHandle string) (*Entity, error) {
+func (st *storeImplementation) EntityFindByHandle(entityType string, entityHandle string) (entity *Entity, err error) {
+	defer wrapOpError(&err, "EntityFindByHandle", "")
+
 	if entityType == "" {
-		return nil, errors.New("entity type cannot be empty")
+		return nil, errInvalidArgument("entity type cannot be empty")
 	}
 
 	if entityHandle == "" {
-		return nil, errors.New("entity handle cannot be empty")
+		return nil, errInvalidArgument("entity handle cannot be empty")
 	}
 
 	list, err := st.EntityList(EntityQueryOptions{
@@ -1,13 +1,27 @@
 package entitystore
 
-import "errors"
-
 // EntityFindByID finds an entity by ID
-func (st *storeImplementation) EntityFindByID(entityID string) (*Entity, error) {
+func (st *storeImplementation) EntityFindByID(entityID string) (entity *Entity, err error) {
+	defer wrapOpError(&err, "EntityFindByID", entityID)
+
 	if entityID == "" {
-		return nil, errors.New("entity ID cannot be empty")
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if cached, exists := st.cacheGet(cacheKeyEntity(entityID)); exists {
+		entity := cached.(Entity)
+
+		if st.tenantScoped && entity.TenantID() != st.tenantID {
+			return nil, nil
+		}
+
+		entity.st = st
+
+		return &entity, nil
 	}
 
+	generation := st.cacheGeneration()
+
 	list, err := st.EntityList(EntityQueryOptions{
 		ID:    entityID,
 		Limit: 1,
@@ -18,6 +32,7 @@ func (st *storeImplementation) EntityFindByID(entityID string) (*Entity, error)
 	}
 
 	if len(list) > 0 {
+		st.cacheSet(cacheKeyEntity(entityID), list[0], generation)
 		return &list[0], nil
 	}
 
@@ -0,0 +1,126 @@
+package entitystore
+
+import "testing"
+
+func TestEntityHierarchy(t *testing.T) {
+	db := InitDB("test_entity_hierarchy.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                    db,
+		EntityTableName:       "cms_entity",
+		AttributeTableName:    "cms_attribute",
+		HierarchyEnabled:      true,
+		HierarchyTrashCascade: true,
+		AutomigrateEnabled:    true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// root > folder > subfolder > file
+	names := []string{"root", "folder", "subfolder", "file"}
+	entities := []*Entity{}
+
+	for i, name := range names {
+		entity, err := store.EntityCreateWithTypeAndAttributes("folder", map[string]string{"name": name})
+
+		if err != nil {
+			t.Fatal("Entity could not be created: " + err.Error())
+		}
+
+		if i > 0 {
+			err = store.EntitySetParent(entity.ID(), entities[i-1].ID())
+
+			if err != nil {
+				t.Fatal("Parent could not be set: " + err.Error())
+			}
+		}
+
+		entities = append(entities, entity)
+	}
+
+	err = store.EntitySetParent(entities[0].ID(), entities[3].ID())
+
+	if err == nil {
+		t.Fatal("Moving an entity under its descendant must fail")
+	}
+
+	children, err := store.EntityChildren(entities[0].ID())
+
+	if err != nil {
+		t.Fatal("Children could not be listed: " + err.Error())
+	}
+
+	if len(children) != 1 || children[0].ID() != entities[1].ID() {
+		t.Fatal("Children mismatch:", len(children))
+	}
+
+	ancestors, err := store.EntityAncestors(entities[3].ID())
+
+	if err != nil {
+		t.Fatal("Ancestors could not be listed: " + err.Error())
+	}
+
+	if len(ancestors) != 3 || ancestors[0].ID() != entities[2].ID() || ancestors[2].ID() != entities[0].ID() {
+		t.Fatal("Ancestors mismatch:", len(ancestors))
+	}
+
+	descendants, err := store.EntityDescendants(entities[0].ID(), 2)
+
+	if err != nil {
+		t.Fatal("Descendants could not be listed: " + err.Error())
+	}
+
+	if len(descendants) != 2 || descendants[0].ID() != entities[1].ID() || descendants[1].ID() != entities[2].ID() {
+		t.Fatal("Descendants mismatch:", len(descendants))
+	}
+
+	isTrashed, err := store.EntityTrash(entities[1].ID())
+
+	if err != nil || !isTrashed {
+		t.Fatal("Entity could not be trashed:", err)
+	}
+
+	count, err := store.EntityCount(EntityQueryOptions{EntityType: "folder"})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if count != 1 {
+		t.Fatal("Descendants must be trashed together, remaining: ", count)
+	}
+
+	isRestored, err := store.EntityRestore(entities[1].ID())
+
+	if err != nil || !isRestored {
+		t.Fatal("Entity could not be restored:", err)
+	}
+
+	descendants, err = store.EntityDescendants(entities[0].ID(), 0)
+
+	if err != nil {
+		t.Fatal("Descendants could not be listed: " + err.Error())
+	}
+
+	if len(descendants) != 3 {
+		t.Fatal("Descendants must be restored together, found: ", len(descendants))
+	}
+
+	file, err := store.EntityFindByID(entities[3].ID())
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	name, err := file.GetString("name", "")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if name != "file" || file.ParentID() != entities[2].ID() {
+		t.Fatal("Restored entity mismatch:", name, file.ParentID())
+	}
+}
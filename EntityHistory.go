@@ -0,0 +1,98 @@
+package entitystore
+
+import (
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type EntityHistoryOptions struct {
+	AttributeKey string
+	Since        time.Time
+	Until        time.Time
+	Limit        uint64
+	Offset       uint64
+	SortOrder    string // asc / desc
+}
+
+// EntityHistory lists the attribute changes of an entity,
+// oldest first unless SortOrder is desc. The values are decoded
+func (st *storeImplementation) EntityHistory(entityID string, options EntityHistoryOptions) (entries []AttributeHistoryEntry, err error) {
+	defer wrapOpError(&err, "EntityHistory", entityID)
+
+	if st.attributeHistoryTableName == "" {
+		return nil, errNotEnabled("attribute history")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	visible, err := st.tenantEntityVisible(entityID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !visible {
+		return []AttributeHistoryEntry{}, nil
+	}
+
+	q := goqu.Dialect(st.dbDriverName).From(st.attributeHistoryTableName)
+	q = q.Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID))
+
+	if options.AttributeKey != "" {
+		q = q.Where(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(options.AttributeKey))
+	}
+
+	if !options.Since.IsZero() {
+		q = q.Where(goqu.C(COLUMN_CHANGED_AT).Gte(options.Since))
+	}
+
+	if !options.Until.IsZero() {
+		q = q.Where(goqu.C(COLUMN_CHANGED_AT).Lte(options.Until))
+	}
+
+	if options.SortOrder == "desc" {
+		q = q.Order(goqu.C(COLUMN_CHANGED_AT).Desc(), goqu.C(COLUMN_ID).Desc())
+	} else {
+		q = q.Order(goqu.C(COLUMN_CHANGED_AT).Asc(), goqu.C(COLUMN_ID).Asc())
+	}
+
+	if options.Limit > 0 {
+		q = q.Limit(uint(options.Limit))
+	}
+
+	if options.Offset > 0 {
+		q = q.Offset(uint(options.Offset))
+	}
+
+	sqlStr, _, errSql := q.Select().ToSQL()
+
+	if errSql != nil {
+		return nil, errSql
+	}
+
+	entryMaps, err := st.selectToMapString("EntityHistory", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entryMap := range entryMaps {
+		entry := newAttributeHistoryEntryFromMap(entryMap)
+
+		// the values are recorded encoded
+		if entry.OldValue, err = st.attributeValueDecode(entry.AttributeKey, entry.OldValue); err != nil {
+			return nil, err
+		}
+
+		if entry.NewValue, err = st.attributeValueDecode(entry.AttributeKey, entry.NewValue); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,128 @@
+package entitystore
+
+import (
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gouniverse/uid"
+)
+
+type EntityLinkOptions struct {
+	Position int64
+	Metadata map[string]string
+}
+
+// EntityLink links two existing entities with the specified relation.
+// If the link already exists its position and metadata are updated
+func (st *storeImplementation) EntityLink(fromEntityID string, toEntityID string, relation string, options EntityLinkOptions) (link *Link, err error) {
+	defer wrapOpError(&err, "EntityLink", fromEntityID)
+
+	if st.linkTableName == "" {
+		return nil, errNotEnabled("entity links")
+	}
+
+	if fromEntityID == "" || toEntityID == "" {
+		return nil, errInvalidArgument("entity ids cannot be empty")
+	}
+
+	if relation == "" {
+		return nil, errInvalidArgument("relation cannot be empty")
+	}
+
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		for _, entityID := range []string{fromEntityID, toEntityID} {
+			entity, err := tx.EntityFindByID(entityID)
+
+			if err != nil {
+				return err
+			}
+
+			if entity == nil {
+				return errEntityNotFound(entityID)
+			}
+		}
+
+		existing, err := tx.linkList(goqu.Ex{
+			COLUMN_FROM_ID:  fromEntityID,
+			COLUMN_TO_ID:    toEntityID,
+			COLUMN_RELATION: relation,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		var sqlStr string
+		var errSql error
+
+		if len(existing) > 0 {
+			link = &existing[0]
+			link.Position = options.Position
+			link.Metadata = options.Metadata
+			link.UpdatedAt = time.Now()
+
+			sqlStr, _, errSql = goqu.Dialect(tx.dbDriverName).Update(tx.linkTableName).
+				Where(goqu.C(COLUMN_ID).Eq(link.ID)).
+				Set(link.ToMap()).
+				ToSQL()
+		} else {
+			link = &Link{
+				ID:        uid.HumanUid(),
+				FromID:    fromEntityID,
+				ToID:      toEntityID,
+				Relation:  relation,
+				Position:  options.Position,
+				Metadata:  options.Metadata,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+
+			sqlStr, _, errSql = goqu.Dialect(tx.dbDriverName).Insert(tx.linkTableName).
+				Rows(link.ToMap()).
+				ToSQL()
+		}
+
+		if errSql != nil {
+			return errSql
+		}
+
+		_, err = tx.exec("EntityLink", sqlStr)
+
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// linkList lists the links matching the expression, ordered by position
+func (st *storeImplementation) linkList(where goqu.Expression) (links []Link, err error) {
+	q := goqu.Dialect(st.dbDriverName).From(st.linkTableName).
+		Where(where).
+		Order(goqu.C(COLUMN_POSITION).Asc(), goqu.C(COLUMN_ID).Asc())
+
+	sqlStr, _, errSql := q.Select().ToSQL()
+
+	if errSql != nil {
+		return nil, errSql
+	}
+
+	linkMaps, err := st.selectToMapString("linkList", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, linkMap := range linkMaps {
+		links = append(links, newLinkFromMap(linkMap))
+	}
+
+	return links, nil
+}
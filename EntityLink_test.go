@@ -0,0 +1,101 @@
+package entitystore
+
+import "testing"
+
+func TestEntityLink(t *testing.T) {
+	db := InitDB("test_entity_link.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		LinkTableName:      "cms_link",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	order, err := store.EntityCreateWithType("order")
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	products := []*Entity{}
+
+	for i := 0; i < 3; i++ {
+		product, err := store.EntityCreateWithType("product")
+
+		if err != nil {
+			t.Fatal("Entity could not be created: " + err.Error())
+		}
+
+		products = append(products, product)
+	}
+
+	// linked in reverse order of creation, to check the position order
+	for i, product := range products {
+		_, err := store.EntityLink(order.ID(), product.ID(), "items", EntityLinkOptions{
+			Position: int64(len(products) - i),
+			Metadata: map[string]string{"quantity": "1"},
+		})
+
+		if err != nil {
+			t.Fatal("Entities could not be linked: " + err.Error())
+		}
+	}
+
+	_, err = store.EntityLink(order.ID(), "MISSING_ID", "items", EntityLinkOptions{})
+
+	if err == nil {
+		t.Fatal("Linking a missing entity must fail")
+	}
+
+	related, err := store.EntityListRelated(order.ID(), "items", EntityQueryOptions{Limit: 2})
+
+	if err != nil {
+		t.Fatal("Related entities could not be listed: " + err.Error())
+	}
+
+	if len(related) != 2 || related[0].ID() != products[2].ID() || related[1].ID() != products[1].ID() {
+		t.Fatal("Related entities must follow the link positions")
+	}
+
+	incoming, err := store.EntityLinks(products[0].ID(), "items", LINK_DIRECTION_INCOMING)
+
+	if err != nil {
+		t.Fatal("Links could not be listed: " + err.Error())
+	}
+
+	if len(incoming) != 1 || incoming[0].FromID != order.ID() || incoming[0].Metadata["quantity"] != "1" {
+		t.Fatal("Incoming link mismatch:", incoming)
+	}
+
+	isUnlinked, err := store.EntityUnlink(order.ID(), products[0].ID(), "items")
+
+	if err != nil {
+		t.Fatal("Entities could not be unlinked: " + err.Error())
+	}
+
+	if !isUnlinked {
+		t.Fatal("Entities must be unlinked")
+	}
+
+	_, err = store.EntityTrash(products[1].ID())
+
+	if err != nil {
+		t.Fatal("Entity could not be trashed: " + err.Error())
+	}
+
+	outgoing, err := store.EntityLinks(order.ID(), "", LINK_DIRECTION_OUTGOING)
+
+	if err != nil {
+		t.Fatal("Links could not be listed: " + err.Error())
+	}
+
+	if len(outgoing) != 1 || outgoing[0].ToID != products[2].ID() {
+		t.Fatal("Only the link to the third product must remain, found: ", len(outgoing))
+	}
+}
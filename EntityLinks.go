@@ -0,0 +1,50 @@
+package entitystore
+
+import (
+	"github.com/doug-martin/goqu/v9"
+)
+
+// EntityLinks lists the links of an entity, ordered by position.
+// The direction is one of LINK_DIRECTION_OUTGOING (default),
+// LINK_DIRECTION_INCOMING or LINK_DIRECTION_BOTH. An empty relation
+// matches all relations
+func (st *storeImplementation) EntityLinks(entityID string, relation string, direction string) (links []Link, err error) {
+	defer wrapOpError(&err, "EntityLinks", entityID)
+
+	if st.linkTableName == "" {
+		return nil, errNotEnabled("entity links")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	visible, err := st.tenantEntityVisible(entityID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !visible {
+		return []Link{}, nil
+	}
+
+	var where goqu.Expression
+
+	switch direction {
+	case LINK_DIRECTION_INCOMING:
+		where = goqu.C(COLUMN_TO_ID).Eq(entityID)
+	case LINK_DIRECTION_BOTH:
+		where = goqu.Or(goqu.C(COLUMN_FROM_ID).Eq(entityID), goqu.C(COLUMN_TO_ID).Eq(entityID))
+	case LINK_DIRECTION_OUTGOING, "":
+		where = goqu.C(COLUMN_FROM_ID).Eq(entityID)
+	default:
+		return nil, errInvalidArgument("unsupported link direction " + direction)
+	}
+
+	if relation != "" {
+		where = goqu.And(where, goqu.C(COLUMN_RELATION).Eq(relation))
+	}
+
+	return st.linkList(where)
+}
@@ -1,11 +1,13 @@
 package entitystore
 
-import (
-	"log"
-)
-
 // EntityList lists entities
 func (st *storeImplementation) EntityList(options EntityQueryOptions) (entityList []Entity, err error) {
+	defer wrapOpError(&err, "EntityList", "")
+
+	if err := st.entityQueryCheck(options); err != nil {
+		return nil, err
+	}
+
 	q := st.EntityQuery(options)
 
 	sqlStr, _, errSql := q.ToSQL()
@@ -14,11 +16,7 @@ func (st *storeImplementation) EntityList(options EntityQueryOptions) (entityLis
 		return entityList, errSql
 	}
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
-
-	entityMaps, errSelect := st.database.SelectToMapString(sqlStr)
+	entityMaps, errSelect := st.selectToMapString("EntityList", sqlStr)
 	// errScan := sqlscan.Select(context.Background(), st.db, &entityMaps, sqlStr)
 	// if errScan != nil {
 	// 	if errScan == sql.ErrNoRows {
@@ -35,7 +33,6 @@ func (st *storeImplementation) EntityList(options EntityQueryOptions) (entityLis
 	// }
 
 	if errSelect != nil {
-		log.Println("EntityList. Error: ", errSelect.Error())
 		return nil, errSelect
 	}
 
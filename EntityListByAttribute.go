@@ -1,47 +1,44 @@
 package entitystore
 
 import (
-	"log"
-
 	"github.com/doug-martin/goqu/v9"
 )
 
 // EntityListByAttribute finds an entity by attribute
 func (st *storeImplementation) EntityListByAttribute(entityType string, attributeKey string, attributeValue string) (entityList []Entity, err error) {
+	defer wrapOpError(&err, "EntityListByAttribute", "")
+
+	// sealed values are random bytes to the database
+	if st.attributeEncrypted(attributeKey) {
+		return nil, errInvalidArgument("attribute " + attributeKey + " is encrypted and cannot be queried")
+	}
+
 	var entityIDs []string
 
 	q := goqu.Dialect(st.dbDriverName).From(st.attributeTableName).
 		LeftJoin(goqu.I(st.entityTableName), goqu.On(goqu.Ex{st.attributeTableName + "." + COLUMN_ENTITY_ID: goqu.I(st.entityTableName + "." + COLUMN_ID)})).
 		Where(goqu.C(COLUMN_ENTITY_TYPE).Eq(entityType)).
-		Where(goqu.And(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(attributeKey), goqu.C(COLUMN_ATTRIBUTE_VALUE).Eq(attributeValue))).
+		Where(goqu.And(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(attributeKey), goqu.C(COLUMN_ATTRIBUTE_VALUE).Eq(st.valueEscape(attributeValue)))).
 		Select(COLUMN_ENTITY_ID)
 
+	if st.tenantScoped {
+		q = q.Where(goqu.I(st.entityTableName + "." + COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
+
 	sqlStr, _, err := q.ToSQL()
 
 	if err != nil {
-		if st.GetDebug() {
-			log.Println(err.Error())
-		}
 		return nil, err
 	}
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
-
-	rows, err := st.database.Query(sqlStr)
+	rows, err := st.selectToMapString("EntityListByAttribute", sqlStr)
 
 	if err != nil {
 		return []Entity{}, err
 	}
 
-	for rows.Next() {
-		var entityID string
-		err := rows.Scan(&entityID)
-		if err != nil {
-			return []Entity{}, err
-		}
-		entityIDs = append(entityIDs, entityID)
+	for _, row := range rows {
+		entityIDs = append(entityIDs, row[COLUMN_ENTITY_ID])
 	}
 
 	if len(entityIDs) < 1 {
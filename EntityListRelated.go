@@ -0,0 +1,77 @@
+package entitystore
+
+// EntityListRelated lists the entities linked from the specified entity
+// with the relation. Unless options.SortBy is set, the entities are
+// returned in the order of the link positions
+func (st *storeImplementation) EntityListRelated(entityID string, relation string, options EntityQueryOptions) (entities []Entity, err error) {
+	defer wrapOpError(&err, "EntityListRelated", entityID)
+
+	links, err := st.EntityLinks(entityID, relation, LINK_DIRECTION_OUTGOING)
+
+	if err != nil {
+		return nil, err
+	}
+
+	relatedIDs := []string{}
+
+	for _, link := range links {
+		if len(options.IDs) > 0 && !contains(options.IDs, link.ToID) {
+			continue
+		}
+		relatedIDs = append(relatedIDs, link.ToID)
+	}
+
+	if len(relatedIDs) < 1 {
+		return []Entity{}, nil
+	}
+
+	options.IDs = relatedIDs
+
+	if options.SortBy != "" {
+		return st.EntityList(options)
+	}
+
+	// Keep the link order, paginate after sorting
+	limit := options.Limit
+	offset := options.Offset
+	options.Limit = 0
+	options.Offset = 0
+
+	list, err := st.EntityList(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByID := map[string]Entity{}
+
+	for _, entity := range list {
+		entitiesByID[entity.ID()] = entity
+	}
+
+	related := []Entity{}
+	added := map[string]bool{}
+
+	for _, relatedID := range relatedIDs {
+		entity, exists := entitiesByID[relatedID]
+
+		if !exists || added[relatedID] {
+			continue
+		}
+
+		added[relatedID] = true
+		related = append(related, entity)
+	}
+
+	if offset >= uint64(len(related)) {
+		return []Entity{}, nil
+	}
+
+	related = related[offset:]
+
+	if limit > 0 && limit < uint64(len(related)) {
+		related = related[:limit]
+	}
+
+	return related, nil
+}
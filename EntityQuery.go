@@ -1,18 +1,28 @@
 package entitystore
 
-import "github.com/doug-martin/goqu/v9"
+import (
+	"sort"
+
+	"github.com/doug-martin/goqu/v9"
+)
 
 type EntityQueryOptions struct {
 	ID           string
 	IDs          []string
 	EntityType   string
 	EntityHandle string
+	ParentID     string
 	Limit        uint64
 	Offset       uint64
 	Search       string
 	SortBy       string
 	SortOrder    string // asc / dec
 	CountOnly    bool
+
+	// Where optional, only the entities having all these attribute values
+	Where map[string]string
+	// SortByAttribute optional, sorts by the value of the attribute instead of SortBy
+	SortByAttribute string
 }
 
 func (st *storeImplementation) EntityQuery(options EntityQueryOptions) *goqu.SelectDataset {
@@ -37,12 +47,35 @@ func (st *storeImplementation) EntityQuery(options EntityQueryOptions) *goqu.Sel
 		sortByColumn = options.SortBy
 	}
 
-	if sortOrder == "asc" {
+	if options.SortByAttribute != "" {
+		sortBy := goqu.L("(?)", st.entityAttributeValueQuery(options.SortByAttribute))
+
+		if sortOrder == "asc" {
+			q = q.Order(sortBy.Asc(), goqu.I(st.entityTableName+"."+COLUMN_ID).Asc())
+		} else {
+			q = q.Order(sortBy.Desc(), goqu.I(st.entityTableName+"."+COLUMN_ID).Asc())
+		}
+	} else if sortOrder == "asc" {
 		q = q.Order(goqu.I(sortByColumn).Asc())
 	} else {
 		q = q.Order(goqu.I(sortByColumn).Desc())
 	}
 
+	whereKeys := []string{}
+
+	for key := range options.Where {
+		whereKeys = append(whereKeys, key)
+	}
+
+	sort.Strings(whereKeys)
+
+	// through the projection of the type, if built and it has the attribute
+	projection := st.projectionBuiltFor(options.EntityType)
+
+	for _, key := range whereKeys {
+		q = q.Where(goqu.C(COLUMN_ID).In(st.entityIDsWithAttributeQuery(projection, key, options.Where[key])))
+	}
+
 	if options.EntityType != "" {
 		q = q.Where(goqu.C(COLUMN_ENTITY_TYPE).Eq(options.EntityType))
 	}
@@ -51,10 +84,12 @@ func (st *storeImplementation) EntityQuery(options EntityQueryOptions) *goqu.Sel
 		q = q.Where(goqu.C(COLUMN_ENTITY_HANDLE).Eq(options.EntityHandle))
 	}
 
-	q = q.Offset(uint(options.Offset))
+	if st.tenantScoped {
+		q = q.Where(goqu.C(COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
 
-	if options.Limit != 0 {
-		q = q.Limit(uint(options.Limit))
+	if options.ParentID != "" {
+		q = q.Where(goqu.C(COLUMN_PARENT_ID).Eq(options.ParentID))
 	}
 
 	if !options.CountOnly {
@@ -69,3 +104,63 @@ func (st *storeImplementation) EntityQuery(options EntityQueryOptions) *goqu.Sel
 
 	return q.Select()
 }
+
+// entityQueryCheck returns an error if the options filter or sort
+// by an encrypted attribute, as sealed values are random bytes
+// to the database
+func (st *storeImplementation) entityQueryCheck(options EntityQueryOptions) error {
+	keys := []string{}
+
+	for key := range options.Where {
+		keys = append(keys, key)
+	}
+
+	if options.SortByAttribute != "" {
+		keys = append(keys, options.SortByAttribute)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if st.attributeEncrypted(key) {
+			return errInvalidArgument("attribute " + key + " is encrypted and cannot be queried")
+		}
+	}
+
+	return nil
+}
+
+// entityAttributeValueQuery selects the value of the attribute of the entity
+// in the outer query. It is read from the attribute rows even if the type
+// has a projection, so the values sort as text, with or without it
+func (st *storeImplementation) entityAttributeValueQuery(attributeKey string) *goqu.SelectDataset {
+	return goqu.Dialect(st.dbDriverName).
+		From(st.attributeTableName).
+		Select(goqu.I(st.attributeTableName+"."+COLUMN_ATTRIBUTE_VALUE)).
+		Where(
+			goqu.I(st.attributeTableName+"."+COLUMN_ENTITY_ID).Eq(goqu.I(st.entityTableName+"."+COLUMN_ID)),
+			goqu.I(st.attributeTableName+"."+COLUMN_ATTRIBUTE_KEY).Eq(attributeKey),
+		)
+}
+
+// entityIDsWithAttributeQuery selects the IDs of the entities having the
+// attribute value, through the projection if it has the attribute and
+// the value fits the type of its column. The floats are compared as text,
+// through the attribute rows, as the same number has many texts (1.5, 1.50)
+func (st *storeImplementation) entityIDsWithAttributeQuery(projection *Projection, attributeKey string, attributeValue string) *goqu.SelectDataset {
+	if projection != nil {
+		if valueType, exists := projection.Columns[attributeKey]; exists && valueType != VALUE_TYPE_FLOAT {
+			if value := projectionValue(valueType, attributeValue); value != nil {
+				return goqu.Dialect(st.dbDriverName).
+					From(projection.TableName).
+					Select(goqu.C(COLUMN_ENTITY_ID)).
+					Where(goqu.C(attributeKey).Eq(value))
+			}
+		}
+	}
+
+	return goqu.Dialect(st.dbDriverName).
+		From(st.attributeTableName).
+		Select(goqu.C(COLUMN_ENTITY_ID)).
+		Where(goqu.C(COLUMN_ATTRIBUTE_KEY).Eq(attributeKey), goqu.C(COLUMN_ATTRIBUTE_VALUE).Eq(st.valueEscape(attributeValue)))
+}
@@ -0,0 +1,156 @@
+package entitystore
+
+import (
+	"github.com/doug-martin/goqu/v9"
+	"github.com/dromara/carbon/v2"
+)
+
+// EntityRestore moves an entity and all attributes back from the trash bin.
+// With HierarchyTrashCascade the descendants trashed together with the
+// entity are restored too. Returns ErrEntityNotFound if the entity is not in the trash bin
+func (st *storeImplementation) EntityRestore(entityID string) (isRestored bool, err error) {
+	defer wrapOpError(&err, "EntityRestore", entityID)
+
+	if entityID == "" {
+		return false, errInvalidArgument("entity id cannot be empty")
+	}
+
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		trashMap, err := tx.entityTrashFind(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		if trashMap == nil {
+			return errEntityNotFound(entityID)
+		}
+
+		if err := tx.entityRestore(trashMap); err != nil {
+			return err
+		}
+
+		if !tx.hierarchyEnabled || !tx.hierarchyTrashCascade {
+			return nil
+		}
+
+		deletedAt := carbon.Parse(trashMap[COLUMN_DELETED_AT], carbon.UTC).StdTime()
+		parentIDs := []string{entityID}
+
+		for len(parentIDs) > 0 {
+			children, err := tx.entityTrashList(goqu.C(COLUMN_PARENT_ID).In(parentIDs))
+
+			if err != nil {
+				return err
+			}
+
+			parentIDs = []string{}
+
+			for _, child := range children {
+				childDeletedAt := carbon.Parse(child[COLUMN_DELETED_AT], carbon.UTC).StdTime()
+
+				// children trashed on their own stay in the trash bin
+				if !childDeletedAt.Equal(deletedAt) {
+					continue
+				}
+
+				if err := tx.entityRestore(child); err != nil {
+					return err
+				}
+
+				parentIDs = append(parentIDs, child[COLUMN_ID])
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// entityRestore moves a single trashed entity and its attributes back
+func (st *storeImplementation) entityRestore(trashMap map[string]string) error {
+	entityID := trashMap[COLUMN_ID]
+	entity := st.NewEntityFromMap(trashMap)
+
+	attributeMaps, err := st.selectToMapStringFrom("entityRestore", st.attributeTrashTableName, goqu.C(COLUMN_ENTITY_ID).Eq(entityID))
+
+	if err != nil {
+		return err
+	}
+
+	sqlStrs := []string{}
+
+	sqlStr, _, _ := goqu.Dialect(st.dbDriverName).Insert(st.entityTableName).Rows(entity.ToMap()).ToSQL()
+	sqlStrs = append(sqlStrs, sqlStr)
+
+	for _, attributeMap := range attributeMaps {
+		attribute := st.NewAttributeFromMap(attributeMap)
+		sqlStr, _, _ := goqu.Dialect(st.dbDriverName).Insert(st.attributeTableName).Rows(attribute.ToMap()).ToSQL()
+		sqlStrs = append(sqlStrs, sqlStr)
+	}
+
+	sqlStr, _, _ = goqu.Dialect(st.dbDriverName).From(st.attributeTrashTableName).Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID)).Delete().ToSQL()
+	sqlStrs = append(sqlStrs, sqlStr)
+
+	sqlStr, _, _ = goqu.Dialect(st.dbDriverName).From(st.entityTrashTableName).Where(goqu.C(COLUMN_ID).Eq(entityID)).Delete().ToSQL()
+	sqlStrs = append(sqlStrs, sqlStr)
+
+	for _, sqlStr := range sqlStrs {
+		if _, err := st.exec("entityRestore", sqlStr); err != nil {
+			return err
+		}
+	}
+
+	if err := st.projectionSync(entityID); err != nil {
+		return err
+	}
+
+	return st.changeRecordEntity(CHANGE_ENTITY_RESTORE, &entity, nil)
+}
+
+// entityTrashFind finds an entity in the trash bin, as a map
+func (st *storeImplementation) entityTrashFind(entityID string) (map[string]string, error) {
+	list, err := st.entityTrashList(goqu.C(COLUMN_ID).Eq(entityID))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) < 1 {
+		return nil, nil
+	}
+
+	return list[0], nil
+}
+
+// entityTrashList lists the entities in the trash bin matching the expression, as maps
+func (st *storeImplementation) entityTrashList(where goqu.Expression) ([]map[string]string, error) {
+	if st.tenantScoped {
+		where = goqu.And(where, goqu.C(COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
+
+	return st.selectToMapStringFrom("entityTrashList", st.entityTrashTableName, where)
+}
+
+// entityTrashPurge removes a trashed entity and its attributes
+// from the trash bin for good
+func (st *storeImplementation) entityTrashPurge(entityID string) error {
+	sqlStr1, _, _ := goqu.Dialect(st.dbDriverName).From(st.attributeTrashTableName).Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID)).Delete().ToSQL()
+
+	if _, err := st.exec("entityTrashPurge", sqlStr1); err != nil {
+		return err
+	}
+
+	sqlStr2, _, _ := goqu.Dialect(st.dbDriverName).From(st.entityTrashTableName).Where(goqu.C(COLUMN_ID).Eq(entityID)).Delete().ToSQL()
+
+	if _, err := st.exec("entityTrashPurge", sqlStr2); err != nil {
+		return err
+	}
+
+	return nil
+}
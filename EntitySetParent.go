@@ -0,0 +1,61 @@
+package entitystore
+
+import (
+	"fmt"
+)
+
+// EntitySetParent moves an entity under a parent entity.
+// An empty parent ID makes the entity a root entity
+func (st *storeImplementation) EntitySetParent(entityID string, parentID string) (err error) {
+	defer wrapOpError(&err, "EntitySetParent", entityID)
+
+	if !st.hierarchyEnabled {
+		return errNotEnabled("hierarchy")
+	}
+
+	if entityID == "" {
+		return errInvalidArgument("entity id cannot be empty")
+	}
+
+	if entityID == parentID {
+		return errInvalidArgument("entity cannot be its own parent")
+	}
+
+	return st.inTransaction(func(tx *storeImplementation) error {
+		entity, err := tx.EntityFindByID(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		if entity == nil {
+			return errEntityNotFound(entityID)
+		}
+
+		if parentID != "" {
+			parent, err := tx.EntityFindByID(parentID)
+
+			if err != nil {
+				return err
+			}
+
+			if parent == nil {
+				return fmt.Errorf("%w: parent entity %s does not exist", ErrEntityNotFound, parentID)
+			}
+
+			ancestorIDs, err := tx.entityTreeIDs(parentID, true, 0)
+
+			if err != nil {
+				return err
+			}
+
+			if contains(ancestorIDs, entityID) {
+				return errInvalidArgument("entity cannot be moved under its own descendant")
+			}
+		}
+
+		entity.SetParentID(parentID)
+
+		return tx.EntityUpdate(*entity)
+	})
+}
@@ -1,145 +1,174 @@
 package entitystore
 
 import (
-	"errors"
-	"log"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
-// EntityTrash moves an entity and all attributes to the trash bin
-func (st *storeImplementation) EntityTrash(entityID string) (bool, error) {
+// EntityTrash moves an entity and all attributes to the trash bin.
+// With HierarchyTrashCascade the descendants are moved too.
+// Returns ErrEntityNotFound if the entity does not exist
+func (st *storeImplementation) EntityTrash(entityID string) (isTrashed bool, err error) {
+	defer wrapOpError(&err, "EntityTrash", entityID)
+
 	if entityID == "" {
-		return false, errors.New("entity ID cannot be empty")
+		return false, errInvalidArgument("entity id cannot be empty")
 	}
 
-	// Note the use of tx as the database handle once you are within a transaction
-	err := st.database.BeginTransaction()
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		descendantIDs := []string{}
+
+		if tx.hierarchyEnabled && tx.hierarchyTrashCascade {
+			var err error
+			descendantIDs, err = tx.entityTreeIDs(entityID, false, 0)
 
-	defer func() {
-		if r := recover(); r != nil {
-			err = st.database.RollbackTransaction()
 			if err != nil {
-				log.Println(err)
+				return err
 			}
 		}
-	}()
 
-	if err != nil {
-		return false, err
-	}
+		// the same deletion time marks the entities trashed together
+		deletedAt := time.Now()
 
-	ent, err := st.EntityFindByID(entityID)
+		isTrashed, err := tx.entityTrash(entityID, deletedAt)
 
-	if err != nil {
-		_ = st.database.RollbackTransaction()
-		return false, err
-	}
+		if err != nil {
+			return err
+		}
+
+		if !isTrashed {
+			return errEntityNotFound(entityID)
+		}
 
-	if ent == nil {
-		_ = st.database.RollbackTransaction()
+		for _, descendantID := range descendantIDs {
+			if _, err := tx.entityTrash(descendantID, deletedAt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
 		return false, err
 	}
 
-	entTrash := EntityTrash{
-		ID:        ent.ID(),
-		Type:      ent.Type(),
-		CreatedAt: ent.CreatedAt(),
-		UpdatedAt: ent.UpdatedAt(),
-		DeletedAt: time.Now(),
-	}
+	return true, nil
+}
 
-	q := goqu.Dialect(st.dbDriverName).Insert(st.entityTrashTableName)
-	q = q.Rows(entTrash)
-	sqlStr, _, _ := q.ToSQL()
+// entityTrash moves a single entity and its attributes to the trash bin
+func (st *storeImplementation) entityTrash(entityID string, deletedAt time.Time) (isTrashed bool, err error) {
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		ent, err := tx.EntityFindByID(entityID)
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
+		if err != nil {
+			return err
+		}
 
-	if _, err := st.database.Exec(sqlStr); err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if ent == nil {
+			return nil
 		}
-		_ = st.database.RollbackTransaction()
-		return false, err
-	}
 
-	attrs, err := st.EntityAttributeList(entityID)
+		if err := tx.entityHooksRun(hookBeforeEntityTrash, ent); err != nil {
+			return err
+		}
 
-	if err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		entTrash := EntityTrash{
+			ID:        ent.ID(),
+			Type:      ent.Type(),
+			Handle:    ent.Handle(),
+			ParentID:  ent.ParentID(),
+			TenantID:  ent.TenantID(),
+			Version:   ent.Version(),
+			CreatedAt: ent.CreatedAt(),
+			UpdatedAt: ent.UpdatedAt(),
+			DeletedAt: deletedAt,
+			DeletedBy: tx.actor,
 		}
-		_ = st.database.RollbackTransaction()
-		return false, err
-	}
 
-	for _, attr := range attrs {
-		attrTrash := AttributeTrash{
-			ID:             attr.ID(),
-			EntityID:       attr.EntityID(),
-			AttributeKey:   attr.AttributeKey(),
-			AttributeValue: attr.AttributeValue(),
-			CreatedAt:      attr.CreatedAt(),
-			UpdatedAt:      attr.UpdatedAt(),
-			DeletedAt:      time.Now(),
+		q := goqu.Dialect(tx.dbDriverName).Insert(tx.entityTrashTableName)
+		q = q.Rows(entTrash)
+		sqlStr, _, _ := q.ToSQL()
+
+		if _, err := tx.exec("entityTrash", sqlStr); err != nil {
+			return err
 		}
 
-		q := goqu.Dialect(st.dbDriverName).Insert(st.attributeTrashTableName)
-		q = q.Rows(attrTrash)
-		sqlStrAttr, _, _ := q.ToSQL()
+		attrs, err := tx.EntityAttributeList(entityID)
 
-		if st.GetDebug() {
-			log.Println(sqlStrAttr)
+		if err != nil {
+			return err
 		}
 
-		if _, err := st.database.Exec(sqlStrAttr); err != nil {
-			if st.GetDebug() {
-				log.Println(err)
+		for _, attr := range attrs {
+			attrTrash := AttributeTrash{
+				ID:             attr.ID(),
+				EntityID:       attr.EntityID(),
+				AttributeKey:   attr.AttributeKey(),
+				AttributeValue: attr.AttributeValue(),
+				CreatedAt:      attr.CreatedAt(),
+				UpdatedAt:      attr.UpdatedAt(),
+				DeletedAt:      deletedAt,
+				DeletedBy:      tx.actor,
+			}
+
+			q := goqu.Dialect(tx.dbDriverName).Insert(tx.attributeTrashTableName)
+			q = q.Rows(attrTrash)
+			sqlStrAttr, _, _ := q.ToSQL()
+
+			if _, err := tx.exec("entityTrash", sqlStrAttr); err != nil {
+				return err
 			}
-			_ = st.database.RollbackTransaction()
-			return false, err
 		}
-	}
 
-	q1 := goqu.Dialect(st.dbDriverName).From(st.attributeTableName).Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID)).Delete()
-	sqlStr1, _, _ := q1.ToSQL()
+		q1 := goqu.Dialect(tx.dbDriverName).From(tx.attributeTableName).Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID)).Delete()
+		sqlStr1, _, _ := q1.ToSQL()
 
-	if _, err := st.database.Exec(sqlStr1); err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if _, err := tx.exec("entityTrash", sqlStr1); err != nil {
+			return err
 		}
-		_ = st.database.RollbackTransaction()
-		return false, err
-	}
 
-	q2 := goqu.Dialect(st.dbDriverName).From(st.entityTableName).Where(goqu.C(COLUMN_ID).Eq(entityID)).Delete()
-	sqlStr2, _, _ := q2.ToSQL()
+		q2 := goqu.Dialect(tx.dbDriverName).From(tx.entityTableName).Where(goqu.C(COLUMN_ID).Eq(entityID)).Delete()
+		sqlStr2, _, _ := q2.ToSQL()
 
-	if _, err := st.database.Exec(sqlStr2); err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		if _, err := tx.exec("entityTrash", sqlStr2); err != nil {
+			return err
 		}
-		_ = st.database.RollbackTransaction()
-		return false, err
-	}
 
-	err = st.database.CommitTransaction()
+		attributeKeys := []string{}
 
-	if err == nil {
-		return true, nil
-	}
+		for _, attr := range attrs {
+			attributeKeys = append(attributeKeys, attr.AttributeKey())
+			tx.cacheInvalidate(cacheKeyAttribute(entityID, attr.AttributeKey()))
+		}
 
-	if st.GetDebug() {
-		log.Println(err)
-	}
+		tx.cacheInvalidate(cacheKeyEntity(entityID))
 
-	err = st.database.RollbackTransaction()
-	if err != nil && st.GetDebug() {
-		log.Println(err)
+		if err := tx.entityLinksDelete(entityID); err != nil {
+			return err
+		}
+
+		if err := tx.projectionSync(entityID); err != nil {
+			return err
+		}
+
+		if err := tx.changeRecordEntity(CHANGE_ENTITY_TRASH, ent, attributeKeys); err != nil {
+			return err
+		}
+
+		if err := tx.entityHooksRun(hookAfterEntityTrash, ent); err != nil {
+			return err
+		}
+
+		isTrashed = true
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
 	}
 
-	return false, err
+	return isTrashed, nil
 }
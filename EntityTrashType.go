@@ -9,6 +9,9 @@ type EntityTrash struct {
 	ID        string    `db:"id"`
 	Type      string    `db:"entity_type"`
 	Handle    string    `db:"entity_handle"`
+	ParentID  string    `db:"parent_id" goqu:"omitempty"`
+	TenantID  string    `db:"tenant_id" goqu:"omitempty"`
+	Version   int64     `db:"version"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
 	DeletedAt time.Time `db:"deleted_at"`
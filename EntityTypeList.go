@@ -0,0 +1,70 @@
+package entitystore
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/dromara/carbon/v2"
+)
+
+// EntityTypeInfo describes an entity type in use
+type EntityTypeInfo struct {
+	EntityType     string
+	Count          int64
+	FirstCreatedAt time.Time
+	LastCreatedAt  time.Time
+}
+
+// EntityTypeList lists the entity types in use, sorted by type,
+// with the number of entities and the creation time of the oldest
+// and of the newest. The trashed entities are not counted
+func (st *storeImplementation) EntityTypeList() (types []EntityTypeInfo, err error) {
+	defer wrapOpError(&err, "EntityTypeList", "")
+
+	q := goqu.Dialect(st.dbDriverName).
+		From(st.entityTableName).
+		GroupBy(goqu.C(COLUMN_ENTITY_TYPE)).
+		Select(
+			goqu.C(COLUMN_ENTITY_TYPE),
+			goqu.COUNT(goqu.Star()).As("entity_count"),
+			goqu.MIN(goqu.C(COLUMN_CREATED_AT)).As("first_created_at"),
+			goqu.MAX(goqu.C(COLUMN_CREATED_AT)).As("last_created_at"),
+		).
+		Order(goqu.C(COLUMN_ENTITY_TYPE).Asc())
+
+	if st.tenantScoped {
+		q = q.Where(goqu.C(COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
+
+	sqlStr, _, err := q.ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := st.selectToMapString("EntityTypeList", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	types = []EntityTypeInfo{}
+
+	for _, row := range rows {
+		count, err := strconv.ParseInt(row["entity_count"], 10, 64)
+
+		if err != nil {
+			return nil, err
+		}
+
+		types = append(types, EntityTypeInfo{
+			EntityType:     row[COLUMN_ENTITY_TYPE],
+			Count:          count,
+			FirstCreatedAt: carbon.Parse(row["first_created_at"], carbon.UTC).StdTime(),
+			LastCreatedAt:  carbon.Parse(row["last_created_at"], carbon.UTC).StdTime(),
+		})
+	}
+
+	return types, nil
+}
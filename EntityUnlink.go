@@ -0,0 +1,79 @@
+package entitystore
+
+import (
+	"github.com/doug-martin/goqu/v9"
+)
+
+// EntityUnlink removes the link with the specified relation between two entities.
+// Returns false if the entities were not linked
+func (st *storeImplementation) EntityUnlink(fromEntityID string, toEntityID string, relation string) (isUnlinked bool, err error) {
+	defer wrapOpError(&err, "EntityUnlink", fromEntityID)
+
+	if st.linkTableName == "" {
+		return false, errNotEnabled("entity links")
+	}
+
+	if fromEntityID == "" || toEntityID == "" {
+		return false, errInvalidArgument("entity ids cannot be empty")
+	}
+
+	if relation == "" {
+		return false, errInvalidArgument("relation cannot be empty")
+	}
+
+	visible, err := st.tenantEntityVisible(fromEntityID)
+
+	if err != nil {
+		return false, err
+	}
+
+	if !visible {
+		return false, nil
+	}
+
+	affected, err := st.linksDelete(goqu.Ex{
+		COLUMN_FROM_ID:  fromEntityID,
+		COLUMN_TO_ID:    toEntityID,
+		COLUMN_RELATION: relation,
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// entityLinksDelete removes all the links from and to an entity.
+// Does nothing if the entity links are not enabled
+func (st *storeImplementation) entityLinksDelete(entityID string) error {
+	if st.linkTableName == "" {
+		return nil
+	}
+
+	_, err := st.linksDelete(goqu.Or(
+		goqu.C(COLUMN_FROM_ID).Eq(entityID),
+		goqu.C(COLUMN_TO_ID).Eq(entityID),
+	))
+
+	return err
+}
+
+// linksDelete removes the links matching the expression
+func (st *storeImplementation) linksDelete(where goqu.Expression) (int64, error) {
+	q := goqu.Dialect(st.dbDriverName).From(st.linkTableName).Where(where).Delete()
+
+	sqlStr, _, errSql := q.ToSQL()
+
+	if errSql != nil {
+		return 0, errSql
+	}
+
+	result, err := st.exec("linksDelete", sqlStr)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
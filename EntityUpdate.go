@@ -1,40 +1,73 @@
 package entitystore
 
 import (
-	"log"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
 )
 
-// EntityUpdate updates an entity
-func (st *storeImplementation) EntityUpdate(ent Entity) error {
+// EntityUpdate updates an entity.
+// Returns ErrEntityNotFound if the entity does not exist
+func (st *storeImplementation) EntityUpdate(ent Entity) (err error) {
+	defer wrapOpError(&err, "EntityUpdate", ent.ID())
+
 	ent.SetUpdatedAt(time.Now())
 
-	q := goqu.Dialect(st.dbDriverName).
-		Update(st.GetEntityTableName()).
-		Where(goqu.C("id").Eq(ent.ID())).
-		Set(ent.ToMap())
+	if st.tenantScoped {
+		ent.SetTenantID(st.tenantID)
+	}
 
-	sqlStr, _, errSql := q.ToSQL()
+	return st.inTransaction(func(tx *storeImplementation) error {
+		if err := tx.entityHooksRun(hookBeforeEntityUpdate, &ent); err != nil {
+			return err
+		}
 
-	if errSql != nil {
-		return errSql
-	}
+		row := ent.ToMap()
+		row[COLUMN_VERSION] = goqu.L("? + 1", goqu.C(COLUMN_VERSION))
 
-	if st.GetDebug() {
-		log.Println(sqlStr)
-	}
+		q := goqu.Dialect(tx.dbDriverName).
+			Update(tx.GetEntityTableName()).
+			Where(goqu.C("id").Eq(ent.ID())).
+			Set(row)
 
-	_, err := st.database.Exec(sqlStr)
+		if tx.tenantScoped {
+			q = q.Where(goqu.C(COLUMN_TENANT_ID).Eq(tx.tenantID))
+		}
 
-	if err != nil {
-		if st.GetDebug() {
-			log.Println(err)
+		sqlStr, _, errSql := q.ToSQL()
+
+		if errSql != nil {
+			return errSql
 		}
 
-		return err
-	}
+		result, err := tx.exec("EntityUpdate", sqlStr)
+
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if affected < 1 {
+			return errEntityNotFound(ent.ID())
+		}
+
+		tx.cacheInvalidate(cacheKeyEntity(ent.ID()))
+
+		if err := tx.projectionSync(ent.ID()); err != nil {
+			return err
+		}
+
+		err = tx.changeRecordEntity(CHANGE_ENTITY_UPDATE, &ent, nil)
+
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return tx.entityHooksRun(hookAfterEntityUpdate, &ent)
+	})
 }
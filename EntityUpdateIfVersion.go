@@ -0,0 +1,85 @@
+package entitystore
+
+import (
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// EntityUpdateIfVersion updates an entity only if its version in the store
+// still equals the expected version. Returns ErrVersionConflict otherwise,
+// or ErrEntityNotFound if the entity does not exist
+func (st *storeImplementation) EntityUpdateIfVersion(ent Entity, expectedVersion int64) (err error) {
+	defer wrapOpError(&err, "EntityUpdateIfVersion", ent.ID())
+
+	ent.SetUpdatedAt(time.Now())
+
+	if st.tenantScoped {
+		ent.SetTenantID(st.tenantID)
+	}
+
+	return st.inTransaction(func(tx *storeImplementation) error {
+		if err := tx.entityHooksRun(hookBeforeEntityUpdate, &ent); err != nil {
+			return err
+		}
+
+		row := ent.ToMap()
+		row[COLUMN_VERSION] = goqu.L("? + 1", goqu.C(COLUMN_VERSION))
+
+		q := goqu.Dialect(tx.dbDriverName).
+			Update(tx.GetEntityTableName()).
+			Where(goqu.C(COLUMN_ID).Eq(ent.ID())).
+			Where(goqu.C(COLUMN_VERSION).Eq(expectedVersion)).
+			Set(row)
+
+		if tx.tenantScoped {
+			q = q.Where(goqu.C(COLUMN_TENANT_ID).Eq(tx.tenantID))
+		}
+
+		sqlStr, _, errSql := q.ToSQL()
+
+		if errSql != nil {
+			return errSql
+		}
+
+		result, err := tx.exec("EntityUpdateIfVersion", sqlStr)
+
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if affected < 1 {
+			existing, err := tx.EntityFindByID(ent.ID())
+
+			if err != nil {
+				return err
+			}
+
+			if existing == nil {
+				return errEntityNotFound(ent.ID())
+			}
+
+			return ErrVersionConflict
+		}
+
+		tx.cacheInvalidate(cacheKeyEntity(ent.ID()))
+
+		if err := tx.projectionSync(ent.ID()); err != nil {
+			return err
+		}
+
+		err = tx.changeRecordEntity(CHANGE_ENTITY_UPDATE, &ent, nil)
+
+		if err != nil {
+			return err
+		}
+
+		return tx.entityHooksRun(hookAfterEntityUpdate, &ent)
+	})
+}
@@ -0,0 +1,80 @@
+package entitystore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEntityUpdateIfVersion(t *testing.T) {
+	db := InitDB("test_entity_update_if_version.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.EntityCreateWithType("post")
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	if entity.Version() != 1 {
+		t.Fatal("Version must be 1, found: ", entity.Version())
+	}
+
+	err = entity.SetString("title", "Hello world")
+
+	if err != nil {
+		t.Fatal("Entity title could not be set: " + err.Error())
+	}
+
+	stale, err := store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if stale.Version() != 2 {
+		t.Fatal("Version must be 2 after attribute set, found: ", stale.Version())
+	}
+
+	entity.SetHandle("hello-world")
+
+	err = entity.Save()
+
+	if err != nil {
+		t.Fatal("Entity could not be saved: " + err.Error())
+	}
+
+	if entity.Version() != 3 {
+		t.Fatal("Version must be 3 after save, found: ", entity.Version())
+	}
+
+	stale.SetHandle("stale-handle")
+
+	// the conflict is checked on the connection of the transaction
+	db.SetMaxOpenConns(1)
+
+	err = stale.Save()
+
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatal("Must be ErrVersionConflict, found: ", err)
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if found.Handle() != "hello-world" {
+		t.Fatal("Handle must be hello-world, found: ", found.Handle())
+	}
+}
@@ -0,0 +1,153 @@
+package entitystore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/dromara/carbon/v2"
+)
+
+// exportBatchSize the number of entities read per query when exporting
+const exportBatchSize = 100
+
+// ExportOptions selects the entities to export
+type ExportOptions struct {
+	// EntityType exports only the entities of the type, all if empty
+	EntityType string
+	// IncludeTrash exports the trashed entities too, after the others
+	IncludeTrash bool
+}
+
+// ExportRecord is a single entity with all its attributes,
+// written as one JSON document per line by Export
+type ExportRecord struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Handle     string            `json:"handle,omitempty"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	TenantID   string            `json:"tenant_id,omitempty"`
+	Version    int64             `json:"version,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	DeletedAt  *time.Time        `json:"deleted_at,omitempty"` // set for the trashed entities
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Export writes the entities with their attributes to w as JSON Lines,
+// one ExportRecord per line. The attribute values are written decoded,
+// i.e. decrypted and decompressed
+func (st *storeImplementation) Export(ctx context.Context, w io.Writer, options ExportOptions) (err error) {
+	defer wrapOpError(&err, "Export", "")
+
+	if w == nil {
+		return errInvalidArgument("writer cannot be nil")
+	}
+
+	encoder := json.NewEncoder(w)
+
+	for offset := uint64(0); ; offset += exportBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entities, err := st.EntityList(EntityQueryOptions{
+			EntityType: options.EntityType,
+			Offset:     offset,
+			Limit:      exportBatchSize,
+			SortBy:     COLUMN_ID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, entity := range entities {
+			record, err := st.exportRecord(entity, false)
+
+			if err != nil {
+				return err
+			}
+
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+
+		if len(entities) < exportBatchSize {
+			break
+		}
+	}
+
+	if !options.IncludeTrash {
+		return nil
+	}
+
+	where := goqu.Ex{}
+
+	if options.EntityType != "" {
+		where[COLUMN_ENTITY_TYPE] = options.EntityType
+	}
+
+	trashMaps, err := st.entityTrashList(where)
+
+	if err != nil {
+		return err
+	}
+
+	for _, trashMap := range trashMaps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := st.exportRecord(st.NewEntityFromMap(trashMap), true)
+
+		if err != nil {
+			return err
+		}
+
+		deletedAt := carbon.Parse(trashMap[COLUMN_DELETED_AT], carbon.UTC).StdTime()
+		record.DeletedAt = &deletedAt
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportRecord reads the attributes of the entity into an export record
+func (st *storeImplementation) exportRecord(entity Entity, isTrashed bool) (ExportRecord, error) {
+	record := ExportRecord{
+		ID:         entity.ID(),
+		Type:       entity.Type(),
+		Handle:     entity.Handle(),
+		ParentID:   entity.ParentID(),
+		TenantID:   entity.TenantID(),
+		Version:    entity.Version(),
+		CreatedAt:  entity.CreatedAt(),
+		UpdatedAt:  entity.UpdatedAt(),
+		Attributes: map[string]string{},
+	}
+
+	attributes, err := st.entityAttributeListIncludingTrash(entity.ID(), isTrashed)
+
+	if err != nil {
+		return record, err
+	}
+
+	for _, attribute := range attributes {
+		value, err := st.attributeValueDecode(attribute.AttributeKey(), attribute.AttributeValue())
+
+		if err != nil {
+			return record, err
+		}
+
+		record.Attributes[attribute.AttributeKey()] = value
+	}
+
+	return record, nil
+}
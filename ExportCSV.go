@@ -0,0 +1,189 @@
+package entitystore
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// the columns of the CSV files, before the attribute columns
+const CSV_COLUMN_ID = "id"
+const CSV_COLUMN_HANDLE = "handle"
+const CSV_COLUMN_CREATED_AT = "created_at"
+const CSV_COLUMN_UPDATED_AT = "updated_at"
+
+// CSVOptions controls the columns of a CSV export
+type CSVOptions struct {
+	// Columns the attribute keys exported as columns, in order.
+	// If empty, the attribute keys of all the entities of the type, sorted
+	Columns []string
+	// Comma the field delimiter, a comma by default
+	Comma rune
+}
+
+// ExportCSV writes the entities of a type to w as CSV, one row per entity
+// with its id, handle and timestamps, followed by a column per attribute.
+// The entities missing an attribute have an empty cell. The attribute keys
+// cannot be the names of the fixed columns, and the cells a spreadsheet
+// could take for a formula are escaped, see csvCellEscape
+func (st *storeImplementation) ExportCSV(ctx context.Context, w io.Writer, entityType string, options CSVOptions) (err error) {
+	defer wrapOpError(&err, "ExportCSV", "")
+
+	if w == nil {
+		return errInvalidArgument("writer cannot be nil")
+	}
+
+	if entityType == "" {
+		return errInvalidArgument("entity type cannot be empty")
+	}
+
+	columns := options.Columns
+
+	if len(columns) < 1 {
+		columns, err = st.csvAttributeKeys(entityType)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer := csv.NewWriter(w)
+
+	if options.Comma != 0 {
+		writer.Comma = options.Comma
+	}
+
+	header := []string{CSV_COLUMN_ID, CSV_COLUMN_HANDLE, CSV_COLUMN_CREATED_AT, CSV_COLUMN_UPDATED_AT}
+
+	for _, column := range columns {
+		if contains(header, column) {
+			return errInvalidArgument("attribute " + column + " has the name of a fixed column, list the columns to export without it")
+		}
+	}
+
+	header = append(header, columns...)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for offset := uint64(0); ; offset += exportBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entities, err := st.EntityList(EntityQueryOptions{
+			EntityType: entityType,
+			Offset:     offset,
+			Limit:      exportBatchSize,
+			SortBy:     COLUMN_ID,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, entity := range entities {
+			record, err := st.exportRecord(entity, false)
+
+			if err != nil {
+				return err
+			}
+
+			row := []string{
+				csvCellEscape(record.ID),
+				csvCellEscape(record.Handle),
+				record.CreatedAt.UTC().Format(time.DateTime),
+				record.UpdatedAt.UTC().Format(time.DateTime),
+			}
+
+			for _, column := range columns {
+				row = append(row, csvCellEscape(record.Attributes[column]))
+			}
+
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+
+		if len(entities) < exportBatchSize {
+			break
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// csvNumberPattern matches the plain numbers, not escaped by csvCellEscape
+var csvNumberPattern = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?$`)
+
+// csvCellEscape prefixes with a quote the cells starting with a character
+// a spreadsheet could take for the start of a formula, except the plain
+// numbers, and the cells starting with a quote, so ImportCSV can remove
+// the prefix
+func csvCellEscape(value string) string {
+	if value == "" || csvNumberPattern.MatchString(value) {
+		return value
+	}
+
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r', '\'':
+		return "'" + value
+	}
+
+	return value
+}
+
+// csvCellUnescape removes the prefix added by csvCellEscape. A quote
+// typed by hand, which csvCellEscape would not have added, is kept
+func csvCellUnescape(value string) string {
+	if strings.HasPrefix(value, "'") && csvCellEscape(value[1:]) == value {
+		return value[1:]
+	}
+
+	return value
+}
+
+// csvAttributeKeys lists the distinct attribute keys of the entities of the type, sorted
+func (st *storeImplementation) csvAttributeKeys(entityType string) ([]string, error) {
+	entityIDs := goqu.Dialect(st.dbDriverName).
+		From(st.entityTableName).
+		Select(goqu.C(COLUMN_ID)).
+		Where(goqu.C(COLUMN_ENTITY_TYPE).Eq(entityType))
+
+	if st.tenantScoped {
+		entityIDs = entityIDs.Where(goqu.C(COLUMN_TENANT_ID).Eq(st.tenantID))
+	}
+
+	sqlStr, _, errSql := goqu.Dialect(st.dbDriverName).
+		From(st.attributeTableName).
+		SelectDistinct(goqu.C(COLUMN_ATTRIBUTE_KEY)).
+		Where(goqu.C(COLUMN_ENTITY_ID).In(entityIDs)).
+		Order(goqu.C(COLUMN_ATTRIBUTE_KEY).Asc()).
+		ToSQL()
+
+	if errSql != nil {
+		return nil, errSql
+	}
+
+	keyMaps, err := st.selectToMapString("ExportCSV", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+
+	for _, keyMap := range keyMaps {
+		keys = append(keys, keyMap[COLUMN_ATTRIBUTE_KEY])
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,243 @@
+package entitystore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExportImportCSV(t *testing.T) {
+	db := InitDB("test_export_csv.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	apple := store.NewEntity(NewEntityOptions{ID: "apple", Type: "product", Handle: "apple"})
+
+	if err := store.EntityCreate(&apple); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := store.AttributesSet("apple", map[string]string{"name": "Apple", "price": "1.20"}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	pear := store.NewEntity(NewEntityOptions{ID: "pear", Type: "product", Handle: "pear"})
+
+	if err := store.EntityCreate(&pear); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := store.AttributesSet("pear", map[string]string{"name": "Pear, green", "stock": "5"}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	csvAll := bytes.Buffer{}
+
+	if err := store.ExportCSV(context.Background(), &csvAll, "product", CSVOptions{}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(csvAll.String()), "\n")
+
+	if len(lines) != 3 {
+		t.Fatal("Export must have a header and 2 rows, found:", len(lines))
+	}
+
+	if lines[0] != "id,handle,created_at,updated_at,name,price,stock" {
+		t.Fatal("Header must have the union of the attribute keys, found:", lines[0])
+	}
+
+	if !strings.HasPrefix(lines[2], "pear,pear,") || !strings.HasSuffix(lines[2], `,"Pear, green",,5`) {
+		t.Fatal("Row must have the attribute values, found:", lines[2])
+	}
+
+	csvColumns := bytes.Buffer{}
+
+	if err := store.ExportCSV(context.Background(), &csvColumns, "product", CSVOptions{Columns: []string{"price"}, Comma: ';'}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if header := strings.Split(csvColumns.String(), "\n")[0]; header != "id;handle;created_at;updated_at;price" {
+		t.Fatal("Header must have the explicit columns, found:", header)
+	}
+
+	input := "sku,title,cost\n" +
+		"apple,Apple Red,1.50\n" +
+		"plum,Plum,0.80\n" +
+		",Nameless,1\n" +
+		"kiwi,Kiwi\n"
+
+	_, err = store.ImportCSV(context.Background(), strings.NewReader(input), "product", CSVMapping{
+		Attributes: map[string]string{"title": "name", "cost": "price"},
+		MatchBy:    CSV_MATCH_HANDLE,
+	})
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Matching by handle without a handle column must fail:", err)
+	}
+
+	input = strings.Replace(input, "sku,", "handle,", 1)
+
+	result, err := store.ImportCSV(context.Background(), strings.NewReader(input), "product", CSVMapping{
+		Attributes: map[string]string{"title": "name", "cost": "price"},
+		MatchBy:    CSV_MATCH_HANDLE,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Created != 1 || result.Updated != 1 {
+		t.Fatal("Import must create 1 and update 1 entity, found:", result.Created, result.Updated)
+	}
+
+	if len(result.Errors) != 2 || result.Errors[0].Row != 4 || result.Errors[1].Row != 5 {
+		t.Fatal("Import must report the rows 4 and 5, found:", result.Errors)
+	}
+
+	if !errors.Is(result.Errors[0], ErrInvalidArgument) {
+		t.Fatal("Row error must be ErrInvalidArgument:", result.Errors[0])
+	}
+
+	name, err := apple.GetString("name", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if name != "Apple Red" {
+		t.Fatal("Name must be updated to Apple Red, found:", name)
+	}
+
+	plum, err := store.EntityFindByHandle("product", "plum")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if plum == nil {
+		t.Fatal("Plum must be created")
+	}
+
+	// the exported file updates the entities by id
+	result, err = store.ImportCSV(context.Background(), bytes.NewReader(csvAll.Bytes()), "product", CSVMapping{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Updated != 2 || len(result.Errors) != 0 {
+		t.Fatal("Import of the export must update 2 entities, found:", result.Updated, result.Errors)
+	}
+
+	name, err = apple.GetString("name", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if name != "Apple" {
+		t.Fatal("Name must be restored to Apple, found:", name)
+	}
+}
+
+func TestExportCSVEscaping(t *testing.T) {
+	store, err := NewStore(NewStoreOptions{
+		DB:                 InitDB("test_export_csv_escaping.db"),
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	values := map[string]string{
+		"formula": "=HYPERLINK(\"http://example.com\")",
+		"plus":    "+1",
+		"minus":   "-1",
+		"sum":     "-1+2",
+		"at":      "@SUM(A1)",
+		"quoted":  "'quoted",
+		"plain":   "plain",
+	}
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("cell", values)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	output := bytes.Buffer{}
+
+	if err := store.ExportCSV(context.Background(), &output, "cell", CSVOptions{}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+
+	if !strings.HasSuffix(lines[1], `,'@SUM(A1),"'=HYPERLINK(""http://example.com"")",-1,plain,+1,''quoted,'-1+2`) {
+		t.Fatal("Cells must be escaped, found:", lines[1])
+	}
+
+	for key := range values {
+		if err := store.AttributeSetString(entity.ID(), key, "changed"); err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	result, err := store.ImportCSV(context.Background(), bytes.NewReader(output.Bytes()), "cell", CSVMapping{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Updated != 1 || len(result.Errors) != 0 {
+		t.Fatal("Import of the export must update the entity, found:", result.Updated, result.Errors)
+	}
+
+	for key, value := range values {
+		found, err := entity.GetString(key, "")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if found != value {
+			t.Fatal("Value must be imported unescaped:", value, "found:", found)
+		}
+	}
+
+	// the quotes typed by hand are kept
+	typed := "id,quoted\n" + entity.ID() + ",'typed\n"
+
+	if _, err := store.ImportCSV(context.Background(), strings.NewReader(typed), "cell", CSVMapping{}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if quoted, _ := entity.GetString("quoted", ""); quoted != "'typed" {
+		t.Fatal("A quote typed by hand must be kept, found:", quoted)
+	}
+
+	if err := entity.SetString("id", "attribute"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	err = store.ExportCSV(context.Background(), &output, "cell", CSVOptions{})
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Attribute named as a fixed column must fail:", err)
+	}
+}
@@ -0,0 +1,256 @@
+package entitystore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	source, err := NewStore(NewStoreOptions{
+		DB:                 InitDB("test_export_source.db"),
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	post, err := source.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Hello", "body": "World"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	draft, err := source.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Draft"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	_, err = source.EntityCreateWithTypeAndAttributes("page", map[string]string{"title": "About"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, err = source.EntityTrash(draft.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	posts := bytes.Buffer{}
+	err = source.Export(context.Background(), &posts, ExportOptions{EntityType: "post"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if lines := strings.Count(posts.String(), "\n"); lines != 1 {
+		t.Fatal("Export of the posts must have 1 line, found:", lines)
+	}
+
+	all := bytes.Buffer{}
+	err = source.Export(context.Background(), &all, ExportOptions{IncludeTrash: true})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if lines := strings.Count(all.String(), "\n"); lines != 3 {
+		t.Fatal("Export including the trash must have 3 lines, found:", lines)
+	}
+
+	target, err := NewStore(NewStoreOptions{
+		DB:                 InitDB("test_export_target.db"),
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	result, err := target.Import(context.Background(), bytes.NewReader(all.Bytes()), ImportOptions{PreserveIDs: true, BatchSize: 2})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Created != 3 {
+		t.Fatal("Import must create 3 entities, found:", result.Created)
+	}
+
+	imported, err := target.EntityFindByID(post.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if imported == nil {
+		t.Fatal("Imported post must keep its ID")
+	}
+
+	body, err := imported.GetString("body", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if body != "World" {
+		t.Fatal("Imported body must be World, found:", body)
+	}
+
+	isRestored, err := target.EntityRestore(draft.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !isRestored {
+		t.Fatal("Imported draft must be in the trash bin")
+	}
+
+	_, err = target.Import(context.Background(), bytes.NewReader(all.Bytes()), ImportOptions{PreserveIDs: true})
+
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Must be ErrDuplicate:", err)
+	}
+
+	result, err = target.Import(context.Background(), bytes.NewReader(all.Bytes()), ImportOptions{PreserveIDs: true, OnConflict: IMPORT_CONFLICT_SKIP})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Skipped != 3 {
+		t.Fatal("Import must skip 3 entities, found:", result.Skipped)
+	}
+
+	err = target.AttributeSetString(post.ID(), "extra", "value")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	result, err = target.Import(context.Background(), bytes.NewReader(all.Bytes()), ImportOptions{PreserveIDs: true, OnConflict: IMPORT_CONFLICT_OVERWRITE})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Updated != 3 {
+		t.Fatal("Import must update 3 entities, found:", result.Updated)
+	}
+
+	extra, err := target.AttributeFind(post.ID(), "extra")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if extra != nil {
+		t.Fatal("Overwrite must delete the attributes missing from the record")
+	}
+
+	restored, err := target.EntityFindByID(draft.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if restored != nil {
+		t.Fatal("Overwrite must move the draft back to the trash bin")
+	}
+
+	result, err = target.Import(context.Background(), bytes.NewReader(posts.Bytes()), ImportOptions{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Created != 1 {
+		t.Fatal("Import without preserving the IDs must create a new entity, found:", result.Created)
+	}
+
+	count, err := target.EntityCount(EntityQueryOptions{EntityType: "post"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 2 {
+		t.Fatal("Post count must be 2, found:", count)
+	}
+}
+
+func TestImportChildBeforeParent(t *testing.T) {
+	db := InitDB("test_import_child_before_parent.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		HierarchyEnabled:   true,
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	records := `{"id":"CHILD","type":"page","handle":"child","parent_id":"PARENT","attributes":{"title":"Child"}}
+{"id":"TRASHED","type":"page","handle":"trashed","parent_id":"PARENT","attributes":{},"deleted_at":"2024-01-01T00:00:00Z"}
+{"id":"PARENT","type":"page","handle":"parent","attributes":{"title":"Parent"}}
+`
+
+	result, err := store.Import(context.Background(), strings.NewReader(records), ImportOptions{BatchSize: 1})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Created != 3 {
+		t.Fatal("Import must create 3 entities, found:", result.Created)
+	}
+
+	parent, err := store.EntityFindByHandle("page", "parent")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	child, err := store.EntityFindByHandle("page", "child")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if parent == nil || child == nil || child.ParentID() != parent.ID() {
+		t.Fatal("Child imported before its parent must be linked to it")
+	}
+
+	var trashedParentID string
+	err = db.QueryRow(`SELECT parent_id FROM ` + store.GetEntityTrashTableName() + ` WHERE entity_handle = 'trashed'`).Scan(&trashedParentID)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if trashedParentID != parent.ID() {
+		t.Fatal("Trashed child must be linked to its parent, found:", trashedParentID)
+	}
+
+	orphan := `{"id":"ORPHAN","type":"page","handle":"orphan","parent_id":"MISSING","attributes":{}}
+`
+
+	_, err = store.Import(context.Background(), strings.NewReader(orphan), ImportOptions{})
+
+	if !errors.Is(err, ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound:", err)
+	}
+}
@@ -0,0 +1,60 @@
+package entitystore
+
+import (
+	"github.com/doug-martin/goqu/v9"
+)
+
+// ForTenant returns a view of the store scoped to a single tenant.
+// Entities created through the view belong to the tenant, while the
+// entities of other tenants (and their attributes, history and links)
+// are invisible to it. Requires TenancyEnabled
+func (st *storeImplementation) ForTenant(tenantID string) StoreInterface {
+	view := *st
+	view.tenantScoped = true
+	view.tenantID = tenantID
+	return &view
+}
+
+// tenantEntityIDsQuery selects the IDs of the entities of the tenant
+func (st *storeImplementation) tenantEntityIDsQuery() *goqu.SelectDataset {
+	return goqu.Dialect(st.dbDriverName).
+		From(st.entityTableName).
+		Select(goqu.C(COLUMN_ID)).
+		Where(goqu.C(COLUMN_TENANT_ID).Eq(st.tenantID))
+}
+
+// tenantEntityCheck returns an error if the store is scoped to a tenant
+// and the entity does not exist, or belongs to another tenant
+func (st *storeImplementation) tenantEntityCheck(entityID string) error {
+	if !st.tenantScoped {
+		return nil
+	}
+
+	entity, err := st.EntityFindByID(entityID)
+
+	if err != nil {
+		return err
+	}
+
+	if entity == nil {
+		return errEntityNotFound(entityID)
+	}
+
+	return nil
+}
+
+// tenantEntityVisible returns false if the store is scoped to a tenant and
+// the entity, including the trashed ones, belongs to another tenant
+func (st *storeImplementation) tenantEntityVisible(entityID string) (bool, error) {
+	if !st.tenantScoped {
+		return true, nil
+	}
+
+	entity, _, err := st.entityFindIncludingTrash(entityID)
+
+	if err != nil {
+		return false, err
+	}
+
+	return entity != nil, nil
+}
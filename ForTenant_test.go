@@ -0,0 +1,165 @@
+package entitystore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestForTenant(t *testing.T) {
+	db := InitDB("test_entity_for_tenant.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		TenancyEnabled:     true,
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	acme := store.ForTenant("acme")
+	globex := store.ForTenant("globex")
+
+	order, err := acme.EntityCreateWithTypeAndAttributes("order", map[string]string{"status": "paid"})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	if order.TenantID() != "acme" {
+		t.Fatal("Tenant ID mismatch:", order.TenantID())
+	}
+
+	_, err = globex.EntityCreateWithTypeAndAttributes("order", map[string]string{"status": "pending"})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	found, err := globex.EntityFindByID(order.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if found != nil {
+		t.Fatal("Entity of another tenant must not be visible")
+	}
+
+	attr, err := globex.AttributeFind(order.ID(), "status")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if attr != nil {
+		t.Fatal("Attribute of another tenant must not be visible")
+	}
+
+	byAttribute, err := globex.EntityFindByAttribute("order", "status", "paid")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if byAttribute != nil {
+		t.Fatal("Entity of another tenant must not be found by attribute")
+	}
+
+	err = globex.AttributeSetString(order.ID(), "status", "refunded")
+
+	if err == nil {
+		t.Fatal("Setting an attribute of another tenant must fail")
+	}
+
+	isTrashed, err := globex.EntityTrash(order.ID())
+
+	if !errors.Is(err, ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound:", err)
+	}
+
+	if isTrashed {
+		t.Fatal("Entity of another tenant must not be trashed")
+	}
+
+	_, err = globex.EntityDelete(order.ID())
+
+	if !errors.Is(err, ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound:", err)
+	}
+
+	for tenant, view := range map[string]StoreInterface{"acme": acme, "globex": globex} {
+		count, err := view.EntityCount(EntityQueryOptions{EntityType: "order"})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if count != 1 {
+			t.Fatal("Entity count of", tenant, "must be 1, found:", count)
+		}
+	}
+
+	count, err := store.EntityCount(EntityQueryOptions{EntityType: "order"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 2 {
+		t.Fatal("Unscoped entity count must be 2, found:", count)
+	}
+
+	attr, err = acme.AttributeFind(order.ID(), "status")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if attr == nil || attr.AttributeValue() != "paid" {
+		t.Fatal("Attribute of the tenant must be unchanged")
+	}
+
+	isTrashed, err = acme.EntityTrash(order.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !isTrashed {
+		t.Fatal("Entity of the tenant must be trashed")
+	}
+
+	isRestored, err := globex.EntityRestore(order.ID())
+
+	if !errors.Is(err, ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound:", err)
+	}
+
+	if isRestored {
+		t.Fatal("Entity of another tenant must not be restored")
+	}
+
+	isRestored, err = acme.EntityRestore(order.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !isRestored {
+		t.Fatal("Entity of the tenant must be restored")
+	}
+
+	restored, err := acme.EntityFindByID(order.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if restored == nil || restored.TenantID() != "acme" {
+		t.Fatal("Restored entity must keep its tenant")
+	}
+}
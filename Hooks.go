@@ -0,0 +1,153 @@
+package entitystore
+
+import (
+	"context"
+	"sync"
+)
+
+// EntityHook is called with the affected entity. Returning an error
+// vetoes the operation and rolls back the surrounding transaction
+type EntityHook func(ctx context.Context, entity *Entity) error
+
+// AttributeHook is called with the affected attribute. Returning an error
+// vetoes the operation and rolls back the surrounding transaction
+type AttributeHook func(ctx context.Context, attribute *Attribute) error
+
+const hookBeforeEntityCreate = "before_entity_create"
+const hookAfterEntityCreate = "after_entity_create"
+const hookBeforeEntityUpdate = "before_entity_update"
+const hookAfterEntityUpdate = "after_entity_update"
+const hookBeforeEntityTrash = "before_entity_trash"
+const hookAfterEntityTrash = "after_entity_trash"
+const hookBeforeEntityDelete = "before_entity_delete"
+const hookAfterEntityDelete = "after_entity_delete"
+const hookBeforeAttributeSet = "before_attribute_set"
+const hookAfterAttributeSet = "after_attribute_set"
+const hookBeforeAttributeDelete = "before_attribute_delete"
+const hookAfterAttributeDelete = "after_attribute_delete"
+
+// storeHooks holds the registered hooks, shared by all views of a store
+type storeHooks struct {
+	mu             sync.RWMutex
+	entityHooks    map[string][]EntityHook
+	attributeHooks map[string][]AttributeHook
+}
+
+func newStoreHooks() *storeHooks {
+	return &storeHooks{
+		entityHooks:    map[string][]EntityHook{},
+		attributeHooks: map[string][]AttributeHook{},
+	}
+}
+
+// BeforeEntityCreate registers a hook called before an entity is created
+func (st *storeImplementation) BeforeEntityCreate(hook EntityHook) {
+	st.entityHookAdd(hookBeforeEntityCreate, hook)
+}
+
+// AfterEntityCreate registers a hook called after an entity is created
+func (st *storeImplementation) AfterEntityCreate(hook EntityHook) {
+	st.entityHookAdd(hookAfterEntityCreate, hook)
+}
+
+// BeforeEntityUpdate registers a hook called before an entity is updated
+func (st *storeImplementation) BeforeEntityUpdate(hook EntityHook) {
+	st.entityHookAdd(hookBeforeEntityUpdate, hook)
+}
+
+// AfterEntityUpdate registers a hook called after an entity is updated
+func (st *storeImplementation) AfterEntityUpdate(hook EntityHook) {
+	st.entityHookAdd(hookAfterEntityUpdate, hook)
+}
+
+// BeforeEntityTrash registers a hook called before an entity is moved to the trash bin
+func (st *storeImplementation) BeforeEntityTrash(hook EntityHook) {
+	st.entityHookAdd(hookBeforeEntityTrash, hook)
+}
+
+// AfterEntityTrash registers a hook called after an entity is moved to the trash bin
+func (st *storeImplementation) AfterEntityTrash(hook EntityHook) {
+	st.entityHookAdd(hookAfterEntityTrash, hook)
+}
+
+// BeforeEntityDelete registers a hook called before an entity is deleted
+func (st *storeImplementation) BeforeEntityDelete(hook EntityHook) {
+	st.entityHookAdd(hookBeforeEntityDelete, hook)
+}
+
+// AfterEntityDelete registers a hook called after an entity is deleted
+func (st *storeImplementation) AfterEntityDelete(hook EntityHook) {
+	st.entityHookAdd(hookAfterEntityDelete, hook)
+}
+
+// BeforeAttributeSet registers a hook called before an attribute is created or updated
+func (st *storeImplementation) BeforeAttributeSet(hook AttributeHook) {
+	st.attributeHookAdd(hookBeforeAttributeSet, hook)
+}
+
+// AfterAttributeSet registers a hook called after an attribute is created or updated
+func (st *storeImplementation) AfterAttributeSet(hook AttributeHook) {
+	st.attributeHookAdd(hookAfterAttributeSet, hook)
+}
+
+// BeforeAttributeDelete registers a hook called before an attribute is deleted
+func (st *storeImplementation) BeforeAttributeDelete(hook AttributeHook) {
+	st.attributeHookAdd(hookBeforeAttributeDelete, hook)
+}
+
+// AfterAttributeDelete registers a hook called after an attribute is deleted
+func (st *storeImplementation) AfterAttributeDelete(hook AttributeHook) {
+	st.attributeHookAdd(hookAfterAttributeDelete, hook)
+}
+
+func (st *storeImplementation) entityHookAdd(event string, hook EntityHook) {
+	st.hooks.mu.Lock()
+	defer st.hooks.mu.Unlock()
+	st.hooks.entityHooks[event] = append(st.hooks.entityHooks[event], hook)
+}
+
+func (st *storeImplementation) attributeHookAdd(event string, hook AttributeHook) {
+	st.hooks.mu.Lock()
+	defer st.hooks.mu.Unlock()
+	st.hooks.attributeHooks[event] = append(st.hooks.attributeHooks[event], hook)
+}
+
+// entityHooksRun runs the entity hooks registered for the event,
+// stopping at the first error
+func (st *storeImplementation) entityHooksRun(event string, entity *Entity) error {
+	if st.hooks == nil {
+		return nil
+	}
+
+	st.hooks.mu.RLock()
+	hooks := st.hooks.entityHooks[event]
+	st.hooks.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(st.context(), entity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attributeHooksRun runs the attribute hooks registered for the event,
+// stopping at the first error
+func (st *storeImplementation) attributeHooksRun(event string, attribute *Attribute) error {
+	if st.hooks == nil {
+		return nil
+	}
+
+	st.hooks.mu.RLock()
+	hooks := st.hooks.attributeHooks[event]
+	st.hooks.mu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(st.context(), attribute); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package entitystore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	db := InitDB("test_hooks.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	type ctxKey string
+
+	events := []string{}
+	requests := []any{}
+
+	store.BeforeEntityCreate(func(ctx context.Context, entity *Entity) error {
+		requests = append(requests, ctx.Value(ctxKey("request")))
+		entity.SetHandle("created-by-hook")
+		events = append(events, "before_entity_create")
+		return nil
+	})
+
+	store.AfterEntityCreate(func(ctx context.Context, entity *Entity) error {
+		events = append(events, "after_entity_create")
+		return nil
+	})
+
+	store.BeforeAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		if attribute.AttributeKey() == "readonly" {
+			return errors.New("readonly attribute")
+		}
+		events = append(events, "before_attribute_set")
+		return nil
+	})
+
+	store.AfterAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		events = append(events, "after_attribute_set")
+		return nil
+	})
+
+	store.BeforeEntityTrash(func(ctx context.Context, entity *Entity) error {
+		return errors.New("trash vetoed")
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "REQUEST_01")
+
+	entity, err := store.WithContext(ctx).EntityCreateWithTypeAndAttributes("post", map[string]string{
+		"title": "Hello world",
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	if len(requests) != 1 || requests[0] != "REQUEST_01" {
+		t.Fatal("Context must be passed to the hook, found: ", requests)
+	}
+
+	if entity.Handle() != "created-by-hook" {
+		t.Fatal("Handle must be set by hook, found: ", entity.Handle())
+	}
+
+	expected := []string{"before_entity_create", "after_entity_create", "before_attribute_set", "after_attribute_set"}
+
+	if len(events) != len(expected) {
+		t.Fatal("Events mismatch:", events)
+	}
+
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatal("Events mismatch:", events)
+		}
+	}
+
+	_, err = store.EntityCreateWithTypeAndAttributes("post", map[string]string{
+		"readonly": "yes",
+	})
+
+	if err == nil {
+		t.Fatal("Hook must veto the attribute")
+	}
+
+	count, err := store.EntityCount(EntityQueryOptions{EntityType: "post"})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if count != 1 {
+		t.Fatal("Vetoed entity must be rolled back, found entities: ", count)
+	}
+
+	isTrashed, err := store.EntityTrash(entity.ID())
+
+	if err == nil || isTrashed {
+		t.Fatal("Hook must veto the trash")
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if found == nil {
+		t.Fatal("Entity must still exist after the vetoed trash")
+	}
+}
@@ -0,0 +1,411 @@
+package entitystore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gouniverse/uid"
+)
+
+const IMPORT_CONFLICT_FAIL = "fail"
+const IMPORT_CONFLICT_SKIP = "skip"
+const IMPORT_CONFLICT_OVERWRITE = "overwrite"
+
+// importBatchSizeDefault the number of entities imported per transaction
+const importBatchSizeDefault = 100
+
+// ImportOptions controls how the records of an export are imported
+type ImportOptions struct {
+	// OnConflict what to do with the entities which already exist,
+	// IMPORT_CONFLICT_FAIL (default), IMPORT_CONFLICT_SKIP or IMPORT_CONFLICT_OVERWRITE.
+	// With PreserveIDs an entity exists if its ID is found (in the trash bin too),
+	// otherwise if an entity of the same type has the same non-empty handle
+	OnConflict string
+	// PreserveIDs keeps the IDs of the records, otherwise new IDs are assigned
+	// and the parent IDs are mapped to the new IDs of the imported parents.
+	// The parents are linked once all the records are imported, a parent
+	// which is neither imported nor in the store fails the import
+	PreserveIDs bool
+	// BatchSize the number of records imported per transaction, 100 by default
+	BatchSize int
+}
+
+// ImportResult counts the imported records
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+// Import reads the JSON Lines written by Export, and recreates the entities
+// with their attributes. Each batch of records is imported in a transaction,
+// if a record fails its batch is rolled back, while the previous batches stay
+func (st *storeImplementation) Import(ctx context.Context, r io.Reader, options ImportOptions) (result ImportResult, err error) {
+	defer wrapOpError(&err, "Import", "")
+
+	if r == nil {
+		return result, errInvalidArgument("reader cannot be nil")
+	}
+
+	if options.OnConflict == "" {
+		options.OnConflict = IMPORT_CONFLICT_FAIL
+	}
+
+	if options.OnConflict != IMPORT_CONFLICT_FAIL && options.OnConflict != IMPORT_CONFLICT_SKIP && options.OnConflict != IMPORT_CONFLICT_OVERWRITE {
+		return result, errInvalidArgument("unsupported conflict policy " + options.OnConflict)
+	}
+
+	if options.BatchSize < 1 {
+		options.BatchSize = importBatchSizeDefault
+	}
+
+	// the hooks run during the import receive the context
+	view := st.WithContext(ctx).(*storeImplementation)
+
+	decoder := json.NewDecoder(r)
+	newIDs := map[string]string{}
+	// the parent IDs of the records, by the ID of their entity
+	parentIDs := map[string]string{}
+	batch := []ExportRecord{}
+	line := 0
+
+	importBatch := func() error {
+		if len(batch) < 1 {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batchResult := ImportResult{}
+
+		err := view.inTransaction(func(tx *storeImplementation) error {
+			for _, record := range batch {
+				if err := tx.importRecord(record, options, newIDs, parentIDs, &batchResult); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		result.Created += batchResult.Created
+		result.Updated += batchResult.Updated
+		result.Skipped += batchResult.Skipped
+		batch = []ExportRecord{}
+
+		return nil
+	}
+
+	for {
+		record := ExportRecord{}
+		err := decoder.Decode(&record)
+
+		if err == io.EOF {
+			break
+		}
+
+		line++
+
+		if err != nil {
+			return result, fmt.Errorf("%w: record %d: %v", ErrInvalidArgument, line, err)
+		}
+
+		if record.Type == "" {
+			return result, fmt.Errorf("%w: record %d: entity type cannot be empty", ErrInvalidArgument, line)
+		}
+
+		batch = append(batch, record)
+
+		if len(batch) >= options.BatchSize {
+			if err := importBatch(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := importBatch(); err != nil {
+		return result, err
+	}
+
+	if options.PreserveIDs {
+		return result, nil
+	}
+
+	return result, view.importParentsLink(parentIDs, newIDs, options.BatchSize)
+}
+
+// importParentsLink sets the parents of the imported entities,
+// mapping the parent IDs of the records to the new IDs. A parent
+// which was not imported must already be in the store
+func (st *storeImplementation) importParentsLink(parentIDs map[string]string, newIDs map[string]string, batchSize int) error {
+	entityIDs := make([]string, 0, len(parentIDs))
+
+	for entityID := range parentIDs {
+		entityIDs = append(entityIDs, entityID)
+	}
+
+	sort.Strings(entityIDs)
+
+	for start := 0; start < len(entityIDs); start += batchSize {
+		end := min(start+batchSize, len(entityIDs))
+
+		err := st.inTransaction(func(tx *storeImplementation) error {
+			for _, entityID := range entityIDs[start:end] {
+				parentID, isImported := newIDs[parentIDs[entityID]]
+
+				if !isImported {
+					parent, _, err := tx.entityFindIncludingTrash(parentIDs[entityID])
+
+					if err != nil {
+						return err
+					}
+
+					if parent == nil {
+						return fmt.Errorf("%w: parent entity %s of entity %s is neither imported nor in the store", ErrEntityNotFound, parentIDs[entityID], entityID)
+					}
+
+					parentID = parent.ID()
+				}
+
+				if err := tx.importParentSet(entityID, parentID); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importParentSet sets the parent of an imported entity, in the trash bin too
+func (st *storeImplementation) importParentSet(entityID string, parentID string) error {
+	entity, isTrashed, err := st.entityFindIncludingTrash(entityID)
+
+	if err != nil {
+		return err
+	}
+
+	if entity == nil {
+		return errEntityNotFound(entityID)
+	}
+
+	if !isTrashed {
+		return st.EntitySetParent(entityID, parentID)
+	}
+
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+		Update(st.entityTrashTableName).
+		Set(goqu.Record{COLUMN_PARENT_ID: parentID}).
+		Where(goqu.C(COLUMN_ID).Eq(entityID)).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = st.exec("importParentSet", sqlStr)
+
+	return err
+}
+
+// importRecord creates the entity of the record, or resolves
+// the conflict with the existing entity according to the policy
+func (st *storeImplementation) importRecord(record ExportRecord, options ImportOptions, newIDs map[string]string, parentIDs map[string]string, result *ImportResult) error {
+	var existing *Entity
+	existingTrashed := false
+	var err error
+
+	if options.PreserveIDs && record.ID != "" {
+		existing, existingTrashed, err = st.entityFindIncludingTrash(record.ID)
+	} else if record.Handle != "" {
+		existing, err = st.EntityFindByHandle(record.Type, record.Handle)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// without the IDs preserved, the parents are linked after the import
+	parentID := record.ParentID
+
+	if !options.PreserveIDs {
+		parentID = ""
+	}
+
+	if existing != nil {
+		newIDs[record.ID] = existing.ID()
+
+		switch options.OnConflict {
+		case IMPORT_CONFLICT_SKIP:
+			result.Skipped++
+			return nil
+		case IMPORT_CONFLICT_FAIL:
+			return fmt.Errorf("%w: entity %s already exists", ErrDuplicate, existing.ID())
+		}
+
+		if !options.PreserveIDs && record.ParentID != "" && st.hierarchyEnabled {
+			parentIDs[existing.ID()] = record.ParentID
+		}
+
+		// a trashed entity is replaced by the record
+		if existingTrashed {
+			if err := st.entityTrashPurge(existing.ID()); err != nil {
+				return err
+			}
+
+			if err := st.importEntityCreate(existing.ID(), parentID, record); err != nil {
+				return err
+			}
+
+			result.Updated++
+			return nil
+		}
+
+		if err := st.importEntityOverwrite(*existing, parentID, record); err != nil {
+			return err
+		}
+
+		result.Updated++
+		return nil
+	}
+
+	entityID := uid.HumanUid()
+
+	if options.PreserveIDs && record.ID != "" {
+		entityID = record.ID
+	}
+
+	newIDs[record.ID] = entityID
+
+	if !options.PreserveIDs && record.ParentID != "" && st.hierarchyEnabled {
+		parentIDs[entityID] = record.ParentID
+	}
+
+	if err := st.importEntityCreate(entityID, parentID, record); err != nil {
+		return err
+	}
+
+	result.Created++
+	return nil
+}
+
+// importEntityCreate creates the entity of the record with its attributes,
+// and moves it to the trash bin if the record was trashed
+func (st *storeImplementation) importEntityCreate(entityID string, parentID string, record ExportRecord) error {
+	entity := st.NewEntity(NewEntityOptions{
+		ID:        entityID,
+		Type:      record.Type,
+		Handle:    record.Handle,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	})
+
+	if st.hierarchyEnabled {
+		entity.SetParentID(parentID)
+	}
+
+	if st.tenancyEnabled {
+		entity.SetTenantID(record.TenantID)
+	}
+
+	if err := st.EntityCreate(&entity); err != nil {
+		return err
+	}
+
+	for _, key := range importAttributeKeys(record) {
+		attribute := st.NewAttribute(NewAttributeOptions{
+			EntityID:       entityID,
+			AttributeKey:   key,
+			AttributeValue: record.Attributes[key],
+			CreatedAt:      record.CreatedAt,
+			UpdatedAt:      record.UpdatedAt,
+		})
+
+		if err := st.AttributeCreate(&attribute); err != nil {
+			return err
+		}
+	}
+
+	if record.DeletedAt == nil {
+		return nil
+	}
+
+	_, err := st.entityTrash(entityID, *record.DeletedAt)
+
+	return err
+}
+
+// importEntityOverwrite updates the existing entity to match the record,
+// the attributes missing from the record are deleted
+func (st *storeImplementation) importEntityOverwrite(entity Entity, parentID string, record ExportRecord) error {
+	entity.SetType(record.Type)
+	entity.SetHandle(record.Handle)
+
+	if st.hierarchyEnabled {
+		entity.SetParentID(parentID)
+	}
+
+	if err := st.EntityUpdate(entity); err != nil {
+		return err
+	}
+
+	attributes, err := st.EntityAttributeList(entity.ID())
+
+	if err != nil {
+		return err
+	}
+
+	for _, attribute := range attributes {
+		if _, exists := record.Attributes[attribute.AttributeKey()]; exists {
+			continue
+		}
+
+		if _, err := st.AttributeDelete(entity.ID(), attribute.AttributeKey()); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range importAttributeKeys(record) {
+		if err := st.AttributeSetString(entity.ID(), key, record.Attributes[key]); err != nil {
+			return err
+		}
+	}
+
+	if record.DeletedAt == nil {
+		return nil
+	}
+
+	_, err = st.entityTrash(entity.ID(), *record.DeletedAt)
+
+	return err
+}
+
+// importAttributeKeys the attribute keys of the record, sorted
+// so the attributes are always created in the same order
+func importAttributeKeys(record ExportRecord) []string {
+	keys := make([]string, 0, len(record.Attributes))
+
+	for key := range record.Attributes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
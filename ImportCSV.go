@@ -0,0 +1,227 @@
+package entitystore
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+)
+
+const CSV_MATCH_ID = "id"
+const CSV_MATCH_HANDLE = "handle"
+
+// CSVMapping controls how the columns of a CSV file are imported
+type CSVMapping struct {
+	// Attributes maps the CSV columns to the attribute keys. If empty, all
+	// the columns except id, handle, created_at and updated_at are imported
+	// as the attributes of the same name
+	Attributes map[string]string
+	// MatchBy the column finding the existing entities to update,
+	// CSV_MATCH_ID (default) or CSV_MATCH_HANDLE
+	MatchBy string
+	// Comma the field delimiter, a comma by default
+	Comma rune
+}
+
+// CSVRowError is the error of a single row of a CSV import.
+// The rows are numbered as in a spreadsheet, the header being row 1
+type CSVRowError struct {
+	Row int
+	Err error
+}
+
+func (e CSVRowError) Error() string {
+	return "row " + strconv.Itoa(e.Row) + ": " + e.Err.Error()
+}
+
+func (e CSVRowError) Unwrap() error {
+	return e.Err
+}
+
+// CSVImportResult counts the imported rows, and lists the failed ones
+type CSVImportResult struct {
+	Created int
+	Updated int
+	Errors  []CSVRowError
+}
+
+// ImportCSV creates or updates the entities of a type from the rows of a CSV
+// file with a header, like the ones written by ExportCSV. The existing entities
+// are matched by id or by handle. Each row is imported in its own transaction,
+// the failed rows are reported in the result and the import goes on.
+// The empty cells leave the attributes unchanged, the timestamps are ignored.
+// A leading quote, added by ExportCSV to escape the cells, is removed
+func (st *storeImplementation) ImportCSV(ctx context.Context, r io.Reader, entityType string, mapping CSVMapping) (result CSVImportResult, err error) {
+	defer wrapOpError(&err, "ImportCSV", "")
+
+	if r == nil {
+		return result, errInvalidArgument("reader cannot be nil")
+	}
+
+	if entityType == "" {
+		return result, errInvalidArgument("entity type cannot be empty")
+	}
+
+	if mapping.MatchBy == "" {
+		mapping.MatchBy = CSV_MATCH_ID
+	}
+
+	if mapping.MatchBy != CSV_MATCH_ID && mapping.MatchBy != CSV_MATCH_HANDLE {
+		return result, errInvalidArgument("unsupported match column " + mapping.MatchBy)
+	}
+
+	reader := csv.NewReader(r)
+
+	if mapping.Comma != 0 {
+		reader.Comma = mapping.Comma
+	}
+
+	header, err := reader.Read()
+
+	if err == io.EOF {
+		return result, nil
+	}
+
+	if err != nil {
+		return result, errInvalidArgument("header cannot be read: " + err.Error())
+	}
+
+	columnIndexes := map[string]int{}
+
+	for index, column := range header {
+		columnIndexes[column] = index
+	}
+
+	if _, exists := columnIndexes[mapping.MatchBy]; !exists && mapping.MatchBy == CSV_MATCH_HANDLE {
+		return result, errInvalidArgument("handle column is required to match by handle")
+	}
+
+	attributeColumns := map[int]string{}
+
+	for index, column := range header {
+		if len(mapping.Attributes) > 0 {
+			if key, exists := mapping.Attributes[column]; exists && key != "" {
+				attributeColumns[index] = key
+			}
+
+			continue
+		}
+
+		switch column {
+		case CSV_COLUMN_ID, CSV_COLUMN_HANDLE, CSV_COLUMN_CREATED_AT, CSV_COLUMN_UPDATED_AT:
+			continue
+		}
+
+		attributeColumns[index] = column
+	}
+
+	// the hooks run during the import receive the context
+	view := st.WithContext(ctx).(*storeImplementation)
+
+	for row := 2; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		values, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if errors.Is(err, csv.ErrFieldCount) {
+			result.Errors = append(result.Errors, CSVRowError{Row: row, Err: errInvalidArgument("wrong number of fields")})
+			continue
+		}
+
+		if err != nil {
+			return result, errInvalidArgument("row " + strconv.Itoa(row) + " cannot be read: " + err.Error())
+		}
+
+		cell := func(column string) string {
+			if index, exists := columnIndexes[column]; exists {
+				return csvCellUnescape(values[index])
+			}
+
+			return ""
+		}
+
+		attributes := map[string]string{}
+
+		for index, key := range attributeColumns {
+			if values[index] != "" {
+				attributes[key] = csvCellUnescape(values[index])
+			}
+		}
+
+		isCreated := false
+
+		err = view.inTransaction(func(tx *storeImplementation) error {
+			var err error
+			isCreated, err = tx.importCSVRow(entityType, cell(CSV_COLUMN_ID), cell(CSV_COLUMN_HANDLE), attributes, mapping.MatchBy)
+			return err
+		})
+
+		if err != nil {
+			result.Errors = append(result.Errors, CSVRowError{Row: row, Err: err})
+			continue
+		}
+
+		if isCreated {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	return result, nil
+}
+
+// importCSVRow creates or updates the entity of a row, returns true if created
+func (st *storeImplementation) importCSVRow(entityType string, entityID string, entityHandle string, attributes map[string]string, matchBy string) (bool, error) {
+	var existing *Entity
+	var err error
+
+	if matchBy == CSV_MATCH_HANDLE {
+		if entityHandle == "" {
+			return false, errInvalidArgument("handle cannot be empty")
+		}
+
+		existing, err = st.EntityFindByHandle(entityType, entityHandle)
+	} else if entityID != "" {
+		existing, err = st.EntityFindByID(entityID)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if existing == nil {
+		entity := st.NewEntity(NewEntityOptions{
+			ID:     entityID,
+			Type:   entityType,
+			Handle: entityHandle,
+		})
+
+		if err := st.EntityCreate(&entity); err != nil {
+			return false, err
+		}
+
+		return true, st.AttributesSet(entity.ID(), attributes)
+	}
+
+	if existing.Type() != entityType {
+		return false, errInvalidArgument("entity " + existing.ID() + " is not of type " + entityType)
+	}
+
+	if matchBy == CSV_MATCH_ID && entityHandle != "" && entityHandle != existing.Handle() {
+		existing.SetHandle(entityHandle)
+
+		if err := st.EntityUpdate(*existing); err != nil {
+			return false, err
+		}
+	}
+
+	return false, st.AttributesSet(existing.ID(), attributes)
+}
@@ -0,0 +1,60 @@
+package entitystore
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+)
+
+// Link is a directed relationship between two entities
+type Link struct {
+	ID        string
+	FromID    string
+	ToID      string
+	Relation  string
+	Position  int64
+	Metadata  map[string]string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (l *Link) ToMap() map[string]any {
+	metadata := l.Metadata
+
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+
+	entry := map[string]any{}
+	entry[COLUMN_ID] = l.ID
+	entry[COLUMN_FROM_ID] = l.FromID
+	entry[COLUMN_TO_ID] = l.ToID
+	entry[COLUMN_RELATION] = l.Relation
+	entry[COLUMN_POSITION] = l.Position
+	entry[COLUMN_METADATA] = string(metadataJSON)
+	entry[COLUMN_CREATED_AT] = l.CreatedAt
+	entry[COLUMN_UPDATED_AT] = l.UpdatedAt
+	return entry
+}
+
+func newLinkFromMap(linkMap map[string]string) Link {
+	link := Link{
+		ID:        linkMap[COLUMN_ID],
+		FromID:    linkMap[COLUMN_FROM_ID],
+		ToID:      linkMap[COLUMN_TO_ID],
+		Relation:  linkMap[COLUMN_RELATION],
+		Metadata:  map[string]string{},
+		CreatedAt: carbon.Parse(linkMap[COLUMN_CREATED_AT], carbon.UTC).StdTime(),
+		UpdatedAt: carbon.Parse(linkMap[COLUMN_UPDATED_AT], carbon.UTC).StdTime(),
+	}
+
+	link.Position, _ = strconv.ParseInt(linkMap[COLUMN_POSITION], 10, 64)
+
+	_ = json.Unmarshal([]byte(linkMap[COLUMN_METADATA]), &link.Metadata)
+
+	return link
+}
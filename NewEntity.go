@@ -6,6 +6,9 @@ type NewEntityOptions struct {
 	ID        string
 	Type      string
 	Handle    string
+	ParentID  string
+	TenantID  string
+	Version   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -15,6 +18,9 @@ func (st *storeImplementation) NewEntity(opts NewEntityOptions) Entity {
 	entity.SetID(opts.ID)
 	entity.SetType(opts.Type)
 	entity.SetHandle(opts.Handle)
+	entity.SetParentID(opts.ParentID)
+	entity.SetTenantID(opts.TenantID)
+	entity.SetVersion(opts.Version)
 	entity.SetCreatedAt(opts.CreatedAt)
 	entity.SetUpdatedAt(opts.UpdatedAt)
 	entity.st = st
@@ -1,6 +1,10 @@
 package entitystore
 
-import "github.com/dromara/carbon/v2"
+import (
+	"strconv"
+
+	"github.com/dromara/carbon/v2"
+)
 
 func (st *storeImplementation) NewEntityFromMap(entityMap map[string]string) Entity {
 	opts := NewEntityOptions{}
@@ -13,10 +17,19 @@ func (st *storeImplementation) NewEntityFromMap(entityMap map[string]string) Ent
 	if entityHandle, exists := entityMap[COLUMN_ENTITY_HANDLE]; exists {
 		opts.Handle = entityHandle
 	}
+	if parentID, exists := entityMap[COLUMN_PARENT_ID]; exists {
+		opts.ParentID = parentID
+	}
+	if tenantID, exists := entityMap[COLUMN_TENANT_ID]; exists {
+		opts.TenantID = tenantID
+	}
+	if version, exists := entityMap[COLUMN_VERSION]; exists {
+		opts.Version, _ = strconv.ParseInt(version, 10, 64)
+	}
 	if createdAt, exists := entityMap[COLUMN_CREATED_AT]; exists {
 		opts.CreatedAt = carbon.Parse(createdAt, carbon.UTC).StdTime()
 	}
-	if updatedAt, exists := entityMap[COLUMN_CREATED_AT]; exists {
+	if updatedAt, exists := entityMap[COLUMN_UPDATED_AT]; exists {
 		opts.UpdatedAt = carbon.Parse(updatedAt, carbon.UTC).StdTime()
 	}
 
@@ -0,0 +1,431 @@
+package entitystore
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Projection declares a projection table: a table with a row per entity
+// of the type and a typed column per attribute key, kept in sync by every
+// write in the transaction of the write. RebuildProjection fills it for
+// the existing entities, EntityList filters through it once built
+type Projection struct {
+	EntityType string
+	TableName  string
+
+	// Columns maps the attribute keys to the types of their columns,
+	// VALUE_TYPE_STRING, VALUE_TYPE_INT or VALUE_TYPE_FLOAT. The
+	// values which do not fit the type of their column are NULL,
+	// as are the ints not written plainly (e.g. 007 or +7)
+	Columns map[string]string
+}
+
+// projectionColumnPattern the attribute keys must be plain column names
+var projectionColumnPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// projectionValidate returns an error if the projections are not valid
+func (st *storeImplementation) projectionValidate(projections []Projection) error {
+	entityTypes := map[string]bool{}
+
+	for _, projection := range projections {
+		if projection.EntityType == "" || projection.TableName == "" {
+			return errors.New("entity store: EntityType and TableName are required for a projection")
+		}
+
+		if entityTypes[projection.EntityType] {
+			return errors.New("entity store: more than one projection for entity type " + projection.EntityType)
+		}
+
+		entityTypes[projection.EntityType] = true
+
+		for key, valueType := range projection.Columns {
+			if !projectionColumnPattern.MatchString(key) || key == COLUMN_ENTITY_ID {
+				return errors.New("entity store: attribute key " + key + " cannot be a projection column")
+			}
+
+			if valueType != VALUE_TYPE_STRING && valueType != VALUE_TYPE_INT && valueType != VALUE_TYPE_FLOAT {
+				return errors.New("entity store: unsupported projection column type " + valueType)
+			}
+
+			// sealed values are random bytes to the database
+			if st.attributeEncrypted(key) {
+				return errors.New("entity store: attribute " + key + " is encrypted and cannot be projected")
+			}
+		}
+	}
+
+	return nil
+}
+
+// projectionFor returns the projection of the entity type, nil if it has none
+func (st *storeImplementation) projectionFor(entityType string) *Projection {
+	for i := range st.projections {
+		if st.projections[i].EntityType == entityType {
+			return &st.projections[i]
+		}
+	}
+
+	return nil
+}
+
+// projectionKeys returns the attribute keys of the projection, sorted
+func projectionKeys(projection Projection) []string {
+	keys := []string{}
+
+	for key := range projection.Columns {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// projectionColumnType returns the column type of the value type for the dialect
+func (st *storeImplementation) projectionColumnType(valueType string) string {
+	switch {
+	case valueType == VALUE_TYPE_INT && st.dbDriverName == "sqlite":
+		return "integer"
+	case valueType == VALUE_TYPE_INT:
+		return "bigint"
+	case valueType == VALUE_TYPE_FLOAT && st.dbDriverName == "sqlite":
+		return "real"
+	case valueType == VALUE_TYPE_FLOAT && st.dbDriverName == "mysql":
+		return "double"
+	case valueType == VALUE_TYPE_FLOAT:
+		return "double precision"
+	}
+
+	return "text"
+}
+
+// sqlCreateProjectionTable returns the SQL to create a projection table
+func (st *storeImplementation) sqlCreateProjectionTable(projection Projection) string {
+	columns := []string{st.quoteIdentifier(COLUMN_ENTITY_ID) + " varchar(40) NOT NULL PRIMARY KEY"}
+
+	for _, key := range projectionKeys(projection) {
+		columns = append(columns, st.quoteIdentifier(key)+" "+st.projectionColumnType(projection.Columns[key]))
+	}
+
+	return "CREATE TABLE IF NOT EXISTS " + st.quoteIdentifier(projection.TableName) + " (" + strings.Join(columns, ", ") + ");"
+}
+
+// projectionStateTableName returns the name of the table
+// recording the projections built by RebuildProjection
+func (st *storeImplementation) projectionStateTableName() string {
+	return st.entityTableName + "_projection"
+}
+
+// sqlCreateProjectionStateTable returns the SQL to create the projection state table
+func (st *storeImplementation) sqlCreateProjectionStateTable() string {
+	return "CREATE TABLE IF NOT EXISTS " + st.quoteIdentifier(st.projectionStateTableName()) + " (" +
+		st.quoteIdentifier(COLUMN_ID) + " varchar(255) NOT NULL PRIMARY KEY, " +
+		st.quoteIdentifier(COLUMN_ENTITY_TYPE) + " varchar(40) NOT NULL, " +
+		st.quoteIdentifier(COLUMN_METADATA) + " text);"
+}
+
+// projectionBuiltSave records the projection as built, with its columns
+func (st *storeImplementation) projectionBuiltSave(projection Projection) error {
+	columns, err := json.Marshal(projection.Columns)
+
+	if err != nil {
+		return err
+	}
+
+	deleteSql, _, err := goqu.Dialect(st.dbDriverName).
+		From(st.projectionStateTableName()).
+		Where(goqu.C(COLUMN_ID).Eq(projection.TableName)).
+		Delete().
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	insertSql, _, err := goqu.Dialect(st.dbDriverName).
+		Insert(st.projectionStateTableName()).
+		Rows(goqu.Record{
+			COLUMN_ID:          projection.TableName,
+			COLUMN_ENTITY_TYPE: projection.EntityType,
+			COLUMN_METADATA:    string(columns),
+		}).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	err = st.inTransaction(func(tx *storeImplementation) error {
+		if _, err := tx.exec("RebuildProjection", deleteSql); err != nil {
+			return err
+		}
+
+		_, err := tx.exec("RebuildProjection", insertSql)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	st.projectionsBuilt.Store(projection.TableName, true)
+
+	return nil
+}
+
+// projectionBuiltFor returns the projection of the entity type, nil if it
+// has none or it was not built by RebuildProjection with its current columns.
+// Until then the table misses the entities written before it was declared
+func (st *storeImplementation) projectionBuiltFor(entityType string) *Projection {
+	projection := st.projectionFor(entityType)
+
+	if projection == nil {
+		return nil
+	}
+
+	if _, isBuilt := st.projectionsBuilt.Load(projection.TableName); isBuilt {
+		return projection
+	}
+
+	rows, err := st.selectToMapStringFrom("projectionBuiltFor", st.projectionStateTableName(), goqu.C(COLUMN_ID).Eq(projection.TableName))
+
+	// not built as far as known, the attribute rows are used
+	if err != nil || len(rows) < 1 {
+		return nil
+	}
+
+	columns := map[string]string{}
+
+	if err := json.Unmarshal([]byte(rows[0][COLUMN_METADATA]), &columns); err != nil {
+		return nil
+	}
+
+	if rows[0][COLUMN_ENTITY_TYPE] != projection.EntityType || len(columns) != len(projection.Columns) {
+		return nil
+	}
+
+	for key, valueType := range projection.Columns {
+		if columns[key] != valueType {
+			return nil
+		}
+	}
+
+	st.projectionsBuilt.Store(projection.TableName, true)
+
+	return projection
+}
+
+// projectionsAdopt adds the projections recorded as built by another store
+// for the entity types this store has no projection of, so its writes keep
+// them in sync too. The projections built after the store was created are
+// not known to it, until it is created again
+func (st *storeImplementation) projectionsAdopt() error {
+	// a failed statement would abort the transaction in PostgreSQL
+	if st.database.Tx() != nil {
+		return nil
+	}
+
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).From(st.projectionStateTableName()).Select().ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	// fails if the table is not migrated yet, as nothing was built.
+	// Not observed, as failing is expected
+	rows, err := st.database.SelectToMapString(sqlStr)
+
+	if err != nil {
+		return nil
+	}
+
+	adopted := []Projection{}
+
+	for _, row := range rows {
+		if st.projectionFor(row[COLUMN_ENTITY_TYPE]) != nil {
+			continue
+		}
+
+		projection := Projection{
+			EntityType: row[COLUMN_ENTITY_TYPE],
+			TableName:  row[COLUMN_ID],
+			Columns:    map[string]string{},
+		}
+
+		if err := json.Unmarshal([]byte(row[COLUMN_METADATA]), &projection.Columns); err != nil {
+			return errors.New("entity store: projection " + projection.TableName + " has invalid metadata: " + err.Error())
+		}
+
+		adopted = append(adopted, projection)
+	}
+
+	if err := st.projectionValidate(adopted); err != nil {
+		return err
+	}
+
+	// copied, not to append to the slice of the options
+	st.projections = append(append([]Projection{}, st.projections...), adopted...)
+
+	return nil
+}
+
+// projectionValue converts the attribute value to the type of its column,
+// nil (NULL) if it does not fit. The ints must round-trip to the same
+// text, so the int columns compare like the text of the attribute rows
+func projectionValue(valueType string, value string) any {
+	switch valueType {
+	case VALUE_TYPE_INT:
+		number, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || strconv.FormatInt(number, 10) != value {
+			return nil
+		}
+
+		return number
+	case VALUE_TYPE_FLOAT:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return nil
+		}
+
+		return number
+	}
+
+	return value
+}
+
+// projectionRows reads the attributes of the entities,
+// returning their rows for the projection table
+func (st *storeImplementation) projectionRows(projection Projection, entityIDs []string) ([]any, error) {
+	keys := projectionKeys(projection)
+
+	rowsByID := map[string]goqu.Record{}
+
+	for _, entityID := range entityIDs {
+		row := goqu.Record{COLUMN_ENTITY_ID: entityID}
+
+		for _, key := range keys {
+			row[key] = nil
+		}
+
+		rowsByID[entityID] = row
+	}
+
+	if len(keys) > 0 {
+		attributeMaps, err := st.selectToMapStringFrom("projectionRows", st.attributeTableName, goqu.And(
+			goqu.C(COLUMN_ENTITY_ID).In(entityIDs),
+			goqu.C(COLUMN_ATTRIBUTE_KEY).In(keys),
+		))
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, attributeMap := range attributeMaps {
+			key := attributeMap[COLUMN_ATTRIBUTE_KEY]
+
+			value, err := st.attributeValueDecode(key, attributeMap[COLUMN_ATTRIBUTE_VALUE])
+
+			if err != nil {
+				return nil, err
+			}
+			rowsByID[attributeMap[COLUMN_ENTITY_ID]][key] = projectionValue(projection.Columns[key], value)
+		}
+	}
+
+	rows := []any{}
+
+	for _, entityID := range entityIDs {
+		rows = append(rows, rowsByID[entityID])
+	}
+
+	return rows, nil
+}
+
+// projectionWrite replaces the projection rows of the entities
+func (st *storeImplementation) projectionWrite(projection Projection, entityIDs []string) error {
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+		From(projection.TableName).
+		Where(goqu.C(COLUMN_ENTITY_ID).In(entityIDs)).
+		Delete().
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := st.exec("projectionWrite", sqlStr); err != nil {
+		return err
+	}
+
+	rows, err := st.projectionRows(projection, entityIDs)
+
+	if err != nil {
+		return err
+	}
+
+	sqlStr, _, err = goqu.Dialect(st.dbDriverName).Insert(projection.TableName).Rows(rows...).ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = st.exec("projectionWrite", sqlStr)
+
+	return err
+}
+
+// projectionSync brings the projection rows of the entity up to date
+// after a change, to be called in the transaction of the change. The
+// entity is removed from the projections it no longer belongs to
+func (st *storeImplementation) projectionSync(entityID string) error {
+	if len(st.projections) < 1 {
+		return nil
+	}
+
+	entityMaps, err := st.selectToMapStringFrom("projectionSync", st.entityTableName, goqu.C(COLUMN_ID).Eq(entityID))
+
+	if err != nil {
+		return err
+	}
+
+	entityType := ""
+
+	if len(entityMaps) > 0 {
+		entityType = entityMaps[0][COLUMN_ENTITY_TYPE]
+	}
+
+	for _, projection := range st.projections {
+		if projection.EntityType == entityType {
+			if err := st.projectionWrite(projection, []string{entityID}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+			From(projection.TableName).
+			Where(goqu.C(COLUMN_ENTITY_ID).Eq(entityID)).
+			Delete().
+			ToSQL()
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := st.exec("projectionSync", sqlStr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package entitystore
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestProjection(t *testing.T) {
+	db := InitDB("test_projection.db")
+
+	// entities created before the projection is declared
+	plain, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	for _, price := range []string{"9", "10", "100"} {
+		if _, err := plain.EntityCreateWithTypeAndAttributes("product", map[string]string{"price": price, "status": "active"}); err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+		Projections: []Projection{{
+			EntityType: "product",
+			TableName:  "cms_product",
+			Columns:    map[string]string{"price": VALUE_TYPE_INT, "status": VALUE_TYPE_STRING, "weight": VALUE_TYPE_FLOAT},
+		}},
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// the empty projection is not used before it is built
+	list, err := store.EntityList(EntityQueryOptions{EntityType: "product", Where: map[string]string{"price": "10"}})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(list) != 1 {
+		t.Fatal("Must find the product through the attributes before the rebuild, found:", len(list))
+	}
+
+	count, err := store.RebuildProjection("product")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 3 {
+		t.Fatal("Must write the 3 existing products, found:", count)
+	}
+
+	projected := func(entityID string) (price sql.NullInt64, status sql.NullString, exists bool) {
+		err := db.QueryRow(`SELECT price, status FROM cms_product WHERE entity_id = ?`, entityID).Scan(&price, &status)
+
+		if err == sql.ErrNoRows {
+			return price, status, false
+		}
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		return price, status, true
+	}
+
+	// the writes keep the projection in sync
+	pear, err := store.EntityCreateWithTypeAndAttributes("product", map[string]string{"price": "5", "status": "draft"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if price, status, _ := projected(pear.ID()); price.Int64 != 5 || status.String != "draft" {
+		t.Fatal("The created product must be projected, found:", price, status)
+	}
+
+	if err := store.AttributeSetString(pear.ID(), "price", "n/a"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if price, _, _ := projected(pear.ID()); price.Valid {
+		t.Fatal("A value which is not an int must be NULL, found:", price)
+	}
+
+	if _, err := store.AttributeDelete(pear.ID(), "status"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, status, _ := projected(pear.ID()); status.Valid {
+		t.Fatal("A deleted attribute must be NULL, found:", status)
+	}
+
+	if _, err := store.EntityTrash(pear.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, _, exists := projected(pear.ID()); exists {
+		t.Fatal("A trashed product must be removed from the projection")
+	}
+
+	if _, err := store.EntityRestore(pear.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, _, exists := projected(pear.ID()); !exists {
+		t.Fatal("A restored product must be projected again")
+	}
+
+	// sorted as text, like through the attribute rows
+	for _, sortingStore := range []StoreInterface{store, plain} {
+		list, err = sortingStore.EntityList(EntityQueryOptions{
+			EntityType:      "product",
+			Where:           map[string]string{"status": "active"},
+			SortByAttribute: "price",
+			SortOrder:       "desc",
+		})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		prices := ""
+
+		for _, entity := range list {
+			price, _ := entity.GetString("price", "")
+			prices += price + ","
+		}
+
+		if prices != "9,100,10," {
+			t.Fatal("Must sort the active products by price as text, found:", prices)
+		}
+	}
+
+	list, err = store.EntityList(EntityQueryOptions{EntityType: "product", Where: map[string]string{"price": "10"}})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(list) != 1 {
+		t.Fatal("Must find the product by price, found:", len(list))
+	}
+
+	// the filters match the text, like through the attributes
+	for _, attributes := range []map[string]string{{"price": "7", "weight": "1.5"}, {"price": "007", "weight": "1.50"}} {
+		if _, err := store.EntityCreateWithTypeAndAttributes("product", attributes); err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	for _, where := range []map[string]string{{"price": "7"}, {"price": "007"}, {"weight": "1.5"}, {"weight": "1.50"}} {
+		list, err := store.EntityList(EntityQueryOptions{EntityType: "product", Where: where})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if len(list) != 1 {
+			t.Fatal("Must find a single product by", where, "found:", len(list))
+		}
+	}
+
+	// a column added since the rebuild is not built
+	extended, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+		Projections: []Projection{{
+			EntityType: "product",
+			TableName:  "cms_product",
+			Columns:    map[string]string{"price": VALUE_TYPE_INT, "status": VALUE_TYPE_STRING, "weight": VALUE_TYPE_FLOAT, "color": VALUE_TYPE_STRING},
+		}},
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if extended.(*storeImplementation).projectionBuiltFor("product") != nil {
+		t.Fatal("A projection with a new column must not be used before the rebuild")
+	}
+
+	if _, err := extended.RebuildProjection("product"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if extended.(*storeImplementation).projectionBuiltFor("product") == nil {
+		t.Fatal("A rebuilt projection must be used")
+	}
+
+	if _, err := store.RebuildProjection("post"); err == nil {
+		t.Fatal("A type without projection must not be rebuilt")
+	}
+
+	// a store created after the rebuild keeps the projection
+	// in sync, even though it does not declare it
+	undeclared, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	plum, err := undeclared.EntityCreateWithTypeAndAttributes("product", map[string]string{"price": "3", "status": "active"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if price, status, _ := projected(plum.ID()); price.Int64 != 3 || status.String != "active" {
+		t.Fatal("A product created by a store not declaring the projection must be projected, found:", price, status)
+	}
+
+	list, err = store.EntityList(EntityQueryOptions{EntityType: "product", Where: map[string]string{"price": "3"}})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(list) != 1 {
+		t.Fatal("Must find the product written by the other store, found:", len(list))
+	}
+}
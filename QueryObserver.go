@@ -0,0 +1,120 @@
+package entitystore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// QueryEvent describes a query run by the store
+type QueryEvent struct {
+	Operation    string // the store operation running the query, i.e. EntityCreate
+	SQL          string
+	Args         []any
+	Duration     time.Duration
+	RowsAffected int64 // the rows affected by a statement, or returned by a select
+	Err          error
+	Slow         bool // true if the query took longer than the SlowQueryThreshold
+}
+
+// QueryObserver is notified of every query run by the store,
+// i.e. to collect metrics or traces
+type QueryObserver interface {
+	ObserveQuery(ctx context.Context, event QueryEvent)
+}
+
+// QueryObserverFunc adapts a function to the QueryObserver interface
+type QueryObserverFunc func(ctx context.Context, event QueryEvent)
+
+func (f QueryObserverFunc) ObserveQuery(ctx context.Context, event QueryEvent) {
+	f(ctx, event)
+}
+
+// exec runs a statement through the database (and the transaction
+// in progress, if any), notifying the observer and the logger
+func (st *storeImplementation) exec(operation string, sqlStr string, args ...any) (sql.Result, error) {
+	start := time.Now()
+
+	result, err := st.database.Exec(sqlStr, args...)
+
+	rowsAffected := int64(0)
+
+	if err == nil && result != nil {
+		rowsAffected, _ = result.RowsAffected()
+	}
+
+	st.queryObserve(operation, sqlStr, args, start, rowsAffected, err)
+
+	if isDuplicateError(err) {
+		return result, fmt.Errorf("%w: %w", ErrDuplicate, err)
+	}
+
+	return result, err
+}
+
+// queryObserve notifies the observer of a finished query, and logs it.
+// The queries are logged at debug level (info level with DebugEnabled),
+// the slow ones at warn level, and the failed ones at error level
+func (st *storeImplementation) queryObserve(operation string, sqlStr string, args []any, start time.Time, rowsAffected int64, err error) {
+	event := QueryEvent{
+		Operation:    operation,
+		SQL:          sqlStr,
+		Args:         args,
+		Duration:     time.Since(start),
+		RowsAffected: rowsAffected,
+		Err:          err,
+	}
+
+	event.Slow = st.slowQueryThreshold > 0 && event.Duration >= st.slowQueryThreshold
+
+	if st.queryObserver != nil {
+		st.queryObserver.ObserveQuery(st.context(), event)
+	}
+
+	logger := st.log()
+
+	if logger == nil {
+		return
+	}
+
+	attrs := []any{
+		slog.String("operation", event.Operation),
+		slog.String("sql", event.SQL),
+		slog.Duration("duration", event.Duration),
+		slog.Int64("rows", event.RowsAffected),
+	}
+
+	switch {
+	case err != nil:
+		logger.ErrorContext(st.context(), "entity store query failed", append(attrs, slog.Any("error", err))...)
+	case event.Slow:
+		logger.WarnContext(st.context(), "entity store slow query", attrs...)
+	case st.debugEnabled:
+		logger.InfoContext(st.context(), "entity store query", attrs...)
+	default:
+		logger.DebugContext(st.context(), "entity store query", attrs...)
+	}
+}
+
+// log returns the logger of the store, the default logger if only the
+// debug is enabled, or nil if logging is off
+func (st *storeImplementation) log() *slog.Logger {
+	if st.logger != nil {
+		return st.logger
+	}
+
+	if st.debugEnabled {
+		return slog.Default()
+	}
+
+	return nil
+}
+
+// logError logs an error which is not returned to the caller
+func (st *storeImplementation) logError(message string, err error) {
+	if logger := st.log(); logger != nil {
+		logger.ErrorContext(st.context(), "entity store "+message, slog.Any("error", err))
+	}
+}
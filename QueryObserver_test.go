@@ -0,0 +1,91 @@
+package entitystore
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestQueryObserver(t *testing.T) {
+	db := InitDB("test_query_observer.db")
+
+	var mu sync.Mutex
+	events := []QueryEvent{}
+
+	logs := &bytes.Buffer{}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+		Logger:             slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		QueryObserver: QueryObserverFunc(func(ctx context.Context, event QueryEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}),
+		SlowQueryThreshold: time.Hour,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.EntityCreateWithType("post")
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	_, err = store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	mu.Lock()
+	operations := map[string]QueryEvent{}
+	for _, event := range events {
+		operations[event.Operation] = event
+	}
+	mu.Unlock()
+
+	create, exists := operations["EntityCreate"]
+
+	if !exists {
+		t.Fatal("EntityCreate query must be observed")
+	}
+
+	if !strings.Contains(create.SQL, "INSERT") || create.RowsAffected != 1 || create.Err != nil || create.Slow {
+		t.Fatal("EntityCreate event mismatch:", create)
+	}
+
+	if list, exists := operations["EntityList"]; !exists || list.RowsAffected != 1 {
+		t.Fatal("EntityList query must be observed, returning 1 row")
+	}
+
+	if logs.Len() > 0 {
+		t.Fatal("Nothing must be logged at warn level, found:", logs.String())
+	}
+
+	_, err = db.Exec(`DROP TABLE cms_entity`)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	_, err = store.EntityList(EntityQueryOptions{})
+
+	if err == nil {
+		t.Fatal("Listing the entities of a dropped table must fail")
+	}
+
+	if !strings.Contains(logs.String(), "entity store query failed") {
+		t.Fatal("Failed query must be logged, found:", logs.String())
+	}
+}
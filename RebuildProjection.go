@@ -0,0 +1,99 @@
+package entitystore
+
+import (
+	"errors"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// projectionRebuildBatchSize the number of entities written per transaction by RebuildProjection
+const projectionRebuildBatchSize = 500
+
+// RebuildProjection fills the projection table of the entity type again from
+// the attributes, a batch of entities per transaction, and removes the rows of
+// the entities which no longer exist. Once done the projection is recorded as
+// built, and EntityList uses it. Returns the number of entities written
+func (st *storeImplementation) RebuildProjection(entityType string) (count int64, err error) {
+	defer wrapOpError(&err, "RebuildProjection", "")
+
+	if entityType == "" {
+		return 0, errInvalidArgument("entity type cannot be empty")
+	}
+
+	projection := st.projectionFor(entityType)
+
+	if projection == nil {
+		return 0, errInvalidArgument("entity type " + entityType + " has no projection")
+	}
+
+	if st.tenantScoped {
+		return 0, errors.New("the projections span all tenants, they cannot be rebuilt in a tenant view")
+	}
+
+	entityIDsQuery := goqu.Dialect(st.dbDriverName).
+		From(st.entityTableName).
+		Where(goqu.C(COLUMN_ENTITY_TYPE).Eq(entityType)).
+		Select(goqu.C(COLUMN_ID))
+
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+		From(projection.TableName).
+		Where(goqu.C(COLUMN_ENTITY_ID).NotIn(entityIDsQuery)).
+		Delete().
+		ToSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := st.exec("RebuildProjection", sqlStr); err != nil {
+		return 0, err
+	}
+
+	lastID := ""
+
+	for {
+		q := entityIDsQuery.Order(goqu.C(COLUMN_ID).Asc()).Limit(projectionRebuildBatchSize)
+
+		if lastID != "" {
+			q = q.Where(goqu.C(COLUMN_ID).Gt(lastID))
+		}
+
+		sqlStr, _, err := q.ToSQL()
+
+		if err != nil {
+			return count, err
+		}
+
+		entityIDs := []string{}
+
+		err = st.inTransaction(func(tx *storeImplementation) error {
+			rows, err := tx.selectToMapString("RebuildProjection", sqlStr)
+
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				entityIDs = append(entityIDs, row[COLUMN_ID])
+			}
+
+			if len(entityIDs) < 1 {
+				return nil
+			}
+
+			return tx.projectionWrite(*projection, entityIDs)
+		})
+
+		if err != nil {
+			return count, err
+		}
+
+		count += int64(len(entityIDs))
+
+		if len(entityIDs) < projectionRebuildBatchSize {
+			return count, st.projectionBuiltSave(*projection)
+		}
+
+		lastID = entityIDs[len(entityIDs)-1]
+	}
+}
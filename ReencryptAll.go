@@ -0,0 +1,191 @@
+package entitystore
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// reencryptBatchSize is the number of rows re-encrypted in one transaction
+const reencryptBatchSize = 100
+
+// ReencryptAll seals again, with the current key of the cipher, the values of
+// the encrypted attribute keys sealed with an older key or not sealed at all.
+// The attributes, the trashed attributes and the attribute history are
+// processed in batches. Returns the number of values re-encrypted
+func (st *storeImplementation) ReencryptAll() (count int64, err error) {
+	defer wrapOpError(&err, "ReencryptAll", "")
+
+	if st.attributeCipher == nil {
+		return 0, errNotEnabled("attribute encryption")
+	}
+
+	if st.tenantScoped {
+		return 0, errors.New("re-encryption spans all tenants, it is not available in a tenant view")
+	}
+
+	tables := map[string][]string{
+		st.attributeTableName:      {COLUMN_ATTRIBUTE_VALUE},
+		st.attributeTrashTableName: {COLUMN_ATTRIBUTE_VALUE},
+	}
+
+	if st.attributeHistoryTableName != "" {
+		tables[st.attributeHistoryTableName] = []string{COLUMN_OLD_VALUE, COLUMN_NEW_VALUE}
+	}
+
+	total := int64(0)
+
+	// the cached attributes may hold values sealed with the retired keys
+	defer st.cacheClear()
+
+	for tableName, valueColumns := range tables {
+		count, err := st.reencryptTable(tableName, valueColumns)
+
+		total += count
+
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// reencryptTable re-encrypts the value columns of a table, batch by batch
+func (st *storeImplementation) reencryptTable(tableName string, valueColumns []string) (int64, error) {
+	total := int64(0)
+	cursor := ""
+
+	columns := []any{goqu.C(COLUMN_ID), goqu.C(COLUMN_ATTRIBUTE_KEY)}
+
+	for _, column := range valueColumns {
+		columns = append(columns, goqu.C(column))
+	}
+
+	for {
+		q := goqu.Dialect(st.dbDriverName).
+			From(tableName).
+			Select(columns...).
+			Where(goqu.C(COLUMN_ID).Gt(cursor)).
+			Order(goqu.C(COLUMN_ID).Asc()).
+			Limit(reencryptBatchSize)
+
+		if st.encryptAttributeKey == nil {
+			q = q.Where(goqu.C(COLUMN_ATTRIBUTE_KEY).In(st.encryptedAttributeKeys))
+		}
+
+		sqlStr, _, errSql := q.ToSQL()
+
+		if errSql != nil {
+			return total, errSql
+		}
+
+		rows, err := st.selectToMapString("reencryptTable", sqlStr)
+
+		if err != nil {
+			return total, err
+		}
+
+		if len(rows) < 1 {
+			return total, nil
+		}
+
+		count := int64(0)
+
+		err = st.inTransaction(func(tx *storeImplementation) error {
+			for _, row := range rows {
+				if !tx.attributeEncrypted(row[COLUMN_ATTRIBUTE_KEY]) {
+					continue
+				}
+
+				record := goqu.Record{}
+
+				for _, column := range valueColumns {
+					resealed, changed, err := tx.attributeValueReseal(row[COLUMN_ATTRIBUTE_KEY], row[column])
+
+					if err != nil {
+						return errors.New("re-encryption of " + tableName + " " + row[COLUMN_ID] + " failed: " + err.Error())
+					}
+
+					if changed {
+						record[column] = resealed
+					}
+				}
+
+				if len(record) < 1 {
+					continue
+				}
+
+				sqlStr, _, errSql := goqu.Dialect(tx.dbDriverName).
+					Update(tableName).
+					Set(record).
+					Where(goqu.C(COLUMN_ID).Eq(row[COLUMN_ID])).
+					ToSQL()
+
+				if errSql != nil {
+					return errSql
+				}
+
+				if _, err := tx.exec("reencryptTable", sqlStr); err != nil {
+					return err
+				}
+
+				count += int64(len(record))
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return total, err
+		}
+
+		total += count
+		cursor = rows[len(rows)-1][COLUMN_ID]
+	}
+}
+
+// attributeValueReseal seals a value with the current key, returns
+// false if it is already sealed with the current key. The offloaded
+// values are resealed in the blob store, under a new reference
+func (st *storeImplementation) attributeValueReseal(attributeKey string, value string) (string, bool, error) {
+	if value == "" {
+		return value, false, nil
+	}
+
+	isOffloaded := st.blobStore != nil && strings.HasPrefix(value, blobRefPrefix)
+
+	stored, err := st.blobResolve(value)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if st.attributeCipher.KeyID(stored) == st.attributeCipher.CurrentKeyID() {
+		return value, false, nil
+	}
+
+	// still compressed, if it was
+	opened, err := st.attributeValueOpen(attributeKey, stored)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	sealed, err := st.attributeCipher.Seal(opened)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	if isOffloaded {
+		sealed, err = st.blobOffload(sealed)
+
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	return sealed, true, nil
+}
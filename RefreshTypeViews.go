@@ -0,0 +1,34 @@
+package entitystore
+
+import "sort"
+
+// RefreshTypeViews recreates the views created by CreateTypeView
+// without attribute keys, adding the columns of the keys used since.
+// The views are kept in a table, so they are known after a restart
+func (st *storeImplementation) RefreshTypeViews() (err error) {
+	defer wrapOpError(&err, "RefreshTypeViews", "")
+
+	views, err := st.typeViewList()
+
+	if err != nil {
+		return err
+	}
+
+	viewNames := []string{}
+
+	for viewName, view := range views {
+		if view.attributeKeys == nil {
+			viewNames = append(viewNames, viewName)
+		}
+	}
+
+	sort.Strings(viewNames)
+
+	for _, viewName := range viewNames {
+		if err := st.typeViewCreate(viewName, views[viewName]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
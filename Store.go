@@ -1,8 +1,12 @@
 package entitystore
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/gouniverse/sb"
 )
@@ -13,18 +17,81 @@ type storeImplementation struct {
 	attributeTableName      string
 	entityTrashTableName    string
 	attributeTrashTableName string
-	db                      *sql.DB
-	database                sb.DatabaseInterface
-	dbDriverName            string
-	automigrateEnabled      bool
-	debugEnabled            bool
+	// attributeHistoryTableName is optional, history is not recorded if empty
+	attributeHistoryTableName string
+	// outboxTableName is optional, changes are not recorded if empty
+	outboxTableName string
+	// linkTableName is optional, entity links are not available if empty
+	linkTableName         string
+	db                    *sql.DB
+	database              sb.DatabaseInterface
+	dbDriverName          string
+	automigrateEnabled    bool
+	debugEnabled          bool
+	hierarchyEnabled      bool
+	hierarchyTrashCascade bool
+	tenancyEnabled        bool
+
+	// tenantScoped and tenantID scope all operations to a single tenant, see ForTenant
+	tenantScoped bool
+	tenantID     string
+
+	// attributeCipher seals the values of the encrypted attribute keys,
+	// matched by encryptAttributeKey or listed in encryptedAttributeKeys
+	attributeCipher        AttributeCipher
+	encryptedAttributeKeys []string
+	encryptAttributeKey    func(attributeKey string) bool
+
+	// valueCompression compresses the values longer than valueCompressionThreshold,
+	// blobStore keeps the values longer than blobThreshold, see attributeEncode
+	valueCompression          string
+	valueCompressionThreshold int
+	blobStore                 BlobStore
+	blobThreshold             int
+
+	// cache is optional, shared by all views of the store
+	cache *storeCache
+
+	// logger and queryObserver are optional, see queryObserve
+	logger             *slog.Logger
+	queryObserver      QueryObserver
+	slowQueryThreshold time.Duration
+
+	// actor is recorded as the author of changes, see WithActor
+	actor string
+
+	// ctx is passed to the hooks, see WithContext
+	ctx context.Context
+
+	// hooks are shared by all views of the store
+	hooks *storeHooks
+
+	// subscriptions are shared by all views of the store
+	subscriptions *storeSubscriptions
+
+	// projections are optional, see Projection
+	projections []Projection
+
+	// projectionsBuilt are the table names of the projections found built,
+	// shared by all views of the store, see projectionBuiltFor
+	projectionsBuilt *sync.Map
+
+	// changeEvents queues the change events of the transaction
+	// running on this view, see inTransaction
+	changeEvents *[]ChangeEvent
+
+	// cacheInvalidated queues the cache keys invalidated during
+	// the transaction running on this view, see inTransaction
+	cacheInvalidated *[]string
 }
 
 // StoreOption options for the vault store
 type StoreOption func(*storeImplementation)
 
 // AutoMigrate auto migrate
-func (st *storeImplementation) AutoMigrate() error {
+func (st *storeImplementation) AutoMigrate() (err error) {
+	defer wrapOpError(&err, "AutoMigrate", "")
+
 	sqlArray, err := st.SqlCreateTable()
 
 	if err != nil {
@@ -32,15 +99,87 @@ func (st *storeImplementation) AutoMigrate() error {
 	}
 
 	for _, sql := range sqlArray {
-		_, err := st.database.Exec(sql)
+		_, err := st.exec("AutoMigrate", sql)
 		if err != nil {
-			return nil
+			return err
+		}
+	}
+
+	if err := st.columnsMigrate(); err != nil {
+		return err
+	}
+
+	return st.outboxSequenceMigrate()
+}
+
+// columnsMigrate adds the columns introduced after the tables
+// of an existing store were created
+func (st *storeImplementation) columnsMigrate() error {
+	type column struct {
+		table      string
+		name       string
+		definition string
+		enabled    bool
+	}
+
+	versionType := "bigint"
+	if st.dbDriverName == "sqlite" {
+		versionType = "integer"
+	}
+
+	columns := []column{
+		{st.entityTableName, COLUMN_VERSION, versionType + " NOT NULL DEFAULT 1", true},
+		{st.entityTrashTableName, COLUMN_VERSION, versionType + " NOT NULL DEFAULT 1", true},
+		{st.entityTableName, COLUMN_PARENT_ID, "varchar(40) DEFAULT ''", st.hierarchyEnabled},
+		{st.entityTrashTableName, COLUMN_PARENT_ID, "varchar(40) DEFAULT ''", st.hierarchyEnabled},
+		{st.entityTableName, COLUMN_TENANT_ID, "varchar(40) DEFAULT ''", st.tenancyEnabled},
+		{st.entityTrashTableName, COLUMN_TENANT_ID, "varchar(40) DEFAULT ''", st.tenancyEnabled},
+		{st.outboxTableName, COLUMN_SEQUENCE, versionType + " NOT NULL DEFAULT 0", st.outboxTableName != ""},
+	}
+
+	// the columns of the keys added to a projection since its table was created
+	for _, projection := range st.projections {
+		for _, key := range projectionKeys(projection) {
+			columns = append(columns, column{projection.TableName, key, st.projectionColumnType(projection.Columns[key]), true})
+		}
+	}
+
+	for _, column := range columns {
+		if !column.enabled {
+			continue
+		}
+
+		table := st.quoteIdentifier(column.table)
+		name := st.quoteIdentifier(column.name)
+
+		// the select fails if the column does not exist, the column name
+		// is qualified as sqlite treats an unknown bare quoted identifier
+		// as a string literal. Not observed, as failing is expected
+		_, err := st.database.Exec("SELECT " + table + "." + name + " FROM " + table + " WHERE 1 = 0")
+
+		if err == nil {
+			continue
+		}
+
+		_, err = st.exec("AutoMigrate", "ALTER TABLE "+table+" ADD COLUMN "+name+" "+column.definition)
+
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// quoteIdentifier quotes a table or column name for the database dialect
+func (st *storeImplementation) quoteIdentifier(name string) string {
+	if st.dbDriverName == "mysql" {
+		return "`" + name + "`"
+	}
+
+	return `"` + name + `"`
+}
+
 // EnableDebug - enables the debug option
 func (st *storeImplementation) EnableDebug(debug bool) {
 	st.debugEnabled = debug
@@ -50,6 +189,10 @@ func (st *storeImplementation) GetAttributeTableName() string {
 	return st.attributeTableName
 }
 
+func (st *storeImplementation) GetAttributeHistoryTableName() string {
+	return st.attributeHistoryTableName
+}
+
 func (st *storeImplementation) GetAttributeTrashTableName() string {
 	return st.attributeTrashTableName
 }
@@ -70,13 +213,38 @@ func (st *storeImplementation) GetEntityTrashTableName() string {
 	return st.entityTrashTableName
 }
 
+func (st *storeImplementation) GetLinkTableName() string {
+	return st.linkTableName
+}
+
+func (st *storeImplementation) GetOutboxTableName() string {
+	return st.outboxTableName
+}
+
 func (st *storeImplementation) SqlCreateTable() ([]string, error) {
+	parentColumnMysql := ""
+	parentColumnQuoted := ""
+
+	if st.hierarchyEnabled {
+		parentColumnMysql = "parent_id varchar(40) DEFAULT '',"
+		parentColumnQuoted = `"parent_id" varchar(40) DEFAULT '',`
+	}
+
+	tenantColumnMysql := ""
+	tenantColumnQuoted := ""
+
+	if st.tenancyEnabled {
+		tenantColumnMysql = "tenant_id varchar(40) DEFAULT '',"
+		tenantColumnQuoted = `"tenant_id" varchar(40) DEFAULT '',`
+	}
 
 	sqlMysql1 := `
 	CREATE TABLE IF NOT EXISTS ` + st.entityTableName + ` (
 		id varchar(40) NOT NULL PRIMARY KEY,
 		entity_type varchar(40) NOT NULL,
 		entity_handle varchar(60) DEFAULT '',
+		` + tenantColumnMysql + parentColumnMysql + `
+		version bigint NOT NULL DEFAULT 1,
 		created_at datetime NOT NULL,
 		updated_at datetime NOT NULL
 	 );
@@ -98,6 +266,8 @@ func (st *storeImplementation) SqlCreateTable() ([]string, error) {
 		id varchar(40) NOT NULL PRIMARY KEY,
 		entity_type varchar(40) NOT NULL,
 		entity_handle varchar(60) DEFAULT '',
+		` + tenantColumnMysql + parentColumnMysql + `
+		version bigint NOT NULL DEFAULT 1,
 		created_at datetime NOT NULL,
 		updated_at datetime NOT NULL,
 		deleted_at datetime NOT NULL,
@@ -134,6 +304,8 @@ func (st *storeImplementation) SqlCreateTable() ([]string, error) {
 	   "id" varchar(40) NOT NULL PRIMARY KEY,
 	   "entity_type" varchar(40) NOT NULL,
 	   "entity_handle" varchar(60) DEFAULT '',
+	   ` + tenantColumnQuoted + parentColumnQuoted + `
+	   "version" bigint NOT NULL DEFAULT 1,
 	   "created_at" timestamptz(6),
 	   "updated_at" timestamptz(6)
 	);
@@ -144,6 +316,8 @@ func (st *storeImplementation) SqlCreateTable() ([]string, error) {
 		"id" varchar(40) NOT NULL PRIMARY KEY,
 		"entity_type" varchar(40) NOT NULL,
 		"entity_handle" varchar(60) DEFAULT '',
+		` + tenantColumnQuoted + parentColumnQuoted + `
+		"version" bigint NOT NULL DEFAULT 1,
 		"created_at" timestamptz(6) NOT NULL,
 		"updated_at" timestamptz(6) NOT NULL,
 		"deleted_at" timestamptz(6) NOT NULL,
@@ -179,6 +353,8 @@ func (st *storeImplementation) SqlCreateTable() ([]string, error) {
 	   "id" varchar(40) NOT NULL PRIMARY KEY,
 	   "entity_type" varchar(40) NOT NULL,
 	   "entity_handle" varchar(60) DEFAULT '',
+	   ` + tenantColumnQuoted + parentColumnQuoted + `
+	   "version" integer NOT NULL DEFAULT 1,
 	   "created_at" datetime NOT NULL,
 	   "updated_at" datetime NOT NULL
 	);
@@ -189,6 +365,8 @@ func (st *storeImplementation) SqlCreateTable() ([]string, error) {
 		"id" varchar(40) NOT NULL PRIMARY KEY,
 		"entity_type" varchar(40) NOT NULL,
 		"entity_handle" varchar(60) DEFAULT '',
+		` + tenantColumnQuoted + parentColumnQuoted + `
+		"version" integer NOT NULL DEFAULT 1,
 		"created_at" datetime NOT NULL,
 		"updated_at" datetime NOT NULL,
 		"deleted_at" datetime NOT NULL,
@@ -230,5 +408,205 @@ func (st *storeImplementation) SqlCreateTable() ([]string, error) {
 		return nil, errors.New("unsupported driver " + st.dbDriverName)
 	}
 
+	if st.attributeHistoryTableName != "" {
+		sqls = append(sqls, st.sqlCreateAttributeHistoryTable())
+	}
+
+	if st.outboxTableName != "" {
+		sqls = append(sqls, st.sqlCreateOutboxTable())
+		sqls = append(sqls, st.sqlCreateOutboxSequenceTable()...)
+	}
+
+	if st.linkTableName != "" {
+		sqls = append(sqls, st.sqlCreateLinkTable())
+	}
+
+	sqls = append(sqls, st.sqlCreateTypeViewTable())
+
+	if len(st.projections) > 0 {
+		sqls = append(sqls, st.sqlCreateProjectionStateTable())
+	}
+
+	for _, projection := range st.projections {
+		sqls = append(sqls, st.sqlCreateProjectionTable(projection))
+	}
+
 	return sqls, nil
 }
+
+// sqlCreateAttributeHistoryTable returns the SQL to create the optional attribute history table
+func (st *storeImplementation) sqlCreateAttributeHistoryTable() string {
+	sqlMysql := `
+	CREATE TABLE IF NOT EXISTS ` + st.attributeHistoryTableName + ` (
+		id varchar(40) NOT NULL PRIMARY KEY,
+		entity_id varchar(40) NOT NULL,
+		attribute_key varchar(255) NOT NULL,
+		operation varchar(20) NOT NULL,
+		old_value text,
+		new_value text,
+		changed_at datetime NOT NULL,
+		changed_by varchar(255)
+	);
+	`
+
+	sqlPostgres := `
+	CREATE TABLE IF NOT EXISTS ` + st.attributeHistoryTableName + ` (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"entity_id" varchar(40) NOT NULL,
+		"attribute_key" varchar(255) NOT NULL,
+		"operation" varchar(20) NOT NULL,
+		"old_value" text,
+		"new_value" text,
+		"changed_at" timestamptz(6) NOT NULL,
+		"changed_by" varchar(255)
+	);
+	`
+
+	sqlSqlite := `
+	CREATE TABLE IF NOT EXISTS "` + st.attributeHistoryTableName + `" (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"entity_id" varchar(40) NOT NULL,
+		"attribute_key" varchar(255) NOT NULL,
+		"operation" varchar(20) NOT NULL,
+		"old_value" text,
+		"new_value" text,
+		"changed_at" datetime NOT NULL,
+		"changed_by" varchar(255)
+	);
+	`
+
+	if st.dbDriverName == "mysql" {
+		return sqlMysql
+	}
+
+	if st.dbDriverName == "postgres" {
+		return sqlPostgres
+	}
+
+	return sqlSqlite
+}
+
+// sqlCreateOutboxTable returns the SQL to create the optional outbox table
+func (st *storeImplementation) sqlCreateOutboxTable() string {
+	sqlMysql := `
+	CREATE TABLE IF NOT EXISTS ` + st.outboxTableName + ` (
+		id varchar(40) NOT NULL PRIMARY KEY,
+		operation varchar(40) NOT NULL,
+		entity_type varchar(40) NOT NULL,
+		entity_id varchar(40) NOT NULL,
+		changed_keys text,
+		payload longtext,
+		sequence bigint NOT NULL DEFAULT 0,
+		created_at datetime NOT NULL
+	);
+	`
+
+	sqlPostgres := `
+	CREATE TABLE IF NOT EXISTS ` + st.outboxTableName + ` (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"operation" varchar(40) NOT NULL,
+		"entity_type" varchar(40) NOT NULL,
+		"entity_id" varchar(40) NOT NULL,
+		"changed_keys" text,
+		"payload" text,
+		"sequence" bigint NOT NULL DEFAULT 0,
+		"created_at" timestamptz(6) NOT NULL
+	);
+	`
+
+	sqlSqlite := `
+	CREATE TABLE IF NOT EXISTS "` + st.outboxTableName + `" (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"operation" varchar(40) NOT NULL,
+		"entity_type" varchar(40) NOT NULL,
+		"entity_id" varchar(40) NOT NULL,
+		"changed_keys" text,
+		"payload" text,
+		"sequence" integer NOT NULL DEFAULT 0,
+		"created_at" datetime NOT NULL
+	);
+	`
+
+	if st.dbDriverName == "mysql" {
+		return sqlMysql
+	}
+
+	if st.dbDriverName == "postgres" {
+		return sqlPostgres
+	}
+
+	return sqlSqlite
+}
+
+// sqlCreateOutboxSequenceTable returns the SQL to create the table numbering
+// the changes of the outbox, with its single row
+func (st *storeImplementation) sqlCreateOutboxSequenceTable() []string {
+	table := st.quoteIdentifier(st.outboxSequenceTableName())
+	id := st.quoteIdentifier(COLUMN_ID)
+	sequence := st.quoteIdentifier(COLUMN_SEQUENCE)
+
+	sqlCreate := "CREATE TABLE IF NOT EXISTS " + table + " (" + id + " varchar(40) NOT NULL PRIMARY KEY, " + sequence + " bigint NOT NULL);"
+	row := " INTO " + table + " (" + id + ", " + sequence + ") VALUES ('" + outboxSequenceRowID + "', 0)"
+
+	if st.dbDriverName == "mysql" {
+		return []string{sqlCreate, "INSERT IGNORE" + row + ";"}
+	}
+
+	if st.dbDriverName == "postgres" {
+		return []string{sqlCreate, "INSERT" + row + " ON CONFLICT DO NOTHING;"}
+	}
+
+	return []string{sqlCreate, "INSERT OR IGNORE" + row + ";"}
+}
+
+// sqlCreateLinkTable returns the SQL to create the optional entity link table
+func (st *storeImplementation) sqlCreateLinkTable() string {
+	sqlMysql := `
+	CREATE TABLE IF NOT EXISTS ` + st.linkTableName + ` (
+		id varchar(40) NOT NULL PRIMARY KEY,
+		from_id varchar(40) NOT NULL,
+		to_id varchar(40) NOT NULL,
+		relation varchar(60) NOT NULL,
+		position bigint NOT NULL DEFAULT 0,
+		metadata text,
+		created_at datetime NOT NULL,
+		updated_at datetime NOT NULL
+	);
+	`
+
+	sqlPostgres := `
+	CREATE TABLE IF NOT EXISTS ` + st.linkTableName + ` (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"from_id" varchar(40) NOT NULL,
+		"to_id" varchar(40) NOT NULL,
+		"relation" varchar(60) NOT NULL,
+		"position" bigint NOT NULL DEFAULT 0,
+		"metadata" text,
+		"created_at" timestamptz(6) NOT NULL,
+		"updated_at" timestamptz(6) NOT NULL
+	);
+	`
+
+	sqlSqlite := `
+	CREATE TABLE IF NOT EXISTS "` + st.linkTableName + `" (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"from_id" varchar(40) NOT NULL,
+		"to_id" varchar(40) NOT NULL,
+		"relation" varchar(60) NOT NULL,
+		"position" integer NOT NULL DEFAULT 0,
+		"metadata" text,
+		"created_at" datetime NOT NULL,
+		"updated_at" datetime NOT NULL
+	);
+	`
+
+	if st.dbDriverName == "mysql" {
+		return sqlMysql
+	}
+
+	if st.dbDriverName == "postgres" {
+		return sqlPostgres
+	}
+
+	return sqlSqlite
+}
@@ -0,0 +1,224 @@
+package entitystore
+
+import (
+	"sync"
+	"time"
+)
+
+const SUBSCRIPTION_POLICY_BLOCK = "block"
+const SUBSCRIPTION_POLICY_DROP = "drop"
+
+const subscriptionBufferSizeDefault = 100
+
+// ChangeEvent is emitted to the subscribers after a change is committed
+type ChangeEvent struct {
+	Operation   string // one of the CHANGE_* constants
+	EntityType  string
+	EntityID    string
+	TenantID    string // empty unless the tenancy is enabled
+	ChangedKeys []string
+	OccurredAt  time.Time
+}
+
+// ChangeFilter selects the events delivered to a subscriber.
+// Empty fields match everything
+type ChangeFilter struct {
+	EntityTypes   []string
+	AttributeKeys []string
+	Operations    []string
+}
+
+// Matches returns true if the event passes the filter
+func (f ChangeFilter) Matches(event ChangeEvent) bool {
+	if len(f.EntityTypes) > 0 && !contains(f.EntityTypes, event.EntityType) {
+		return false
+	}
+
+	if len(f.Operations) > 0 && !contains(f.Operations, event.Operation) {
+		return false
+	}
+
+	if len(f.AttributeKeys) > 0 {
+		for _, key := range event.ChangedKeys {
+			if contains(f.AttributeKeys, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+type subscription struct {
+	filter ChangeFilter
+	events chan ChangeEvent
+	done   chan struct{}
+
+	// mu guards the sends to events against its closing by cancel
+	mu     sync.Mutex
+	closed bool
+
+	// tenantScoped subscriptions receive only the events of their tenant
+	tenantScoped bool
+	tenantID     string
+}
+
+// storeSubscriptions holds the subscribers, shared by all views of a store
+type storeSubscriptions struct {
+	mu          sync.RWMutex
+	subscribers map[*subscription]bool
+	bufferSize  int
+	policy      string
+}
+
+func newStoreSubscriptions(bufferSize int, policy string) *storeSubscriptions {
+	if bufferSize < 1 {
+		bufferSize = subscriptionBufferSizeDefault
+	}
+
+	if policy == "" {
+		policy = SUBSCRIPTION_POLICY_DROP
+	}
+
+	return &storeSubscriptions{
+		subscribers: map[*subscription]bool{},
+		bufferSize:  bufferSize,
+		policy:      policy,
+	}
+}
+
+// Subscribe returns a channel receiving the committed changes matching
+// the filter, and a function to cancel the subscription. When the buffer
+// of the channel is full events are dropped, or the writer is blocked,
+// according to the SubscriptionPolicy of the store. A subscription made
+// through a tenant view receives only the events of the tenant
+func (st *storeImplementation) Subscribe(filter ChangeFilter) (<-chan ChangeEvent, func()) {
+	sub := &subscription{
+		filter: filter,
+		events: make(chan ChangeEvent, st.subscriptions.bufferSize),
+		done:   make(chan struct{}),
+
+		tenantScoped: st.tenantScoped,
+		tenantID:     st.tenantID,
+	}
+
+	st.subscriptions.mu.Lock()
+	st.subscriptions.subscribers[sub] = true
+	st.subscriptions.mu.Unlock()
+
+	var once sync.Once
+
+	cancel := func() {
+		once.Do(func() {
+			// unblocks a publisher waiting on a full buffer
+			close(sub.done)
+
+			st.subscriptions.mu.Lock()
+			delete(st.subscriptions.subscribers, sub)
+			st.subscriptions.mu.Unlock()
+
+			sub.mu.Lock()
+			sub.closed = true
+			close(sub.events)
+			sub.mu.Unlock()
+		})
+	}
+
+	return sub.events, cancel
+}
+
+// hasSubscribers returns true if anybody listens for changes
+func (st *storeImplementation) hasSubscribers() bool {
+	if st.subscriptions == nil {
+		return false
+	}
+
+	st.subscriptions.mu.RLock()
+	defer st.subscriptions.mu.RUnlock()
+
+	return len(st.subscriptions.subscribers) > 0
+}
+
+// changeEventQueue queues an event until the transaction is committed.
+// Within a transaction started outside of the store, the event is
+// published right away
+func (st *storeImplementation) changeEventQueue(event ChangeEvent) {
+	if st.changeEvents == nil {
+		st.changeEventsPublish([]ChangeEvent{event})
+		return
+	}
+
+	*st.changeEvents = append(*st.changeEvents, event)
+}
+
+// changeEventsPublish delivers the queued events of a committed transaction
+func (st *storeImplementation) changeEventsPublish(events []ChangeEvent) {
+	if st.subscriptions == nil {
+		return
+	}
+
+	if len(events) < 1 {
+		return
+	}
+
+	// the subscribers are copied, so the sends blocked on a full
+	// buffer do not hold the lock wanted by Subscribe and cancel
+	st.subscriptions.mu.RLock()
+
+	subscribers := make([]*subscription, 0, len(st.subscriptions.subscribers))
+
+	for sub := range st.subscriptions.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+
+	st.subscriptions.mu.RUnlock()
+
+	for _, event := range events {
+		for _, sub := range subscribers {
+			if !sub.filter.Matches(event) {
+				continue
+			}
+
+			if sub.tenantScoped && sub.tenantID != event.TenantID {
+				continue
+			}
+
+			sub.send(event, st.subscriptions.policy)
+		}
+	}
+}
+
+// send delivers the event, unless the subscription was cancelled
+func (sub *subscription) send(event ChangeEvent, policy string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+
+	if policy == SUBSCRIPTION_POLICY_BLOCK {
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+		}
+		return
+	}
+
+	select {
+	case sub.events <- event:
+	case <-sub.done:
+	default:
+		// buffer is full, the event is dropped
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package entitystore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	db := InitDB("test_subscribe.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	events, cancel := store.Subscribe(ChangeFilter{
+		EntityTypes:   []string{"post"},
+		AttributeKeys: []string{"title"},
+	})
+
+	defer cancel()
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{
+		"title": "Hello world",
+		"text":  "Lorem ipsum",
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	_, err = store.EntityCreateWithTypeAndAttributes("page", map[string]string{
+		"title": "Page title",
+	})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	store.BeforeAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		if attribute.AttributeValue() == "vetoed" {
+			return errors.New("vetoed")
+		}
+		return nil
+	})
+
+	err = entity.SetString("title", "vetoed")
+
+	if err == nil {
+		t.Fatal("Attribute set must be vetoed")
+	}
+
+	select {
+	case event := <-events:
+		if event.Operation != CHANGE_ATTRIBUTE_SET || event.EntityID != entity.ID() || event.ChangedKeys[0] != "title" {
+			t.Fatal("Event mismatch:", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Event must be received")
+	}
+
+	select {
+	case event := <-events:
+		t.Fatal("No more events must be received, found: ", event)
+	default:
+	}
+}
+
+func TestSubscribeConcurrentTransactions(t *testing.T) {
+	_ = InitDB("test_subscribe_concurrent.db").Close()
+
+	// the writers wait for each other, instead of failing to upgrade their locks
+	db, err := sql.Open("sqlite3", "test_subscribe_concurrent.db?_txlock=immediate")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store.BeforeAttributeSet(func(ctx context.Context, attribute *Attribute) error {
+		if attribute.AttributeValue() == "vetoed" {
+			return errors.New("vetoed")
+		}
+		return nil
+	})
+
+	workers := 10
+
+	events, cancel := store.Subscribe(ChangeFilter{Operations: []string{CHANGE_ATTRIBUTE_SET}})
+
+	defer cancel()
+
+	wg := sync.WaitGroup{}
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			// the odd workers are rolled back by the hook
+			title := "Title " + strconv.Itoa(worker)
+
+			if worker%2 == 1 {
+				title = "vetoed"
+			}
+
+			_, _ = store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": title})
+		}(worker)
+	}
+
+	wg.Wait()
+
+	received := 0
+
+	for {
+		select {
+		case event := <-events:
+			if event.Operation != CHANGE_ATTRIBUTE_SET {
+				t.Fatal("Event mismatch:", event)
+			}
+			received++
+			continue
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		break
+	}
+
+	if received != workers/2 {
+		t.Fatal("Events must be received for the committed transactions only, found: ", received)
+	}
+}
+
+func TestSubscribeBlockedPublisher(t *testing.T) {
+	store, err := NewStore(NewStoreOptions{
+		DB:                     InitDB("test_subscribe_blocked.db"),
+		EntityTableName:        "cms_entity",
+		AttributeTableName:     "cms_attribute",
+		AutomigrateEnabled:     true,
+		SubscriptionBufferSize: 1,
+		SubscriptionPolicy:     SUBSCRIPTION_POLICY_BLOCK,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	events, cancel := store.Subscribe(ChangeFilter{})
+	defer cancel()
+
+	written := make(chan error)
+
+	// the second change waits for the full buffer
+	go func() {
+		_, err := store.EntityCreateWithType("post")
+
+		if err == nil {
+			_, err = store.EntityCreateWithType("post")
+		}
+
+		written <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	subscribed := make(chan bool)
+
+	go func() {
+		_, cancel := store.Subscribe(ChangeFilter{})
+		cancel()
+		subscribed <- true
+	}()
+
+	select {
+	case <-subscribed:
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe must not wait for a blocked publisher")
+	}
+
+	<-events
+	<-events
+
+	if err := <-written; err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+}
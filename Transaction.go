@@ -0,0 +1,17 @@
+package entitystore
+
+// Transaction runs fn in a transaction. The operations of the store passed
+// to fn are committed together, or rolled back if fn returns an error or panics.
+// Within a transaction in progress fn joins it. The store passed to fn must
+// not be used after fn returns
+func (st *storeImplementation) Transaction(fn func(tx StoreInterface) error) (err error) {
+	defer wrapOpError(&err, "Transaction", "")
+
+	if fn == nil {
+		return errInvalidArgument("transaction function cannot be nil")
+	}
+
+	return st.inTransaction(func(tx *storeImplementation) error {
+		return fn(tx)
+	})
+}
@@ -0,0 +1,264 @@
+package entitystore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const VALUE_COMPRESSION_GZIP = "gzip"
+const VALUE_COMPRESSION_ZSTD = "zstd"
+
+// valueCompressionThresholdDefault values longer than it are compressed
+const valueCompressionThresholdDefault = 1024
+
+// blobThresholdDefault values longer than it are offloaded,
+// well below the 64KB limit of the MySQL text column
+const blobThresholdDefault = 32 * 1024
+
+// the prefixes marking the encoded attribute values, the compressed
+// values are followed by their base64, the blob references by the blob key
+const compressedGzipPrefix = "gzip:"
+const compressedZstdPrefix = "zstd:"
+const blobRefPrefix = "blob:"
+
+// escapedValuePrefix marks the plain values starting with one of the
+// prefixes above, or with itself, kept by a store which encodes values
+const escapedValuePrefix = "raw:"
+
+// attributeEncode returns a copy of the attribute with the value prepared
+// for storage: escaped, compressed, encrypted if its key is encrypted, and
+// offloaded to the blob store, according to the options of the store. The
+// attribute itself is left as is, so a failed write can be retried with it
+func (st *storeImplementation) attributeEncode(attr Attribute) (Attribute, error) {
+	value, err := st.valueCompress(st.valueEscape(attr.AttributeValue()))
+
+	if err != nil {
+		return attr, err
+	}
+
+	if st.attributeEncrypted(attr.AttributeKey()) {
+		value, err = st.attributeCipher.Seal(value)
+
+		if err != nil {
+			return attr, err
+		}
+	}
+
+	value, err = st.blobOffload(value)
+
+	if err != nil {
+		return attr, err
+	}
+
+	attr.SetAttributeValue(value)
+
+	return attr, nil
+}
+
+// attributeValueDecode returns the original of a value encoded
+// by attributeEncode for the attribute key
+func (st *storeImplementation) attributeValueDecode(attributeKey string, value string) (string, error) {
+	if st == nil {
+		return value, nil
+	}
+
+	value, err := st.blobResolve(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	value, err = st.attributeValueOpen(attributeKey, value)
+
+	if err != nil {
+		return "", err
+	}
+
+	value, err = st.valueDecompress(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return st.valueUnescape(value), nil
+}
+
+// valueEncodes returns true if the store compresses or offloads values
+func (st *storeImplementation) valueEncodes() bool {
+	return st.valueCompression != "" || st.blobStore != nil
+}
+
+// valueEscape escapes a plain value which could be taken for an encoded
+// one, when the store encodes values. The attribute values searched for
+// are escaped too, to match the values as they are kept
+func (st *storeImplementation) valueEscape(value string) string {
+	if !st.valueEncodes() {
+		return value
+	}
+
+	for _, prefix := range []string{compressedGzipPrefix, compressedZstdPrefix, blobRefPrefix, escapedValuePrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return escapedValuePrefix + value
+		}
+	}
+
+	return value
+}
+
+// valueUnescape returns the original of an escaped value
+func (st *storeImplementation) valueUnescape(value string) string {
+	if !st.valueEncodes() {
+		return value
+	}
+
+	return strings.TrimPrefix(value, escapedValuePrefix)
+}
+
+// valueCompress compresses a value longer than the compression threshold,
+// unless the compressed value is not shorter
+func (st *storeImplementation) valueCompress(value string) (string, error) {
+	if st.valueCompression == "" || len(value) <= st.valueCompressionThreshold {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	prefix := ""
+
+	switch st.valueCompression {
+	case VALUE_COMPRESSION_GZIP:
+		prefix = compressedGzipPrefix
+		writer := gzip.NewWriter(&buf)
+
+		if _, err := writer.Write([]byte(value)); err != nil {
+			return "", err
+		}
+
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+	case VALUE_COMPRESSION_ZSTD:
+		prefix = compressedZstdPrefix
+		writer, err := zstd.NewWriter(&buf)
+
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := writer.Write([]byte(value)); err != nil {
+			return "", err
+		}
+
+		if err := writer.Close(); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("unsupported value compression " + st.valueCompression)
+	}
+
+	compressed := prefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	if len(compressed) >= len(value) {
+		return value, nil
+	}
+
+	return compressed, nil
+}
+
+// valueDecompress decompresses a compressed value, other values
+// are returned as they are, as are all the values if the store
+// does not compress values
+func (st *storeImplementation) valueDecompress(value string) (string, error) {
+	if st.valueCompression == "" {
+		return value, nil
+	}
+
+	var reader io.Reader
+
+	switch {
+	case strings.HasPrefix(value, compressedGzipPrefix):
+		data, err := base64.StdEncoding.DecodeString(value[len(compressedGzipPrefix):])
+
+		if err != nil {
+			return "", err
+		}
+
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+
+		if err != nil {
+			return "", err
+		}
+
+		defer gzipReader.Close()
+
+		reader = gzipReader
+	case strings.HasPrefix(value, compressedZstdPrefix):
+		data, err := base64.StdEncoding.DecodeString(value[len(compressedZstdPrefix):])
+
+		if err != nil {
+			return "", err
+		}
+
+		zstdReader, err := zstd.NewReader(bytes.NewReader(data))
+
+		if err != nil {
+			return "", err
+		}
+
+		defer zstdReader.Close()
+
+		reader = zstdReader
+	default:
+		return value, nil
+	}
+
+	decompressed, err := io.ReadAll(reader)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(decompressed), nil
+}
+
+// blobOffload writes a value longer than the blob threshold to the
+// blob store, returning the reference to keep in its place. The blobs
+// are keyed by the hash of their content, equal values share a blob
+func (st *storeImplementation) blobOffload(value string) (string, error) {
+	if st.blobStore == nil || len(value) <= st.blobThreshold {
+		return value, nil
+	}
+
+	hash := sha256.Sum256([]byte(value))
+	key := hex.EncodeToString(hash[:])
+
+	if err := st.blobStore.Put(st.context(), key, []byte(value)); err != nil {
+		return "", err
+	}
+
+	return blobRefPrefix + key, nil
+}
+
+// blobResolve reads the value referenced from the blob store, other
+// values are returned as they are, as are all the values if the
+// blob store is not set
+func (st *storeImplementation) blobResolve(value string) (string, error) {
+	if st.blobStore == nil || !strings.HasPrefix(value, blobRefPrefix) {
+		return value, nil
+	}
+
+	data, err := st.blobStore.Get(st.context(), value[len(blobRefPrefix):])
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
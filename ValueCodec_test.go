@@ -0,0 +1,211 @@
+package entitystore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestValueCompression(t *testing.T) {
+	for _, compression := range []string{VALUE_COMPRESSION_GZIP, VALUE_COMPRESSION_ZSTD} {
+		db := InitDB("test_value_compression_" + compression + ".db")
+
+		store, err := NewStore(NewStoreOptions{
+			DB:                        db,
+			EntityTableName:           "cms_entity",
+			AttributeTableName:        "cms_attribute",
+			ValueCompression:          compression,
+			ValueCompressionThreshold: 100,
+			AutomigrateEnabled:        true,
+		})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		document := strings.Repeat("Lorem ipsum dolor sit amet. ", 100)
+
+		entity, err := store.EntityCreateWithTypeAndAttributes("document", map[string]string{
+			"title": "Short",
+			"body":  document,
+		})
+
+		if err != nil {
+			t.Fatal("Entity could not be created: " + err.Error())
+		}
+
+		var raw string
+		err = db.QueryRow(`SELECT attribute_value FROM cms_attribute WHERE entity_id = ? AND attribute_key = 'body'`, entity.ID()).Scan(&raw)
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if !strings.HasPrefix(raw, compression+":") || len(raw) >= len(document) {
+			t.Fatal("Body must be stored compressed with", compression)
+		}
+
+		body, err := entity.GetString("body", "")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if body != document {
+			t.Fatal("Body must be decompressed")
+		}
+
+		title, err := entity.GetString("title", "")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		if title != "Short" {
+			t.Fatal("Title must be unchanged, found:", title)
+		}
+	}
+}
+
+func TestValueBlobStore(t *testing.T) {
+	db := InitDB("test_value_blob_store.db")
+
+	blobStore, err := NewFileBlobStore(t.TempDir())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	cipher, err := NewAESGCMCipher(map[string][]byte{"k1": []byte("0123456789abcdef")}, "k1")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                     db,
+		EntityTableName:        "cms_entity",
+		AttributeTableName:     "cms_attribute",
+		ValueCompression:       VALUE_COMPRESSION_GZIP,
+		BlobStore:              blobStore,
+		BlobThreshold:          200,
+		AttributeCipher:        cipher,
+		EncryptedAttributeKeys: []string{"scan"},
+		AutomigrateEnabled:     true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// random enough not to compress below the blob threshold
+	random := make([]byte, 1000)
+
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	scan := hex.EncodeToString(random)
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("patient", map[string]string{"scan": scan})
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	var raw string
+	err = db.QueryRow(`SELECT attribute_value FROM cms_attribute WHERE entity_id = ? AND attribute_key = 'scan'`, entity.ID()).Scan(&raw)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !strings.HasPrefix(raw, "blob:") {
+		t.Fatal("Scan must be offloaded to the blob store, found:", raw)
+	}
+
+	blob, err := blobStore.Get(context.Background(), strings.TrimPrefix(raw, "blob:"))
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if !strings.HasPrefix(string(blob), "enc:k1:") {
+		t.Fatal("Blob must be encrypted")
+	}
+
+	value, err := entity.GetString("scan", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if value != scan {
+		t.Fatal("Scan must be read back from the blob store")
+	}
+}
+
+func TestValuePrefixedPlainValues(t *testing.T) {
+	blobStore, err := NewFileBlobStore(t.TempDir())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	values := []string{"gzip:yes", "zstd:no", "blob:maybe", "raw:gzip:yes", "raw:"}
+
+	options := map[string]NewStoreOptions{
+		"plain":       {},
+		"compression": {ValueCompression: VALUE_COMPRESSION_GZIP, ValueCompressionThreshold: 100},
+		"blob":        {BlobStore: blobStore},
+	}
+
+	for name, opts := range options {
+		db := InitDB("test_value_prefixed_" + name + ".db")
+
+		opts.DB = db
+		opts.EntityTableName = "cms_entity"
+		opts.AttributeTableName = "cms_attribute"
+		opts.AutomigrateEnabled = true
+
+		store, err := NewStore(opts)
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		entity, err := store.EntityCreateWithTypeAndAttributes("note", map[string]string{})
+
+		if err != nil {
+			t.Fatal("Entity could not be created: " + err.Error())
+		}
+
+		for _, value := range values {
+			if err := entity.SetString("k", value); err != nil {
+				t.Fatal("Must be NIL:", err.Error())
+			}
+
+			found, err := entity.GetString("k", "")
+
+			if err != nil {
+				t.Fatal(name, "Must be NIL:", err.Error())
+			}
+
+			if found != value {
+				t.Fatal(name, "Value must read back as it was set:", value, "found:", found)
+			}
+
+			byValue, err := store.EntityFindByAttribute("note", "k", value)
+
+			if err != nil {
+				t.Fatal("Must be NIL:", err.Error())
+			}
+
+			if byValue == nil || byValue.ID() != entity.ID() {
+				t.Fatal(name, "Entity must be found by the value:", value)
+			}
+		}
+	}
+}
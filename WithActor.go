@@ -0,0 +1,9 @@
+package entitystore
+
+// WithActor returns a view of the store which records
+// the given actor (i.e. user ID) as the author of changes
+func (st *storeImplementation) WithActor(actor string) StoreInterface {
+	view := *st
+	view.actor = actor
+	return &view
+}
@@ -0,0 +1,20 @@
+package entitystore
+
+import "context"
+
+// WithContext returns a view of the store which passes
+// the given context to the registered hooks
+func (st *storeImplementation) WithContext(ctx context.Context) StoreInterface {
+	view := *st
+	view.ctx = ctx
+	return &view
+}
+
+// context returns the context of the store view
+func (st *storeImplementation) context() context.Context {
+	if st.ctx == nil {
+		return context.Background()
+	}
+
+	return st.ctx
+}
@@ -0,0 +1,44 @@
+package entitystore
+
+import (
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gouniverse/uid"
+)
+
+// attributeHistoryRecord records a change of an attribute value.
+// Does nothing if the attribute history is not enabled
+func (st *storeImplementation) attributeHistoryRecord(entityID string, attributeKey string, operation string, oldValue string, newValue string) error {
+	if st.attributeHistoryTableName == "" {
+		return nil
+	}
+
+	entry := AttributeHistoryEntry{
+		ID:           uid.HumanUid(),
+		EntityID:     entityID,
+		AttributeKey: attributeKey,
+		Operation:    operation,
+		OldValue:     oldValue,
+		NewValue:     newValue,
+		ChangedAt:    time.Now(),
+		ChangedBy:    st.actor,
+	}
+
+	q := goqu.Dialect(st.dbDriverName).Insert(st.attributeHistoryTableName)
+	q = q.Rows(entry)
+
+	sqlStr, _, errSql := q.ToSQL()
+
+	if errSql != nil {
+		return errSql
+	}
+
+	_, err := st.exec("attributeHistoryRecord", sqlStr)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package entitystore
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gouniverse/uid"
+)
+
+// changeRecordEntity records a change of an entity
+func (st *storeImplementation) changeRecordEntity(operation string, entity *Entity, changedKeys []string) error {
+	if st.outboxTableName == "" && !st.hasSubscribers() {
+		return nil
+	}
+
+	return st.changeRecord(operation, entity.Type(), entity.ID(), entity.TenantID(), changedKeys, entity.ToMap())
+}
+
+// changeRecordAttribute records a change of an attribute,
+// looking up the type of the entity it belongs to
+func (st *storeImplementation) changeRecordAttribute(operation string, attr *Attribute) error {
+	if st.outboxTableName == "" && !st.hasSubscribers() {
+		return nil
+	}
+
+	entityType := ""
+	tenantID := st.tenantID
+
+	entity, err := st.EntityFindByID(attr.EntityID())
+
+	if err != nil {
+		return err
+	}
+
+	if entity != nil {
+		entityType = entity.Type()
+		tenantID = entity.TenantID()
+	}
+
+	return st.changeRecord(operation, entityType, attr.EntityID(), tenantID, []string{attr.AttributeKey()}, attr.ToMap())
+}
+
+// changeRecord queues the change for the subscribers,
+// and writes it to the outbox table if the outbox is enabled
+func (st *storeImplementation) changeRecord(operation string, entityType string, entityID string, tenantID string, changedKeys []string, payload map[string]any) error {
+	if changedKeys == nil {
+		changedKeys = []string{}
+	}
+
+	if st.hasSubscribers() {
+		st.changeEventQueue(ChangeEvent{
+			Operation:   operation,
+			EntityType:  entityType,
+			EntityID:    entityID,
+			TenantID:    tenantID,
+			ChangedKeys: changedKeys,
+			OccurredAt:  time.Now(),
+		})
+	}
+
+	if st.outboxTableName == "" {
+		return nil
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	sequence, err := st.outboxSequenceNext()
+
+	if err != nil {
+		return err
+	}
+
+	change := Change{
+		ID:          uid.HumanUid(),
+		Operation:   operation,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		ChangedKeys: changedKeys,
+		Payload:     string(payloadJSON),
+		Sequence:    sequence,
+		CreatedAt:   time.Now(),
+	}
+
+	q := goqu.Dialect(st.dbDriverName).Insert(st.outboxTableName)
+	q = q.Rows(change.ToMap())
+
+	sqlStr, _, errSql := q.ToSQL()
+
+	if errSql != nil {
+		return errSql
+	}
+
+	_, err = st.exec("changeRecord", sqlStr)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// outboxSequenceRowID is the ID of the single row of the outbox sequence table
+const outboxSequenceRowID = "outbox"
+
+// outboxSequenceTableName returns the name of the table numbering the changes
+func (st *storeImplementation) outboxSequenceTableName() string {
+	return st.outboxTableName + "_sequence"
+}
+
+// outboxSequenceNext returns the number of the next change. The row of the
+// sequence stays locked until the transaction of the change ends, so the
+// changes are numbered in the order they are committed
+func (st *storeImplementation) outboxSequenceNext() (int64, error) {
+	where := goqu.C(COLUMN_ID).Eq(outboxSequenceRowID)
+
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+		Update(st.outboxSequenceTableName()).
+		Set(goqu.Record{COLUMN_SEQUENCE: goqu.L("? + 1", goqu.C(COLUMN_SEQUENCE))}).
+		Where(where).
+		ToSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := st.exec("outboxSequenceNext", sqlStr); err != nil {
+		return 0, err
+	}
+
+	rows, err := st.selectToMapStringFrom("outboxSequenceNext", st.outboxSequenceTableName(), where)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(rows) < 1 {
+		return 0, errors.New("the outbox sequence table " + st.outboxSequenceTableName() + " has no row, run AutoMigrate")
+	}
+
+	return strconv.ParseInt(rows[0][COLUMN_SEQUENCE], 10, 64)
+}
+
+// outboxSequenceMigrate numbers the changes recorded
+// before the outbox had a sequence, oldest first
+func (st *storeImplementation) outboxSequenceMigrate() error {
+	if st.outboxTableName == "" {
+		return nil
+	}
+
+	sqlStr, _, err := goqu.Dialect(st.dbDriverName).
+		From(st.outboxTableName).
+		Where(goqu.C(COLUMN_SEQUENCE).Eq(0)).
+		Select(goqu.C(COLUMN_ID)).
+		Order(goqu.C(COLUMN_CREATED_AT).Asc(), goqu.C(COLUMN_ID).Asc()).
+		ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	return st.inTransaction(func(tx *storeImplementation) error {
+		rows, err := tx.selectToMapString("outboxSequenceMigrate", sqlStr)
+
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			sequence, err := tx.outboxSequenceNext()
+
+			if err != nil {
+				return err
+			}
+
+			sqlStr, _, err := goqu.Dialect(tx.dbDriverName).
+				Update(tx.outboxTableName).
+				Set(goqu.Record{COLUMN_SEQUENCE: sequence}).
+				Where(goqu.C(COLUMN_ID).Eq(row[COLUMN_ID])).
+				ToSQL()
+
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.exec("outboxSequenceMigrate", sqlStr); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
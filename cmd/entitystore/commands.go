@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// errUsage marks the errors caused by wrong arguments
+var errUsage = errors.New("usage")
+
+// usageError returns the usage of a command as an error
+func usageError(usage string) error {
+	return fmt.Errorf("%w: usage: entitystore %s", errUsage, usage)
+}
+
+// commandFlags returns a flag set for the flags of a command
+func commandFlags(command string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func (c *cli) migrate(args []string) error {
+	if len(args) > 0 {
+		return usageError("migrate")
+	}
+
+	if err := c.store.AutoMigrate(); err != nil {
+		return err
+	}
+
+	return c.printStatus("", "migrated")
+}
+
+func (c *cli) types(args []string) error {
+	if len(args) > 0 {
+		return usageError("types")
+	}
+
+	types, err := c.store.EntityTypeList()
+
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	items := []map[string]any{}
+
+	for _, info := range types {
+		rows = append(rows, []string{info.EntityType, fmt.Sprint(info.Count), formatTime(info.FirstCreatedAt), formatTime(info.LastCreatedAt)})
+		items = append(items, map[string]any{
+			"type":             info.EntityType,
+			"count":            info.Count,
+			"first_created_at": info.FirstCreatedAt,
+			"last_created_at":  info.LastCreatedAt,
+		})
+	}
+
+	return c.print([]string{"TYPE", "COUNT", "FIRST CREATED", "LAST CREATED"}, rows, items)
+}
+
+func (c *cli) keys(args []string) error {
+	if len(args) > 1 {
+		return usageError("keys [type]")
+	}
+
+	entityType := ""
+
+	if len(args) == 1 {
+		entityType = args[0]
+	}
+
+	keys, err := c.store.AttributeKeyList(entityType)
+
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	items := []map[string]any{}
+
+	for _, info := range keys {
+		rows = append(rows, []string{info.AttributeKey, fmt.Sprint(info.Count), info.ValueType, fmt.Sprint(info.MaxLength), info.SampleValue})
+		items = append(items, map[string]any{
+			"key":        info.AttributeKey,
+			"count":      info.Count,
+			"type":       info.ValueType,
+			"max_length": info.MaxLength,
+			"sample":     info.SampleValue,
+		})
+	}
+
+	return c.print([]string{"KEY", "COUNT", "TYPE", "MAX LENGTH", "SAMPLE"}, rows, items)
+}
+
+func (c *cli) list(args []string) error {
+	flags := commandFlags("list")
+	limit := flags.Uint64("limit", 100, "maximum number of entities")
+	offset := flags.Uint64("offset", 0, "number of entities to skip")
+	handle := flags.String("handle", "", "only the entities with the handle")
+	parent := flags.String("parent", "", "only the children of the entity")
+	sortBy := flags.String("sort-by", "", "column to sort by, id by default")
+	sortOrder := flags.String("sort-order", "asc", "sort order: asc or desc")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return usageError("list [-limit n] [-offset n] [-handle handle] [-parent id] [-sort-by column] [-sort-order asc|desc] <type>")
+	}
+
+	entities, err := c.store.EntityList(entitystore.EntityQueryOptions{
+		EntityType:   flags.Arg(0),
+		EntityHandle: *handle,
+		ParentID:     *parent,
+		Limit:        *limit,
+		Offset:       *offset,
+		SortBy:       *sortBy,
+		SortOrder:    *sortOrder,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{}
+	items := []map[string]any{}
+
+	for _, entity := range entities {
+		rows = append(rows, []string{entity.ID(), entity.Type(), entity.Handle(), formatTime(entity.CreatedAt()), formatTime(entity.UpdatedAt())})
+		items = append(items, map[string]any{
+			"id":         entity.ID(),
+			"type":       entity.Type(),
+			"handle":     entity.Handle(),
+			"created_at": entity.CreatedAt(),
+			"updated_at": entity.UpdatedAt(),
+		})
+	}
+
+	return c.print([]string{"ID", "TYPE", "HANDLE", "CREATED_AT", "UPDATED_AT"}, rows, items)
+}
+
+func (c *cli) get(args []string) error {
+	if len(args) != 1 {
+		return usageError("get <id>")
+	}
+
+	entity, err := c.store.EntityFindByID(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if entity == nil {
+		return fmt.Errorf("%w: %s", entitystore.ErrEntityNotFound, args[0])
+	}
+
+	attributes, err := c.store.EntityAttributeList(entity.ID())
+
+	if err != nil {
+		return err
+	}
+
+	record := entitystore.ExportRecord{
+		ID:         entity.ID(),
+		Type:       entity.Type(),
+		Handle:     entity.Handle(),
+		ParentID:   entity.ParentID(),
+		TenantID:   entity.TenantID(),
+		Version:    entity.Version(),
+		CreatedAt:  entity.CreatedAt(),
+		UpdatedAt:  entity.UpdatedAt(),
+		Attributes: map[string]string{},
+	}
+
+	for _, attribute := range attributes {
+		value, err := attribute.Value()
+
+		if err != nil {
+			return err
+		}
+
+		record.Attributes[attribute.AttributeKey()] = value
+	}
+
+	if c.format == FORMAT_JSON {
+		return c.printJSON(record)
+	}
+
+	rows := [][]string{
+		{"id", record.ID},
+		{"type", record.Type},
+		{"handle", record.Handle},
+		{"version", fmt.Sprint(record.Version)},
+		{"created_at", formatTime(record.CreatedAt)},
+		{"updated_at", formatTime(record.UpdatedAt)},
+	}
+
+	if record.ParentID != "" {
+		rows = append(rows, []string{"parent_id", record.ParentID})
+	}
+
+	if record.TenantID != "" {
+		rows = append(rows, []string{"tenant_id", record.TenantID})
+	}
+
+	keys := []string{}
+
+	for key := range record.Attributes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		rows = append(rows, []string{"attributes." + key, record.Attributes[key]})
+	}
+
+	return c.printTable([]string{"FIELD", "VALUE"}, rows)
+}
+
+func (c *cli) set(args []string) error {
+	if len(args) < 3 || len(args)%2 != 1 {
+		return usageError("set <id> <key> <value> [<key> <value>...]")
+	}
+
+	attributes := map[string]string{}
+
+	for i := 1; i < len(args); i += 2 {
+		attributes[args[i]] = args[i+1]
+	}
+
+	entity, err := c.store.EntityFindByID(args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if entity == nil {
+		return fmt.Errorf("%w: %s", entitystore.ErrEntityNotFound, args[0])
+	}
+
+	if err := c.store.AttributesSet(entity.ID(), attributes); err != nil {
+		return err
+	}
+
+	return c.printStatus(entity.ID(), "updated")
+}
+
+func (c *cli) trash(args []string) error {
+	if len(args) != 1 {
+		return usageError("trash <id>")
+	}
+
+	if _, err := c.store.EntityTrash(args[0]); err != nil {
+		return err
+	}
+
+	return c.printStatus(args[0], "trashed")
+}
+
+func (c *cli) restore(args []string) error {
+	if len(args) != 1 {
+		return usageError("restore <id>")
+	}
+
+	if _, err := c.store.EntityRestore(args[0]); err != nil {
+		return err
+	}
+
+	return c.printStatus(args[0], "restored")
+}
+
+func (c *cli) purge(args []string) error {
+	if len(args) != 1 {
+		return usageError("purge <id>")
+	}
+
+	if _, err := c.store.EntityDelete(args[0]); err != nil {
+		return err
+	}
+
+	return c.printStatus(args[0], "purged")
+}
+
+func (c *cli) export(args []string) error {
+	flags := commandFlags("export")
+	entityType := flags.String("type", "", "only the entities of the type")
+	includeTrash := flags.Bool("trash", false, "include the trashed entities")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return usageError("export [-type type] [-trash] [file]")
+	}
+
+	w := c.stdout
+
+	if flags.NArg() == 1 {
+		file, err := os.Create(flags.Arg(0))
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		w = file
+	}
+
+	return c.store.Export(c.ctx, w, entitystore.ExportOptions{
+		EntityType:   *entityType,
+		IncludeTrash: *includeTrash,
+	})
+}
+
+func (c *cli) importEntities(args []string) error {
+	flags := commandFlags("import")
+	onConflict := flags.String("on-conflict", entitystore.IMPORT_CONFLICT_FAIL, "existing entities: fail, skip or overwrite")
+	preserveIDs := flags.Bool("preserve-ids", false, "keep the IDs of the entities")
+	batchSize := flags.Int("batch-size", 100, "entities imported per transaction")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return usageError("import [-on-conflict fail|skip|overwrite] [-preserve-ids] [-batch-size n] [file]")
+	}
+
+	r := c.stdin
+
+	if flags.NArg() == 1 {
+		file, err := os.Open(flags.Arg(0))
+
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+
+		r = file
+	}
+
+	result, err := c.store.Import(c.ctx, r, entitystore.ImportOptions{
+		OnConflict:  *onConflict,
+		PreserveIDs: *preserveIDs,
+		BatchSize:   *batchSize,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{{fmt.Sprint(result.Created), fmt.Sprint(result.Updated), fmt.Sprint(result.Skipped)}}
+	items := map[string]any{"created": result.Created, "updated": result.Updated, "skipped": result.Skipped}
+
+	if c.format == FORMAT_JSON {
+		return c.printJSON(items)
+	}
+
+	return c.printTable([]string{"CREATED", "UPDATED", "SKIPPED"}, rows)
+}
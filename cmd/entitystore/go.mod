@@ -0,0 +1,26 @@
+module github.com/gouniverse/entitystore/cmd/entitystore
+
+go 1.23.3
+
+require (
+	github.com/go-sql-driver/mysql v1.8.1
+	github.com/gouniverse/entitystore v1.1.0 // tagged before the command
+	github.com/lib/pq v1.10.1
+	github.com/mattn/go-sqlite3 v1.14.25
+)
+
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/doug-martin/goqu/v9 v9.19.0 // indirect
+	github.com/dromara/carbon/v2 v2.6.1 // indirect
+	github.com/georgysavva/scany v1.2.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/gouniverse/base v0.9.0 // indirect
+	github.com/gouniverse/maputils v0.7.0 // indirect
+	github.com/gouniverse/sb v0.8.0 // indirect
+	github.com/gouniverse/uid v1.5.0 // indirect
+	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/samber/lo v1.49.1 // indirect
+	github.com/spf13/cast v1.7.1 // indirect
+	golang.org/x/text v0.23.0 // indirect
+)
@@ -0,0 +1,227 @@
+// Command entitystore inspects and edits an entity store from the command line.
+//
+// Usage:
+//
+//	entitystore [flags] <command> [arguments]
+//
+// The commands are migrate, types, keys, list, get, set, trash, restore, purge,
+// export and import. By default the store is the local SQLite file
+// entitystore.db, run "entitystore help" for the flags
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gouniverse/entitystore"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const FORMAT_TABLE = "table"
+const FORMAT_JSON = "json"
+
+// cli holds the store and the output of a single run
+type cli struct {
+	ctx    context.Context
+	store  entitystore.StoreInterface
+	format string
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command line and returns the exit code
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("entitystore", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: entitystore [flags] <command> [arguments]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Commands:")
+		fmt.Fprintln(stderr, "  migrate                                    creates or updates the tables")
+		fmt.Fprintln(stderr, "  types                                      lists the entity types with their counts")
+		fmt.Fprintln(stderr, "  keys [type]                                lists the attribute keys with their inferred types")
+		fmt.Fprintln(stderr, "  list [flags] <type>                        lists the entities of a type")
+		fmt.Fprintln(stderr, "  get <id>                                   shows an entity with its attributes")
+		fmt.Fprintln(stderr, "  set <id> <key> <value> [<key> <value>...]  sets attributes of an entity")
+		fmt.Fprintln(stderr, "  trash <id>                                 moves an entity to the trash bin")
+		fmt.Fprintln(stderr, "  restore <id>                               moves an entity back from the trash bin")
+		fmt.Fprintln(stderr, "  purge <id>                                 deletes an entity for good, skipping the trash bin")
+		fmt.Fprintln(stderr, "  export [flags] [file]                      writes the entities as JSON Lines, to stdout by default")
+		fmt.Fprintln(stderr, "  import [flags] [file]                      reads the entities from JSON Lines, from stdin by default")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Flags:")
+		flags.PrintDefaults()
+	}
+
+	driver := flags.String("driver", "sqlite3", "database driver: sqlite3, mysql or postgres")
+	dsn := flags.String("dsn", "entitystore.db", "data source name, the file name for sqlite3")
+	format := flags.String("format", FORMAT_TABLE, "output format: table or json")
+	entityTable := flags.String("entity-table", "entities_entity", "entity table name")
+	attributeTable := flags.String("attribute-table", "entities_attribute", "attribute table name")
+	entityTrashTable := flags.String("entity-trash-table", "", "entity trash table name, the entity table name with _trash by default")
+	attributeTrashTable := flags.String("attribute-trash-table", "", "attribute trash table name, the attribute table name with _trash by default")
+	historyTable := flags.String("history-table", "", "attribute history table name, if the history is enabled")
+	outboxTable := flags.String("outbox-table", "", "outbox table name, if the outbox is enabled")
+	linkTable := flags.String("link-table", "", "link table name, if the links are enabled")
+	hierarchy := flags.Bool("hierarchy", false, "the hierarchy is enabled")
+	tenancy := flags.Bool("tenancy", false, "the tenancy is enabled")
+	tenant := flags.String("tenant", "", "scopes the commands to the tenant, requires -tenancy")
+	actor := flags.String("actor", "", "the actor recorded as the author of the changes")
+	cipherKeys := flags.String("cipher-keys", "", "file with the keys of the attribute cipher, one id=base64key per line")
+	cipherKey := flags.String("cipher-key", "", "id of the key sealing the new values, may be omitted with a single key")
+	encryptedKeys := flags.String("encrypted-keys", "", "comma separated attribute keys encrypted by the cipher, requires -cipher-keys")
+	compression := flags.String("compression", "", "value compression: gzip or zstd")
+	compressionThreshold := flags.Int("compression-threshold", 0, "compresses the values longer than this, in bytes, 1024 by default")
+	blobDir := flags.String("blob-dir", "", "directory of the blob store offloading the long values")
+	blobThreshold := flags.Int("blob-threshold", 0, "offloads the values longer than this, in bytes, 32768 by default")
+	projections := projectionFlags{}
+	flags.Var(&projections, "projection", "projection kept in sync, as type:table:key=type,key=type, repeatable")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 2
+	}
+
+	if flags.NArg() < 1 || flags.Arg(0) == "help" {
+		flags.Usage()
+		return 2
+	}
+
+	if *format != FORMAT_TABLE && *format != FORMAT_JSON {
+		fmt.Fprintln(stderr, "unsupported format "+*format)
+		return 2
+	}
+
+	if *driver == "sqlite" {
+		*driver = "sqlite3"
+	}
+
+	options := entitystore.NewStoreOptions{
+		EntityTableName:           *entityTable,
+		AttributeTableName:        *attributeTable,
+		EntityTrashTableName:      *entityTrashTable,
+		AttributeTrashTableName:   *attributeTrashTable,
+		AttributeHistoryTableName: *historyTable,
+		OutboxTableName:           *outboxTable,
+		LinkTableName:             *linkTable,
+		HierarchyEnabled:          *hierarchy,
+		TenancyEnabled:            *tenancy,
+		EncryptedAttributeKeys:    listSplit(*encryptedKeys),
+		ValueCompression:          *compression,
+		ValueCompressionThreshold: *compressionThreshold,
+		BlobThreshold:             *blobThreshold,
+		Projections:               projections,
+	}
+
+	if *cipherKeys != "" {
+		cipher, err := cipherLoad(*cipherKeys, *cipherKey)
+
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+
+		options.AttributeCipher = cipher
+	} else if len(options.EncryptedAttributeKeys) > 0 {
+		fmt.Fprintln(stderr, "-encrypted-keys requires -cipher-keys")
+		return 2
+	}
+
+	if *blobDir != "" {
+		blobStore, err := entitystore.NewFileBlobStore(*blobDir)
+
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+
+		options.BlobStore = blobStore
+	}
+
+	db, err := sql.Open(*driver, *dsn)
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	defer db.Close()
+
+	options.DB = db
+
+	store, err := entitystore.NewStore(options)
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	if *tenant != "" {
+		store = store.ForTenant(*tenant)
+	}
+
+	if *actor != "" {
+		store = store.WithActor(*actor)
+	}
+
+	c := &cli{
+		ctx:    ctx,
+		store:  store.WithContext(ctx),
+		format: *format,
+		stdin:  stdin,
+		stdout: stdout,
+	}
+
+	command := flags.Arg(0)
+	commandArgs := flags.Args()[1:]
+
+	commands := map[string]func([]string) error{
+		"migrate": c.migrate,
+		"types":   c.types,
+		"keys":    c.keys,
+		"list":    c.list,
+		"get":     c.get,
+		"set":     c.set,
+		"trash":   c.trash,
+		"restore": c.restore,
+		"purge":   c.purge,
+		"export":  c.export,
+		"import":  c.importEntities,
+	}
+
+	commandFunc, exists := commands[command]
+
+	if !exists {
+		fmt.Fprintln(stderr, "unknown command "+command)
+		flags.Usage()
+		return 2
+	}
+
+	if err := commandFunc(commandArgs); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(stderr, strings.TrimPrefix(err.Error(), errUsage.Error()+": "))
+			return 2
+		}
+
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
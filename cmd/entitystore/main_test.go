@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "entitystore.db")
+
+	runCommand := func(stdin string, args ...string) (string, int) {
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+		code := run(context.Background(), append([]string{"-dsn", dsn}, args...), strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String() + stderr.String(), code
+	}
+
+	if output, code := runCommand("", "migrate"); code != 0 {
+		t.Fatal("Migrate must succeed:", output)
+	}
+
+	records := `{"id":"apple","type":"product","handle":"apple","attributes":{"name":"Apple"}}` + "\n" +
+		`{"id":"pear","type":"product","attributes":{"name":"Pear"}}` + "\n"
+
+	if output, code := runCommand(records, "import", "-preserve-ids"); code != 0 || !strings.Contains(output, "2") {
+		t.Fatal("Import must create 2 entities:", output)
+	}
+
+	output, code := runCommand("", "types")
+
+	if code != 0 || !strings.Contains(output, "product") || !strings.Contains(output, "2") {
+		t.Fatal("Types must list 2 products:", output)
+	}
+
+	output, code = runCommand("", "keys", "product")
+
+	if code != 0 || !strings.Contains(output, "name") || !strings.Contains(output, "string") {
+		t.Fatal("Keys must list the name of the products:", output)
+	}
+
+	output, code = runCommand("", "list", "-limit", "1", "product")
+
+	if code != 0 || !strings.Contains(output, "apple") || strings.Contains(output, "pear") {
+		t.Fatal("List must show the first product only:", output)
+	}
+
+	if output, code := runCommand("", "set", "apple", "name", "Green Apple", "price", "1.20"); code != 0 {
+		t.Fatal("Set must succeed:", output)
+	}
+
+	output, code = runCommand("", "-format", "json", "get", "apple")
+
+	if code != 0 {
+		t.Fatal("Get must succeed:", output)
+	}
+
+	record := map[string]any{}
+
+	if err := json.Unmarshal([]byte(output), &record); err != nil {
+		t.Fatal("Get must print JSON:", err.Error(), output)
+	}
+
+	attributes, _ := record["attributes"].(map[string]any)
+
+	if attributes["name"] != "Green Apple" || attributes["price"] != "1.20" {
+		t.Fatal("Get must print the attributes, found:", attributes)
+	}
+
+	if output, code := runCommand("", "trash", "pear"); code != 0 {
+		t.Fatal("Trash must succeed:", output)
+	}
+
+	if output, code := runCommand("", "get", "pear"); code != 1 || !strings.Contains(output, "not found") {
+		t.Fatal("Get of a trashed entity must fail:", output)
+	}
+
+	if output, code := runCommand("", "restore", "pear"); code != 0 {
+		t.Fatal("Restore must succeed:", output)
+	}
+
+	if output, code := runCommand("", "purge", "pear"); code != 0 {
+		t.Fatal("Purge must succeed:", output)
+	}
+
+	output, code = runCommand("", "export", "-trash")
+
+	if code != 0 || strings.Count(output, "\n") != 1 || !strings.Contains(output, "Green Apple") {
+		t.Fatal("Export must write the remaining entity:", output)
+	}
+
+	if output, code := runCommand("", "set", "apple", "name"); code != 2 || !strings.Contains(output, "usage") {
+		t.Fatal("Set with missing arguments must print the usage:", output)
+	}
+
+	if output, code := runCommand("", "unknown"); code != 2 {
+		t.Fatal("Unknown command must fail:", output)
+	}
+}
+
+func TestRunValueOptions(t *testing.T) {
+	dir := t.TempDir()
+	dsn := filepath.Join(dir, "entitystore.db")
+	keysFile := filepath.Join(dir, "keys")
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	if err := os.WriteFile(keysFile, []byte("# attribute keys\nk1="+key+"\n"), 0600); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	options := []string{
+		"-dsn", dsn,
+		"-cipher-keys", keysFile,
+		"-encrypted-keys", "secret",
+		"-compression", "gzip",
+		"-blob-dir", filepath.Join(dir, "blobs"),
+		"-projection", "product:product_projection:price=float,name=string",
+	}
+
+	runCommand := func(args ...string) (string, int) {
+		stdout := bytes.Buffer{}
+		stderr := bytes.Buffer{}
+		code := run(context.Background(), append(options, args...), strings.NewReader(""), &stdout, &stderr)
+		return stdout.String() + stderr.String(), code
+	}
+
+	if output, code := runCommand("migrate"); code != 0 {
+		t.Fatal("Migrate must succeed:", output)
+	}
+
+	records := `{"id":"apple","type":"product","attributes":{"name":"Apple"}}` + "\n"
+	stdout := bytes.Buffer{}
+
+	if code := run(context.Background(), append(options, "import", "-preserve-ids"), strings.NewReader(records), &stdout, &stdout); code != 0 {
+		t.Fatal("Import must succeed:", stdout.String())
+	}
+
+	if output, code := runCommand("set", "apple", "secret", "s3cret", "price", "1.20"); code != 0 {
+		t.Fatal("Set must succeed:", output)
+	}
+
+	output, code := runCommand("get", "apple")
+
+	if code != 0 || !strings.Contains(output, "s3cret") {
+		t.Fatal("Get must open the encrypted attribute:", output)
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	defer db.Close()
+
+	value := ""
+
+	if err := db.QueryRow(`SELECT attribute_value FROM entities_attribute WHERE attribute_key = 'secret'`).Scan(&value); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if strings.Contains(value, "s3cret") {
+		t.Fatal("Encrypted attribute must not be stored in plaintext, found:", value)
+	}
+
+	price := 0.0
+
+	if err := db.QueryRow(`SELECT price FROM product_projection WHERE entity_id = 'apple'`).Scan(&price); err != nil {
+		t.Fatal("Projection must be synced by set:", err.Error())
+	}
+
+	if price != 1.2 {
+		t.Fatal("Projection must hold the price, found:", price)
+	}
+
+	stderr := bytes.Buffer{}
+
+	if code := run(context.Background(), []string{"-dsn", dsn, "-encrypted-keys", "secret", "get", "apple"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Fatal("Encrypted keys without the cipher keys must fail:", stderr.String())
+	}
+
+	if code := run(context.Background(), []string{"-dsn", dsn, "-projection", "product", "get", "apple"}, strings.NewReader(""), &stdout, &stderr); code != 2 {
+		t.Fatal("Malformed projection must fail:", stderr.String())
+	}
+}
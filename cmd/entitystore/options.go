@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// projectionFlags collects the repeated -projection flags,
+// each as type:table:key=type,key=type
+type projectionFlags []entitystore.Projection
+
+func (p *projectionFlags) String() string {
+	declarations := []string{}
+
+	for _, projection := range *p {
+		columns := []string{}
+
+		for key, valueType := range projection.Columns {
+			columns = append(columns, key+"="+valueType)
+		}
+
+		declarations = append(declarations, projection.EntityType+":"+projection.TableName+":"+strings.Join(columns, ","))
+	}
+
+	return strings.Join(declarations, " ")
+}
+
+func (p *projectionFlags) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return errors.New("projection must be type:table:key=type,key=type")
+	}
+
+	projection := entitystore.Projection{
+		EntityType: parts[0],
+		TableName:  parts[1],
+		Columns:    map[string]string{},
+	}
+
+	for _, column := range strings.Split(parts[2], ",") {
+		key, valueType, found := strings.Cut(column, "=")
+
+		if !found || key == "" || valueType == "" {
+			return errors.New("projection column must be key=type, found " + column)
+		}
+
+		projection.Columns[key] = valueType
+	}
+
+	*p = append(*p, projection)
+
+	return nil
+}
+
+// cipherLoad reads the keys of the attribute cipher from the file,
+// one id=base64key per line, sealing the new values with the key
+// with currentKeyID, which may be omitted if there is a single key
+func cipherLoad(path string, currentKeyID string) (entitystore.AttributeCipher, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	keys := map[string][]byte{}
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		keyID, encoded, found := strings.Cut(line, "=")
+
+		if !found || keyID == "" {
+			return nil, fmt.Errorf("%s: key must be id=base64key", path)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %s: %w", path, keyID, err)
+		}
+
+		keys[keyID] = key
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if currentKeyID == "" && len(keys) == 1 {
+		for keyID := range keys {
+			currentKeyID = keyID
+		}
+	}
+
+	if currentKeyID == "" {
+		return nil, errors.New("-cipher-key is required with several keys")
+	}
+
+	return entitystore.NewAESGCMCipher(keys, currentKeyID)
+}
+
+// listSplit splits a comma separated list, skipping the empty items
+func listSplit(list string) []string {
+	items := []string{}
+
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
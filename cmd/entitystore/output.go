@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// print prints the rows as a table, or the items as JSON
+func (c *cli) print(headers []string, rows [][]string, items any) error {
+	if c.format == FORMAT_JSON {
+		return c.printJSON(items)
+	}
+
+	return c.printTable(headers, rows)
+}
+
+// printTable prints the rows as columns aligned with spaces
+func (c *cli) printTable(headers []string, rows [][]string) error {
+	w := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+
+		// the tabs and newlines in the values would break the columns
+		for i, cell := range row {
+			cells[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cell)
+		}
+
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// printJSON prints the value as indented JSON
+func (c *cli) printJSON(value any) error {
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// printStatus prints the outcome of a command changing an entity
+func (c *cli) printStatus(entityID string, status string) error {
+	if c.format == FORMAT_JSON {
+		item := map[string]string{"status": status}
+
+		if entityID != "" {
+			item["id"] = entityID
+		}
+
+		return c.printJSON(item)
+	}
+
+	if entityID == "" {
+		_, err := fmt.Fprintln(c.stdout, status)
+		return err
+	}
+
+	_, err := fmt.Fprintln(c.stdout, entityID, status)
+	return err
+}
+
+// formatTime formats a time for the table output
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.DateTime)
+}
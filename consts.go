@@ -2,10 +2,49 @@ package entitystore
 
 const COLUMN_ATTRIBUTE_KEY = "attribute_key"
 const COLUMN_ATTRIBUTE_VALUE = "attribute_value"
+const COLUMN_CHANGED_AT = "changed_at"
+const COLUMN_CHANGED_BY = "changed_by"
+const COLUMN_CHANGED_KEYS = "changed_keys"
 const COLUMN_CREATED_AT = "created_at"
 const COLUMN_DELETED_AT = "deleted_at"
 const COLUMN_ID = "id"
 const COLUMN_ENTITY_HANDLE = "entity_handle"
 const COLUMN_ENTITY_ID = "entity_id"
 const COLUMN_ENTITY_TYPE = "entity_type"
+const COLUMN_FROM_ID = "from_id"
+const COLUMN_METADATA = "metadata"
+const COLUMN_NEW_VALUE = "new_value"
+const COLUMN_OLD_VALUE = "old_value"
+const COLUMN_OPERATION = "operation"
+const COLUMN_PARENT_ID = "parent_id"
+const COLUMN_PAYLOAD = "payload"
+const COLUMN_POSITION = "position"
+const COLUMN_RELATION = "relation"
+const COLUMN_SEQUENCE = "sequence"
+const COLUMN_TENANT_ID = "tenant_id"
+const COLUMN_TO_ID = "to_id"
 const COLUMN_UPDATED_AT = "updated_at"
+const COLUMN_VERSION = "version"
+
+const OPERATION_CREATE = "create"
+const OPERATION_DELETE = "delete"
+const OPERATION_UPDATE = "update"
+
+const CHANGE_ATTRIBUTE_DELETE = "attribute_delete"
+const CHANGE_ATTRIBUTE_SET = "attribute_set"
+const CHANGE_ENTITY_CREATE = "entity_create"
+const CHANGE_ENTITY_DELETE = "entity_delete"
+const CHANGE_ENTITY_RESTORE = "entity_restore"
+const CHANGE_ENTITY_TRASH = "entity_trash"
+const CHANGE_ENTITY_UPDATE = "entity_update"
+
+const LINK_DIRECTION_BOTH = "both"
+const LINK_DIRECTION_INCOMING = "incoming"
+const LINK_DIRECTION_OUTGOING = "outgoing"
+
+const VALUE_TYPE_BOOL = "bool"
+const VALUE_TYPE_FLOAT = "float"
+const VALUE_TYPE_INT = "int"
+const VALUE_TYPE_JSON = "json"
+const VALUE_TYPE_STRING = "string"
+const VALUE_TYPE_TIME = "time"
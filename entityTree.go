@@ -0,0 +1,122 @@
+package entitystore
+
+import (
+	"github.com/doug-martin/goqu/v9"
+)
+
+// hierarchyDepthLimit guards the recursive queries against runaway recursion
+const hierarchyDepthLimit = 1000
+
+// entityTreeIDs walks the entity hierarchy with a recursive CTE,
+// upwards (ancestors) or downwards (descendants), returning the
+// IDs of the found entities ordered by depth, nearest first
+func (st *storeImplementation) entityTreeIDs(entityID string, upwards bool, maxDepth int) ([]string, error) {
+	if !st.hierarchyEnabled {
+		return nil, errNotEnabled("hierarchy")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if maxDepth <= 0 || maxDepth > hierarchyDepthLimit {
+		maxDepth = hierarchyDepthLimit
+	}
+
+	join := goqu.I("e." + COLUMN_ID).Eq(goqu.I("entity_tree." + COLUMN_PARENT_ID))
+
+	if !upwards {
+		join = goqu.I("e." + COLUMN_PARENT_ID).Eq(goqu.I("entity_tree." + COLUMN_ID))
+	}
+
+	baseSql, _, err := goqu.Dialect(st.dbDriverName).
+		From(st.entityTableName).
+		Select(goqu.C(COLUMN_ID), goqu.C(COLUMN_PARENT_ID), goqu.L("0").As("depth")).
+		Where(goqu.C(COLUMN_ID).Eq(entityID)).
+		Where(st.tenantTreeWhere(COLUMN_TENANT_ID)).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	recursiveSql, _, err := goqu.Dialect(st.dbDriverName).
+		From(goqu.T(st.entityTableName).As("e")).
+		InnerJoin(goqu.T("entity_tree"), goqu.On(join)).
+		Select(goqu.I("e."+COLUMN_ID), goqu.I("e."+COLUMN_PARENT_ID), goqu.L("? + 1", goqu.I("entity_tree.depth"))).
+		Where(goqu.I("entity_tree.depth").Lt(maxDepth)).
+		Where(st.tenantTreeWhere("e." + COLUMN_TENANT_ID)).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	selectSql, _, err := goqu.Dialect(st.dbDriverName).
+		From("entity_tree").
+		Select(goqu.C(COLUMN_ID)).
+		Where(goqu.C("depth").Gt(0)).
+		Order(goqu.C("depth").Asc(), goqu.C(COLUMN_ID).Asc()).
+		ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// composed by hand, as SQLite does not accept the parentheses
+	// goqu puts around the recursive member of the union
+	sqlStr := "WITH RECURSIVE entity_tree(id, parent_id, depth) AS (" + baseSql + " UNION ALL " + recursiveSql + ") " + selectSql
+
+	rows, err := st.selectToMapString("entityTreeIDs", sqlStr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+
+	for _, row := range rows {
+		ids = append(ids, row[COLUMN_ID])
+	}
+
+	return ids, nil
+}
+
+// entityListInOrder lists the entities with the IDs, keeping the order of the IDs
+func (st *storeImplementation) entityListInOrder(ids []string) ([]Entity, error) {
+	if len(ids) < 1 {
+		return []Entity{}, nil
+	}
+
+	list, err := st.EntityList(EntityQueryOptions{IDs: ids})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByID := map[string]Entity{}
+
+	for _, entity := range list {
+		entitiesByID[entity.ID()] = entity
+	}
+
+	entities := []Entity{}
+
+	for _, id := range ids {
+		if entity, exists := entitiesByID[id]; exists {
+			entities = append(entities, entity)
+		}
+	}
+
+	return entities, nil
+}
+
+// tenantTreeWhere limits the tree walk to the entities of the tenant
+// the store is scoped to, it matches everything if it is not scoped
+func (st *storeImplementation) tenantTreeWhere(column string) goqu.Expression {
+	if !st.tenantScoped {
+		return goqu.L("1 = 1")
+	}
+
+	return goqu.I(column).Eq(st.tenantID)
+}
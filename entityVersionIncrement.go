@@ -0,0 +1,30 @@
+package entitystore
+
+import (
+	"github.com/doug-martin/goqu/v9"
+)
+
+// entityVersionIncrement bumps the version of an entity,
+// used when one of its attributes is modified
+func (st *storeImplementation) entityVersionIncrement(entityID string) error {
+	q := goqu.Dialect(st.dbDriverName).
+		Update(st.entityTableName).
+		Where(goqu.C(COLUMN_ID).Eq(entityID)).
+		Set(goqu.Record{COLUMN_VERSION: goqu.L("? + 1", goqu.C(COLUMN_VERSION))})
+
+	sqlStr, _, errSql := q.ToSQL()
+
+	if errSql != nil {
+		return errSql
+	}
+
+	_, err := st.exec("entityVersionIncrement", sqlStr)
+
+	if err != nil {
+		return err
+	}
+
+	st.cacheInvalidate(cacheKeyEntity(entityID))
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package entitystoretest
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testAttributeAggregate(t *testing.T, store entitystore.StoreInterface) {
+	entityCreate(t, store, "order", "a", map[string]string{"status": "paid", "amount": "10.5"})
+	entityCreate(t, store, "order", "b", map[string]string{"status": "paid", "amount": "-2"})
+	entityCreate(t, store, "order", "c", map[string]string{"status": "pending", "amount": "abc"})
+	entityCreate(t, store, "order", "d", map[string]string{"amount": "4"})
+	entityCreate(t, store, "invoice", "e", map[string]string{"status": "paid", "amount": "1000"})
+
+	results, err := store.AttributeAggregate("order", "amount", entitystore.AggregateOptions{
+		Func:             entitystore.AGGREGATE_SUM,
+		GroupByAttribute: "status",
+	})
+	mustNil(t, err)
+
+	expected := []entitystore.AggregateResult{
+		{Group: "", Value: 4, Count: 1},
+		{Group: "paid", Value: 8.5, Count: 2},
+		{Group: "pending", Value: 0, Count: 1},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatal("Must have", len(expected), "groups, found:", results)
+	}
+
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Fatal("Group", i, "must be", expected[i], "found:", results[i])
+		}
+	}
+
+	cases := []struct {
+		function string
+		where    map[string]string
+		expected float64
+	}{
+		{entitystore.AGGREGATE_AVG, nil, 12.5 / 3},
+		{entitystore.AGGREGATE_MIN, nil, -2},
+		{entitystore.AGGREGATE_MAX, nil, 10.5},
+		{entitystore.AGGREGATE_COUNT, nil, 4},
+		{entitystore.AGGREGATE_SUM, map[string]string{"status": "paid"}, 8.5},
+		{entitystore.AGGREGATE_COUNT, map[string]string{"status": "missing"}, 0},
+	}
+
+	for _, c := range cases {
+		results, err := store.AttributeAggregate("order", "amount", entitystore.AggregateOptions{Func: c.function, Where: c.where})
+		mustNil(t, err)
+
+		if len(results) != 1 || results[0].Value != c.expected {
+			t.Fatal("The", c.function, "must be", c.expected, "found:", results)
+		}
+	}
+
+	// look like numbers to a loose check, but are not numbers
+	for i, malformed := range []string{"1-2", "1.2.3", "e", "1e", "-", ".", "1.", "--1", "1e+"} {
+		entityCreate(t, store, "refund", "r"+strconv.Itoa(i), map[string]string{"amount": malformed})
+	}
+
+	entityCreate(t, store, "refund", "valid", map[string]string{"amount": "2.5e1"})
+
+	for _, function := range []string{entitystore.AGGREGATE_SUM, entitystore.AGGREGATE_MIN, entitystore.AGGREGATE_MAX} {
+		results, err := store.AttributeAggregate("refund", "amount", entitystore.AggregateOptions{Func: function})
+		mustNil(t, err)
+
+		if len(results) != 1 || results[0].Value != 25 || results[0].Count != 10 {
+			t.Fatal("The", function, "must skip the malformed numbers, found:", results)
+		}
+	}
+
+	_, err = store.AttributeAggregate("order", "amount", entitystore.AggregateOptions{Func: "median"})
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+}
+
+func testAttributeFacets(t *testing.T, store entitystore.StoreInterface) {
+	entityCreate(t, store, "product", "a", map[string]string{"status": "active", "color": "red"})
+	entityCreate(t, store, "product", "b", map[string]string{"status": "active", "color": "blue"})
+	entityCreate(t, store, "product", "c", map[string]string{"status": "pending", "color": "red"})
+	entityCreate(t, store, "product", "d", map[string]string{"status": "active"})
+	entityCreate(t, store, "post", "e", map[string]string{"status": "pending"})
+
+	facets, err := store.AttributeFacets("product", []string{"status", "color", "missing"}, entitystore.FacetOptions{})
+	mustNil(t, err)
+
+	expected := map[string]string{
+		"status":  "active=3,pending=1",
+		"color":   "red=2,blue=1",
+		"missing": "",
+	}
+
+	for key, values := range expected {
+		if facetString(facets[key]) != values {
+			t.Fatal("The facets of", key, "must be", values, "found:", facetString(facets[key]))
+		}
+	}
+
+	facets, err = store.AttributeFacets("product", []string{"color"}, entitystore.FacetOptions{Limit: 1})
+	mustNil(t, err)
+
+	if facetString(facets["color"]) != "red=2" {
+		t.Fatal("Must keep the most frequent value, found:", facetString(facets["color"]))
+	}
+
+	facets, err = store.AttributeFacets("product", []string{"status"}, entitystore.FacetOptions{
+		Filter: entitystore.EntityQueryOptions{EntityHandle: "c", Limit: 1, Offset: 1},
+	})
+	mustNil(t, err)
+
+	if facetString(facets["status"]) != "pending=1" {
+		t.Fatal("Must count the entities matching the filter, found:", facetString(facets["status"]))
+	}
+
+	_, err = store.AttributeFacets("", []string{"status"}, entitystore.FacetOptions{})
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+}
+
+// facetString formats the facet values as value=count, comma separated
+func facetString(values []entitystore.FacetValue) string {
+	list := []string{}
+
+	for _, value := range values {
+		list = append(list, value.Value+"="+strconv.FormatInt(value.Count, 10))
+	}
+
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,180 @@
+package entitystoretest
+
+import (
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testAttributeUpsert(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", nil)
+
+	mustNil(t, store.AttributeSetString(entity.ID(), "title", "Hello"))
+
+	created, err := store.AttributeFind(entity.ID(), "title")
+	mustNil(t, err)
+
+	if created == nil || created.AttributeValue() != "Hello" {
+		t.Fatal("The attribute must be created")
+	}
+
+	mustNil(t, store.AttributeSetString(entity.ID(), "title", "Updated"))
+
+	attributes, err := store.EntityAttributeList(entity.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 1 {
+		t.Fatal("Setting an attribute again must update it, found:", len(attributes))
+	}
+
+	if attributes[0].ID() != created.ID() || attributes[0].AttributeValue() != "Updated" {
+		t.Fatal("The attribute must keep its ID and have the new value, found:", attributes[0].AttributeValue())
+	}
+
+	mustNil(t, store.AttributesSet(entity.ID(), map[string]string{"title": "Again", "body": "World"}))
+
+	attributes, err = store.EntityAttributeList(entity.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 2 {
+		t.Fatal("Must have 2 attributes, found:", len(attributes))
+	}
+
+	byHandle, err := store.AttributeFindByHandle("post", "hello", "body")
+	mustNil(t, err)
+
+	if byHandle == nil || byHandle.AttributeValue() != "World" {
+		t.Fatal("Must find the attribute by the type and handle of the entity")
+	}
+
+	missing, err := store.AttributeFind(entity.ID(), "missing")
+	mustNil(t, err)
+
+	if missing != nil {
+		t.Fatal("A missing attribute must be NIL")
+	}
+
+	withKey, err := store.AttributeCreateWithKeyAndValue(entity.ID(), "summary", "Short")
+	mustNil(t, err)
+
+	if withKey.ID() == "" || withKey.EntityID() != entity.ID() {
+		t.Fatal("The attribute must be created for the entity")
+	}
+}
+
+func testAttributeTypes(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "product", "apple", nil)
+
+	mustNil(t, store.AttributeSetInt(entity.ID(), "stock", -42))
+	mustNil(t, store.AttributeSetFloat(entity.ID(), "price", 1.25))
+
+	stock, err := store.AttributeFind(entity.ID(), "stock")
+	mustNil(t, err)
+
+	stockValue, err := stock.GetInt()
+	mustNil(t, err)
+
+	if stockValue != -42 {
+		t.Fatal("Stock must be -42, found:", stockValue)
+	}
+
+	price, err := store.AttributeFind(entity.ID(), "price")
+	mustNil(t, err)
+
+	priceValue, err := price.GetFloat()
+	mustNil(t, err)
+
+	if priceValue != 1.25 {
+		t.Fatal("Price must be 1.25, found:", priceValue)
+	}
+
+	long := ""
+
+	for len(long) < 100000 {
+		long += "0123456789"
+	}
+
+	mustNil(t, store.AttributeSetString(entity.ID(), "description", long))
+
+	description, err := store.AttributeFind(entity.ID(), "description")
+	mustNil(t, err)
+
+	if description.AttributeValue() != long {
+		t.Fatal("A long value must be kept as it is")
+	}
+}
+
+func testAttributeDelete(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", map[string]string{"title": "Hello", "body": "World"})
+
+	isDeleted, err := store.AttributeDelete(entity.ID(), "title")
+	mustNil(t, err)
+
+	if !isDeleted {
+		t.Fatal("Attribute must be deleted")
+	}
+
+	title, err := store.AttributeFind(entity.ID(), "title")
+	mustNil(t, err)
+
+	if title != nil {
+		t.Fatal("A deleted attribute must not be found")
+	}
+
+	attributes, err := store.EntityAttributeList(entity.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 1 {
+		t.Fatal("The other attributes must be kept, found:", len(attributes))
+	}
+
+	_, err = store.AttributeDelete(entity.ID(), "title")
+	mustBe(t, err, entitystore.ErrAttributeNotFound)
+}
+
+func testEntityHelpers(t *testing.T, store entitystore.StoreInterface) {
+	entity, err := store.EntityCreateWithType("product")
+	mustNil(t, err)
+
+	mustNil(t, entity.SetString("name", "Apple"))
+	mustNil(t, entity.SetInt("stock", 7))
+	mustNil(t, entity.SetFloat("price", 0.5))
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	name, err := found.GetString("name", "")
+	mustNil(t, err)
+
+	if name != "Apple" {
+		t.Fatal("Name must be Apple, found:", name)
+	}
+
+	stock, err := found.GetInt("stock", 0)
+	mustNil(t, err)
+
+	if stock != 7 {
+		t.Fatal("Stock must be 7, found:", stock)
+	}
+
+	price, err := found.GetFloat("price", 0)
+	mustNil(t, err)
+
+	if price != 0.5 {
+		t.Fatal("Price must be 0.5, found:", price)
+	}
+
+	fallback, err := found.GetString("missing", "default")
+	mustNil(t, err)
+
+	if fallback != "default" {
+		t.Fatal("A missing attribute must return the default value, found:", fallback)
+	}
+
+	found.SetHandle("apple")
+	mustNil(t, found.Save())
+
+	// the entity read before the save is stale
+	entity.SetHandle("stale")
+	mustBe(t, entity.Save(), entitystore.ErrVersionConflict)
+}
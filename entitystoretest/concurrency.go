@@ -0,0 +1,90 @@
+package entitystoretest
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// concurrencyWorkers the number of goroutines using the store at the same time
+const concurrencyWorkers = 10
+
+func testConcurrency(t *testing.T, store entitystore.StoreInterface) {
+	shared := entityCreate(t, store, "counter", "shared", map[string]string{"title": "Shared"})
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, concurrencyWorkers*10)
+
+	for worker := 0; worker < concurrencyWorkers; worker++ {
+		wg.Add(1)
+
+		go func(worker int) {
+			defer wg.Done()
+
+			entity, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{
+				"worker": strconv.Itoa(worker),
+			})
+
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			if err := store.AttributeSetString(entity.ID(), "title", "Post "+strconv.Itoa(worker)); err != nil {
+				errs <- err
+				return
+			}
+
+			if err := store.AttributeSetInt(shared.ID(), "worker_"+strconv.Itoa(worker), int64(worker)); err != nil {
+				errs <- err
+				return
+			}
+
+			if _, err := store.EntityList(entitystore.EntityQueryOptions{EntityType: "post"}); err != nil {
+				errs <- err
+				return
+			}
+
+			if _, err := store.AttributeFind(shared.ID(), "title"); err != nil {
+				errs <- err
+				return
+			}
+
+			if worker%2 == 0 {
+				if _, err := store.EntityTrash(entity.ID()); err != nil {
+					errs <- err
+				}
+			}
+		}(worker)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "post"})
+	mustNil(t, err)
+
+	if count != concurrencyWorkers/2 {
+		t.Fatal("Must keep the entities not trashed, found:", count)
+	}
+
+	attributes, err := store.EntityAttributeList(shared.ID())
+	mustNil(t, err)
+
+	if len(attributes) != concurrencyWorkers+1 {
+		t.Fatal("Must keep the attributes set by all the workers, found:", len(attributes))
+	}
+
+	entity, err := store.EntityFindByID(shared.ID())
+	mustNil(t, err)
+
+	if entity.Version() != int64(concurrencyWorkers+2) {
+		t.Fatal("Must increment the version once per change, found:", entity.Version())
+	}
+}
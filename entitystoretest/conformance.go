@@ -0,0 +1,142 @@
+// Package entitystoretest verifies that an implementation of
+// entitystore.StoreInterface behaves like the SQL store - the alternate
+// backends, the wrappers and the decorators alike.
+//
+//	func TestConformance(t *testing.T) {
+//		entitystoretest.RunConformance(t, func() entitystore.StoreInterface {
+//			return NewCachingStore(newEmptyStore(t))
+//		})
+//	}
+//
+// The links, the hierarchy, the tenancy, the history and the outbox are
+// optional features, their tests are skipped if the store reports them
+// as not enabled with entitystore.ErrNotEnabled.
+package entitystoretest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// RunConformance runs the conformance tests as subtests of t.
+// The factory is called for every subtest, and must return an empty store
+func RunConformance(t *testing.T, factory func() entitystore.StoreInterface) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store entitystore.StoreInterface)
+	}{
+		{"EntityCreate", testEntityCreate},
+		{"EntityFind", testEntityFind},
+		{"EntityUpdate", testEntityUpdate},
+		{"EntityUpdateIfVersion", testEntityUpdateIfVersion},
+		{"EntityDelete", testEntityDelete},
+		{"AttributeUpsert", testAttributeUpsert},
+		{"AttributeTypes", testAttributeTypes},
+		{"AttributeDelete", testAttributeDelete},
+		{"EntityHelpers", testEntityHelpers},
+		{"EntityTrash", testEntityTrash},
+		{"EntityQuery", testEntityQuery},
+		{"EntityQueryAttributes", testEntityQueryAttributes},
+		{"AttributeQuery", testAttributeQuery},
+		{"AttributeAggregate", testAttributeAggregate},
+		{"AttributeFacets", testAttributeFacets},
+		{"SchemaIntrospection", testSchemaIntrospection},
+		{"InvalidArguments", testInvalidArguments},
+		{"Hooks", testHooks},
+		{"Subscribe", testSubscribe},
+		{"ExportImport", testExportImport},
+		{"Links", testLinks},
+		{"Hierarchy", testHierarchy},
+		{"Tenancy", testTenancy},
+		{"History", testHistory},
+		{"Outbox", testOutbox},
+		{"Transaction", testTransaction},
+		{"Concurrency", testConcurrency},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := factory()
+
+			if store == nil {
+				t.Fatal("The factory must return a store")
+			}
+
+			test.test(t, store)
+		})
+	}
+}
+
+// mustNil fails the test if err is not nil
+func mustNil(t *testing.T, err error) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+}
+
+// mustBe fails the test if err does not wrap the target error
+func mustBe(t *testing.T, err error, target error) {
+	t.Helper()
+
+	if !errors.Is(err, target) {
+		t.Fatal("Must be", target, "found:", err)
+	}
+
+	var opErr *entitystore.OpError
+
+	if !errors.As(err, &opErr) {
+		t.Fatal("Must be an OpError, found:", err)
+	}
+}
+
+// skipIfNotEnabled skips the test if err reports that
+// the optional feature is not enabled in the store
+func skipIfNotEnabled(t *testing.T, err error) {
+	t.Helper()
+
+	if errors.Is(err, entitystore.ErrNotEnabled) {
+		t.Skip("Skipped:", err.Error())
+	}
+}
+
+// entityCreate creates an entity with the handle and the attributes
+func entityCreate(t *testing.T, store entitystore.StoreInterface, entityType string, handle string, attributes map[string]string) *entitystore.Entity {
+	t.Helper()
+
+	entity := store.NewEntity(entitystore.NewEntityOptions{
+		Type:   entityType,
+		Handle: handle,
+	})
+
+	mustNil(t, store.EntityCreate(&entity))
+	mustNil(t, store.AttributesSet(entity.ID(), attributes))
+
+	return &entity
+}
+
+// entityIDs the IDs of the entities, in order
+func entityIDs(entities []entitystore.Entity) []string {
+	ids := []string{}
+
+	for _, entity := range entities {
+		ids = append(ids, entity.ID())
+	}
+
+	return ids
+}
+
+// handles the handles of the entities, in order
+func handles(entities []entitystore.Entity) string {
+	list := []string{}
+
+	for _, entity := range entities {
+		list = append(list, entity.Handle())
+	}
+
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,75 @@
+package entitystoretest
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+	"github.com/gouniverse/entitystore/memstore"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func sqlStoreFactory(t *testing.T, opts entitystore.NewStoreOptions) func() entitystore.StoreInterface {
+	count := 0
+
+	return func() entitystore.StoreInterface {
+		count++
+
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test_conformance_"+strconv.Itoa(count)+".db")+"?_txlock=immediate")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		t.Cleanup(func() { _ = db.Close() })
+
+		opts.DB = db
+		opts.EntityTableName = "cms_entity"
+		opts.AttributeTableName = "cms_attribute"
+		opts.AutomigrateEnabled = true
+
+		store, err := entitystore.NewStore(opts)
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		return store
+	}
+}
+
+func TestConformanceSQLStore(t *testing.T) {
+	RunConformance(t, sqlStoreFactory(t, entitystore.NewStoreOptions{}))
+}
+
+func TestConformanceSQLStoreAllFeatures(t *testing.T) {
+	RunConformance(t, sqlStoreFactory(t, entitystore.NewStoreOptions{
+		AttributeHistoryTableName: "cms_attribute_history",
+		OutboxTableName:           "cms_outbox",
+		LinkTableName:             "cms_link",
+		HierarchyEnabled:          true,
+		TenancyEnabled:            true,
+		Projections: []entitystore.Projection{{
+			EntityType: "post",
+			TableName:  "cms_post",
+			Columns:    map[string]string{"status": entitystore.VALUE_TYPE_STRING, "title": entitystore.VALUE_TYPE_STRING},
+		}},
+	}))
+}
+
+func TestConformanceMemStore(t *testing.T) {
+	RunConformance(t, func() entitystore.StoreInterface {
+		store, err := memstore.NewStore(memstore.NewStoreOptions{
+			AttributeHistoryEnabled: true,
+			OutboxEnabled:           true,
+		})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		return store
+	})
+}
@@ -0,0 +1,202 @@
+package entitystoretest
+
+import (
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testEntityCreate(t *testing.T, store entitystore.StoreInterface) {
+	entity, err := store.EntityCreateWithType("post")
+	mustNil(t, err)
+
+	if entity.ID() == "" {
+		t.Fatal("The ID must be assigned")
+	}
+
+	if entity.Version() != 1 {
+		t.Fatal("The version must start at 1, found:", entity.Version())
+	}
+
+	if entity.CreatedAt().IsZero() || entity.UpdatedAt().IsZero() {
+		t.Fatal("The timestamps must be set")
+	}
+
+	withID := store.NewEntity(entitystore.NewEntityOptions{ID: "conformance-entity", Type: "post", Handle: "hello"})
+	mustNil(t, store.EntityCreate(&withID))
+
+	if withID.ID() != "conformance-entity" {
+		t.Fatal("The ID must be kept, found:", withID.ID())
+	}
+
+	withAttributes, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Hello", "body": "World"})
+	mustNil(t, err)
+
+	attributes, err := store.EntityAttributeList(withAttributes.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 2 {
+		t.Fatal("Must have 2 attributes, found:", len(attributes))
+	}
+
+	mustBe(t, store.EntityCreate(nil), entitystore.ErrInvalidArgument)
+}
+
+func testEntityFind(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", map[string]string{"title": "Hello"})
+	entityCreate(t, store, "page", "hello", map[string]string{"title": "Page"})
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found == nil {
+		t.Fatal("Entity must be found")
+	}
+
+	if found.Type() != "post" || found.Handle() != "hello" {
+		t.Fatal("Must find the entity created, found:", found.Type(), found.Handle())
+	}
+
+	missing, err := store.EntityFindByID("missing")
+	mustNil(t, err)
+
+	if missing != nil {
+		t.Fatal("A missing entity must be NIL")
+	}
+
+	byHandle, err := store.EntityFindByHandle("post", "hello")
+	mustNil(t, err)
+
+	if byHandle == nil || byHandle.ID() != entity.ID() {
+		t.Fatal("Must find the entity of the type by handle")
+	}
+
+	byHandle, err = store.EntityFindByHandle("post", "missing")
+	mustNil(t, err)
+
+	if byHandle != nil {
+		t.Fatal("A missing handle must be NIL")
+	}
+
+	byAttribute, err := store.EntityFindByAttribute("post", "title", "Hello")
+	mustNil(t, err)
+
+	if byAttribute == nil || byAttribute.ID() != entity.ID() {
+		t.Fatal("Must find the entity of the type by attribute")
+	}
+
+	byAttribute, err = store.EntityFindByAttribute("post", "title", "Page")
+	mustNil(t, err)
+
+	if byAttribute != nil {
+		t.Fatal("The entities of other types must not be found by attribute")
+	}
+
+	list, err := store.EntityListByAttribute("page", "title", "Page")
+	mustNil(t, err)
+
+	if len(list) != 1 || list[0].Type() != "page" {
+		t.Fatal("Must list the entity of the type by attribute, found:", len(list))
+	}
+}
+
+func testEntityUpdate(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", nil)
+
+	entity.SetHandle("updated")
+	mustNil(t, store.EntityUpdate(*entity))
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found.Handle() != "updated" {
+		t.Fatal("The handle must be updated, found:", found.Handle())
+	}
+
+	if found.Version() != 2 {
+		t.Fatal("The version must be incremented, found:", found.Version())
+	}
+
+	mustNil(t, store.AttributeSetString(entity.ID(), "title", "Hello"))
+
+	found, err = store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found.Version() != 3 {
+		t.Fatal("An attribute change must increment the version, found:", found.Version())
+	}
+
+	missing := store.NewEntity(entitystore.NewEntityOptions{ID: "missing", Type: "post"})
+	mustBe(t, store.EntityUpdate(missing), entitystore.ErrEntityNotFound)
+}
+
+func testEntityUpdateIfVersion(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", nil)
+
+	entity.SetHandle("first")
+	mustNil(t, store.EntityUpdateIfVersion(*entity, 1))
+
+	entity.SetHandle("second")
+	mustBe(t, store.EntityUpdateIfVersion(*entity, 1), entitystore.ErrVersionConflict)
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found.Handle() != "first" {
+		t.Fatal("A conflicting update must not be applied, found:", found.Handle())
+	}
+
+	missing := store.NewEntity(entitystore.NewEntityOptions{ID: "missing", Type: "post"})
+	mustBe(t, store.EntityUpdateIfVersion(missing, 1), entitystore.ErrEntityNotFound)
+
+	// the entity returned has the version bumped by its attributes
+	withAttributes, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Hello", "body": "World"})
+	mustNil(t, err)
+
+	if withAttributes.Version() != 3 {
+		t.Fatal("Version must be 3 after creating 2 attributes, found:", withAttributes.Version())
+	}
+
+	withAttributes.SetHandle("saved")
+	mustNil(t, withAttributes.Save())
+}
+
+func testEntityDelete(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", map[string]string{"title": "Hello", "body": "World"})
+	other := entityCreate(t, store, "post", "other", map[string]string{"title": "Other"})
+
+	isDeleted, err := store.EntityDelete(entity.ID())
+	mustNil(t, err)
+
+	if !isDeleted {
+		t.Fatal("Entity must be deleted")
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found != nil {
+		t.Fatal("A deleted entity must not be found")
+	}
+
+	attributes, err := store.AttributeList(entitystore.AttributeQueryOptions{EntityID: entity.ID()})
+	mustNil(t, err)
+
+	if len(attributes) != 0 {
+		t.Fatal("The attributes must be deleted with the entity, found:", len(attributes))
+	}
+
+	attributes, err = store.EntityAttributeList(other.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 1 {
+		t.Fatal("The attributes of other entities must be kept, found:", len(attributes))
+	}
+
+	// a deleted entity cannot be restored
+	_, err = store.EntityRestore(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	_, err = store.EntityDelete(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+}
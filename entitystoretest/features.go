@@ -0,0 +1,552 @@
+package entitystoretest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testInvalidArguments(t *testing.T, store entitystore.StoreInterface) {
+	_, err := store.EntityTrash("")
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+
+	_, err = store.EntityRestore("")
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+
+	_, err = store.EntityDelete("")
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+
+	_, err = store.AttributeDelete("", "title")
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+
+	_, err = store.AttributeDelete("entity", "")
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+
+	_, err = store.Import(context.Background(), nil, entitystore.ImportOptions{})
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+
+	_, err = store.Import(context.Background(), bytes.NewBufferString(""), entitystore.ImportOptions{OnConflict: "unknown"})
+	mustBe(t, err, entitystore.ErrInvalidArgument)
+}
+
+func testHooks(t *testing.T, store entitystore.StoreInterface) {
+	errForbidden := errors.New("forbidden")
+	type contextKey struct{}
+	actors := []any{}
+
+	store.BeforeEntityCreate(func(ctx context.Context, entity *entitystore.Entity) error {
+		actors = append(actors, ctx.Value(contextKey{}))
+
+		if entity.Type() == "forbidden" {
+			return errForbidden
+		}
+
+		return nil
+	})
+
+	store.AfterAttributeSet(func(ctx context.Context, attribute *entitystore.Attribute) error {
+		if attribute.AttributeValue() == "invalid" {
+			return errForbidden
+		}
+
+		return nil
+	})
+
+	store.BeforeEntityTrash(func(ctx context.Context, entity *entitystore.Entity) error {
+		if entity.Handle() == "locked" {
+			return errForbidden
+		}
+
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "alice")
+
+	_, err := store.WithContext(ctx).EntityCreateWithType("post")
+	mustNil(t, err)
+
+	if len(actors) != 1 || actors[0] != "alice" {
+		t.Fatal("The hooks must receive the context of the view, found:", actors)
+	}
+
+	_, err = store.EntityCreateWithType("forbidden")
+
+	if !errors.Is(err, errForbidden) {
+		t.Fatal("Must return the error of the hook, found:", err)
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "forbidden"})
+	mustNil(t, err)
+
+	if count != 0 {
+		t.Fatal("An entity rejected by a hook must not be created")
+	}
+
+	entity := entityCreate(t, store, "post", "locked", map[string]string{"title": "Valid"})
+
+	if err := store.AttributeSetString(entity.ID(), "title", "invalid"); !errors.Is(err, errForbidden) {
+		t.Fatal("Must return the error of the hook, found:", err)
+	}
+
+	title, err := store.AttributeFind(entity.ID(), "title")
+	mustNil(t, err)
+
+	if title.AttributeValue() != "Valid" {
+		t.Fatal("A change failing an after hook must be rolled back, found:", title.AttributeValue())
+	}
+
+	// the attributes are set all or none
+	err = store.AttributesSet(entity.ID(), map[string]string{"a": "1", "b": "2", "c": "3", "title": "invalid"})
+
+	if !errors.Is(err, errForbidden) {
+		t.Fatal("Must return the error of the hook, found:", err)
+	}
+
+	attributes, err := store.EntityAttributeList(entity.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 1 {
+		t.Fatal("No attribute must be set when one fails, found:", len(attributes))
+	}
+
+	if _, err := store.EntityTrash(entity.ID()); !errors.Is(err, errForbidden) {
+		t.Fatal("Must return the error of the hook, found:", err)
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found == nil {
+		t.Fatal("An entity the hook refused to trash must be kept")
+	}
+}
+
+func testSubscribe(t *testing.T, store entitystore.StoreInterface) {
+	events, cancel := store.Subscribe(entitystore.ChangeFilter{EntityTypes: []string{"post"}})
+	defer cancel()
+
+	entity := entityCreate(t, store, "post", "hello", nil)
+	entityCreate(t, store, "page", "ignored", nil)
+	mustNil(t, store.AttributeSetString(entity.ID(), "title", "Hello"))
+
+	// a rolled back change is not published
+	store.BeforeEntityTrash(func(ctx context.Context, entity *entitystore.Entity) error {
+		return errors.New("forbidden")
+	})
+
+	if _, err := store.EntityTrash(entity.ID()); err == nil {
+		t.Fatal("Must return the error of the hook")
+	}
+
+	mustNil(t, store.EntityUpdate(*entity))
+
+	expected := []string{entitystore.CHANGE_ENTITY_CREATE, entitystore.CHANGE_ATTRIBUTE_SET, entitystore.CHANGE_ENTITY_UPDATE}
+
+	for _, operation := range expected {
+		select {
+		case event := <-events:
+			if event.Operation != operation || event.EntityID != entity.ID() {
+				t.Fatal("Must receive", operation, "found:", event.Operation, event.EntityType)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Must receive", operation)
+		}
+	}
+
+	select {
+	case event := <-events:
+		t.Fatal("Must not receive more events, found:", event.Operation, event.EntityType)
+	default:
+	}
+
+	cancel()
+
+	if _, open := <-events; open {
+		t.Fatal("The channel must be closed when cancelled")
+	}
+}
+
+func testExportImport(t *testing.T, store entitystore.StoreInterface) {
+	kept := entityCreate(t, store, "post", "kept", map[string]string{"title": "Kept"})
+	trashed := entityCreate(t, store, "post", "trashed", map[string]string{"title": "Trashed"})
+
+	_, err := store.EntityTrash(trashed.ID())
+	mustNil(t, err)
+
+	buffer := bytes.Buffer{}
+	mustNil(t, store.Export(context.Background(), &buffer, entitystore.ExportOptions{IncludeTrash: true}))
+	exported := buffer.String()
+
+	_, err = store.EntityDelete(kept.ID())
+	mustNil(t, err)
+
+	result, err := store.Import(context.Background(), bytes.NewBufferString(exported), entitystore.ImportOptions{
+		PreserveIDs: true,
+		OnConflict:  entitystore.IMPORT_CONFLICT_SKIP,
+	})
+	mustNil(t, err)
+
+	if result.Created != 1 || result.Skipped != 1 {
+		t.Fatal("Must create the deleted entity and skip the trashed one, found:", result)
+	}
+
+	title, err := store.AttributeFind(kept.ID(), "title")
+	mustNil(t, err)
+
+	if title == nil || title.AttributeValue() != "Kept" {
+		t.Fatal("The attributes must be imported")
+	}
+
+	_, err = store.Import(context.Background(), bytes.NewBufferString(exported), entitystore.ImportOptions{PreserveIDs: true})
+	mustBe(t, err, entitystore.ErrDuplicate)
+
+	buffer.Reset()
+	mustNil(t, store.ExportCSV(context.Background(), &buffer, "post", entitystore.CSVOptions{}))
+
+	csvResult, err := store.ImportCSV(context.Background(), &buffer, "post", entitystore.CSVMapping{})
+	mustNil(t, err)
+
+	if csvResult.Updated != 1 || csvResult.Created != 0 || len(csvResult.Errors) != 0 {
+		t.Fatal("Must update the exported entity, found:", csvResult)
+	}
+}
+
+func testLinks(t *testing.T, store entitystore.StoreInterface) {
+	post := entityCreate(t, store, "post", "post", nil)
+	first := entityCreate(t, store, "tag", "first", nil)
+	second := entityCreate(t, store, "tag", "second", nil)
+
+	_, err := store.EntityLink(post.ID(), second.ID(), "tagged", entitystore.EntityLinkOptions{Position: 2})
+	skipIfNotEnabled(t, err)
+	mustNil(t, err)
+
+	_, err = store.EntityLink(post.ID(), first.ID(), "tagged", entitystore.EntityLinkOptions{Position: 1})
+	mustNil(t, err)
+
+	// linking again updates the link
+	_, err = store.EntityLink(post.ID(), first.ID(), "tagged", entitystore.EntityLinkOptions{Position: 3, Metadata: map[string]string{"by": "alice"}})
+	mustNil(t, err)
+
+	links, err := store.EntityLinks(post.ID(), "tagged", entitystore.LINK_DIRECTION_OUTGOING)
+	mustNil(t, err)
+
+	if len(links) != 2 || links[0].ToID != second.ID() || links[1].Metadata["by"] != "alice" {
+		t.Fatal("Must list the links by position, found:", links)
+	}
+
+	incoming, err := store.EntityLinks(first.ID(), "", entitystore.LINK_DIRECTION_INCOMING)
+	mustNil(t, err)
+
+	if len(incoming) != 1 || incoming[0].FromID != post.ID() {
+		t.Fatal("Must list the incoming links, found:", incoming)
+	}
+
+	related, err := store.EntityListRelated(post.ID(), "tagged", entitystore.EntityQueryOptions{})
+	mustNil(t, err)
+
+	if handles(related) != "second,first" {
+		t.Fatal("Must list the related entities by position, found:", handles(related))
+	}
+
+	_, err = store.EntityLink(post.ID(), "missing", "tagged", entitystore.EntityLinkOptions{})
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	isUnlinked, err := store.EntityUnlink(post.ID(), second.ID(), "tagged")
+	mustNil(t, err)
+
+	if !isUnlinked {
+		t.Fatal("Entities must be unlinked")
+	}
+
+	isUnlinked, err = store.EntityUnlink(post.ID(), second.ID(), "tagged")
+	mustNil(t, err)
+
+	if isUnlinked {
+		t.Fatal("Entities not linked must not be unlinked")
+	}
+
+	// deleting an entity deletes its links
+	_, err = store.EntityDelete(first.ID())
+	mustNil(t, err)
+
+	links, err = store.EntityLinks(post.ID(), "", entitystore.LINK_DIRECTION_BOTH)
+	mustNil(t, err)
+
+	if len(links) != 0 {
+		t.Fatal("The links of a deleted entity must be deleted, found:", len(links))
+	}
+}
+
+func testHierarchy(t *testing.T, store entitystore.StoreInterface) {
+	root := entityCreate(t, store, "folder", "root", nil)
+	child := entityCreate(t, store, "folder", "child", nil)
+	grandchild := entityCreate(t, store, "file", "grandchild", nil)
+
+	err := store.EntitySetParent(child.ID(), root.ID())
+	skipIfNotEnabled(t, err)
+	mustNil(t, err)
+
+	mustNil(t, store.EntitySetParent(grandchild.ID(), child.ID()))
+
+	children, err := store.EntityChildren(root.ID())
+	mustNil(t, err)
+
+	if handles(children) != "child" {
+		t.Fatal("Must list the children, found:", handles(children))
+	}
+
+	descendants, err := store.EntityDescendants(root.ID(), 0)
+	mustNil(t, err)
+
+	if handles(descendants) != "child,grandchild" {
+		t.Fatal("Must list the descendants nearest first, found:", handles(descendants))
+	}
+
+	descendants, err = store.EntityDescendants(root.ID(), 1)
+	mustNil(t, err)
+
+	if handles(descendants) != "child" {
+		t.Fatal("Must list the descendants up to the depth, found:", handles(descendants))
+	}
+
+	ancestors, err := store.EntityAncestors(grandchild.ID())
+	mustNil(t, err)
+
+	if handles(ancestors) != "child,root" {
+		t.Fatal("Must list the ancestors parent first, found:", handles(ancestors))
+	}
+
+	list, err := store.EntityList(entitystore.EntityQueryOptions{ParentID: child.ID()})
+	mustNil(t, err)
+
+	if handles(list) != "grandchild" {
+		t.Fatal("Must list the entities by parent, found:", handles(list))
+	}
+
+	mustBe(t, store.EntitySetParent(root.ID(), grandchild.ID()), entitystore.ErrInvalidArgument)
+	mustBe(t, store.EntitySetParent(root.ID(), root.ID()), entitystore.ErrInvalidArgument)
+	mustBe(t, store.EntitySetParent(root.ID(), "missing"), entitystore.ErrEntityNotFound)
+
+	mustNil(t, store.EntitySetParent(grandchild.ID(), ""))
+
+	children, err = store.EntityChildren(child.ID())
+	mustNil(t, err)
+
+	if len(children) != 0 {
+		t.Fatal("An entity moved to the root must not be a child, found:", handles(children))
+	}
+
+	// a child imported before its parent is linked to the new parent
+	records := `{"id":"CHILD","type":"page","handle":"imported-child","parent_id":"PARENT","attributes":{}}
+{"id":"PARENT","type":"page","handle":"imported-parent","attributes":{}}
+`
+
+	_, err = store.Import(context.Background(), bytes.NewBufferString(records), entitystore.ImportOptions{BatchSize: 1})
+	mustNil(t, err)
+
+	importedParent, err := store.EntityFindByHandle("page", "imported-parent")
+	mustNil(t, err)
+
+	importedChild, err := store.EntityFindByHandle("page", "imported-child")
+	mustNil(t, err)
+
+	if importedParent == nil || importedChild == nil || importedChild.ParentID() != importedParent.ID() {
+		t.Fatal("Must link the child imported before its parent")
+	}
+
+	orphan := `{"id":"ORPHAN","type":"page","handle":"orphan","parent_id":"MISSING","attributes":{}}
+`
+
+	_, err = store.Import(context.Background(), bytes.NewBufferString(orphan), entitystore.ImportOptions{})
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+}
+
+func testTenancy(t *testing.T, store entitystore.StoreInterface) {
+	acme := store.ForTenant("acme")
+	globex := store.ForTenant("globex")
+
+	entity, err := acme.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Acme"})
+
+	if err != nil {
+		t.Skip("Skipped, the tenant views are not supported:", err.Error())
+	}
+
+	if entity.TenantID() != "acme" {
+		t.Fatal("The entity must belong to the tenant, found:", entity.TenantID())
+	}
+
+	_, err = globex.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Globex"})
+	mustNil(t, err)
+
+	found, err := globex.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found != nil {
+		t.Fatal("The entities of other tenants must not be found")
+	}
+
+	title, err := globex.AttributeFind(entity.ID(), "title")
+	mustNil(t, err)
+
+	if title != nil {
+		t.Fatal("The attributes of other tenants must not be found")
+	}
+
+	_, err = globex.EntityTrash(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	_, err = globex.EntityDelete(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	list, err := acme.EntityList(entitystore.EntityQueryOptions{EntityType: "post"})
+	mustNil(t, err)
+
+	if len(list) != 1 || list[0].ID() != entity.ID() {
+		t.Fatal("Must list the entities of the tenant only, found:", len(list))
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "post"})
+	mustNil(t, err)
+
+	if count != 2 {
+		t.Fatal("The store must see the entities of all tenants, found:", count)
+	}
+}
+
+func testHistory(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", nil)
+
+	_, err := store.EntityHistory(entity.ID(), entitystore.EntityHistoryOptions{})
+	skipIfNotEnabled(t, err)
+	mustNil(t, err)
+
+	mustNil(t, store.WithActor("alice").AttributeSetString(entity.ID(), "title", "First"))
+	mustNil(t, store.AttributeSetString(entity.ID(), "title", "Second"))
+	mustNil(t, store.AttributeSetString(entity.ID(), "body", "Text"))
+
+	_, err = store.AttributeDelete(entity.ID(), "title")
+	mustNil(t, err)
+
+	history, err := store.AttributeHistory(entity.ID(), "title")
+	mustNil(t, err)
+
+	if len(history) != 3 {
+		t.Fatal("Must record 3 changes, found:", len(history))
+	}
+
+	expected := []entitystore.AttributeHistoryEntry{
+		{Operation: entitystore.OPERATION_CREATE, OldValue: "", NewValue: "First", ChangedBy: "alice"},
+		{Operation: entitystore.OPERATION_UPDATE, OldValue: "First", NewValue: "Second"},
+		{Operation: entitystore.OPERATION_DELETE, OldValue: "Second", NewValue: ""},
+	}
+
+	for i, entry := range history {
+		if entry.Operation != expected[i].Operation || entry.OldValue != expected[i].OldValue || entry.NewValue != expected[i].NewValue || entry.ChangedBy != expected[i].ChangedBy {
+			t.Fatal("Change", i, "must be", expected[i], "found:", entry)
+		}
+	}
+
+	all, err := store.EntityHistory(entity.ID(), entitystore.EntityHistoryOptions{SortOrder: "desc", Limit: 2})
+	mustNil(t, err)
+
+	if len(all) != 2 || all[0].Operation != entitystore.OPERATION_DELETE {
+		t.Fatal("Must list the newest changes first, found:", all)
+	}
+}
+
+func testOutbox(t *testing.T, store entitystore.StoreInterface) {
+	_, err := store.ChangesSince(0, 0)
+	skipIfNotEnabled(t, err)
+	mustNil(t, err)
+
+	entity := entityCreate(t, store, "post", "hello", nil)
+	mustNil(t, store.AttributeSetString(entity.ID(), "title", "Hello"))
+
+	changes, err := store.ChangesSince(0, 0)
+	mustNil(t, err)
+
+	if len(changes) != 2 || changes[0].Operation != entitystore.CHANGE_ENTITY_CREATE || changes[1].Operation != entitystore.CHANGE_ATTRIBUTE_SET {
+		t.Fatal("Must record the changes in order, found:", changes)
+	}
+
+	if changes[0].Sequence >= changes[1].Sequence {
+		t.Fatal("Must number the changes in order, found:", changes)
+	}
+
+	after, err := store.ChangesSince(changes[0].Sequence, 0)
+	mustNil(t, err)
+
+	if len(after) != 1 || after[0].ID != changes[1].ID {
+		t.Fatal("Must list the changes after the cursor, found:", after)
+	}
+
+	mustNil(t, store.AckChanges([]string{changes[0].ID}))
+
+	changes, err = store.ChangesSince(0, 0)
+	mustNil(t, err)
+
+	if len(changes) != 1 {
+		t.Fatal("The acknowledged changes must be removed, found:", len(changes))
+	}
+
+	_, err = store.ForTenant("acme").ChangesSince(0, 0)
+
+	if err == nil {
+		t.Fatal("The outbox must not be available in a tenant view")
+	}
+}
+
+func testTransaction(t *testing.T, store entitystore.StoreInterface) {
+	failure := errors.New("failure")
+
+	err := store.Transaction(func(tx entitystore.StoreInterface) error {
+		entity, err := tx.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Rolled back"})
+
+		if err != nil {
+			return err
+		}
+
+		if err := tx.AttributeSetString(entity.ID(), "body", "Body"); err != nil {
+			return err
+		}
+
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Fatal("Must return the error of the function, found:", err)
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "post"})
+	mustNil(t, err)
+
+	if count != 0 {
+		t.Fatal("A failed transaction must be rolled back, found:", count)
+	}
+
+	var entityID string
+
+	err = store.Transaction(func(tx entitystore.StoreInterface) error {
+		entity, err := tx.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Committed"})
+
+		if err != nil {
+			return err
+		}
+
+		entityID = entity.ID()
+
+		return tx.AttributeSetString(entity.ID(), "body", "Body")
+	})
+	mustNil(t, err)
+
+	body, err := store.AttributeFind(entityID, "body")
+	mustNil(t, err)
+
+	if body == nil || body.AttributeValue() != "Body" {
+		t.Fatal("A transaction must be committed")
+	}
+}
@@ -0,0 +1,155 @@
+package entitystoretest
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testEntityQuery(t *testing.T, store entitystore.StoreInterface) {
+	entities := map[string]*entitystore.Entity{}
+
+	for _, handle := range []string{"c", "a", "d", "b"} {
+		entities[handle] = entityCreate(t, store, "post", handle, nil)
+	}
+
+	page := entityCreate(t, store, "page", "e", nil)
+
+	cases := []struct {
+		name     string
+		options  entitystore.EntityQueryOptions
+		expected string
+	}{
+		{"type", entitystore.EntityQueryOptions{EntityType: "page"}, "e"},
+		{"handle", entitystore.EntityQueryOptions{EntityType: "post", EntityHandle: "b"}, "b"},
+		{"id", entitystore.EntityQueryOptions{ID: entities["d"].ID()}, "d"},
+		{"sort asc", entitystore.EntityQueryOptions{EntityType: "post", SortBy: entitystore.COLUMN_ENTITY_HANDLE, SortOrder: "asc"}, "a,b,c,d"},
+		{"sort desc", entitystore.EntityQueryOptions{EntityType: "post", SortBy: entitystore.COLUMN_ENTITY_HANDLE, SortOrder: "desc"}, "d,c,b,a"},
+		{"limit", entitystore.EntityQueryOptions{EntityType: "post", SortBy: entitystore.COLUMN_ENTITY_HANDLE, Limit: 2}, "a,b"},
+		{"offset", entitystore.EntityQueryOptions{EntityType: "post", SortBy: entitystore.COLUMN_ENTITY_HANDLE, Offset: 1, Limit: 2}, "b,c"},
+		{"offset past the end", entitystore.EntityQueryOptions{EntityType: "post", Offset: 10, Limit: 2}, ""},
+		{"ids", entitystore.EntityQueryOptions{IDs: []string{entities["a"].ID(), page.ID()}, SortBy: entitystore.COLUMN_ENTITY_HANDLE}, "a,e"},
+		{"no match", entitystore.EntityQueryOptions{EntityType: "missing"}, ""},
+	}
+
+	for _, c := range cases {
+		list, err := store.EntityList(c.options)
+		mustNil(t, err)
+
+		if handles(list) != c.expected {
+			t.Fatal("Query", c.name, "must find", c.expected, "found:", handles(list))
+		}
+	}
+
+	// the entities are sorted by ID by default
+	list, err := store.EntityList(entitystore.EntityQueryOptions{})
+	mustNil(t, err)
+
+	ids := entityIDs(list)
+
+	if len(ids) != 5 || !sort.StringsAreSorted(ids) {
+		t.Fatal("The entities must be sorted by ID, found:", ids)
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "post", Offset: 1, Limit: 1})
+	mustNil(t, err)
+
+	if count != 4 {
+		t.Fatal("The count must ignore the limit and the offset, found:", count)
+	}
+
+	count, err = store.EntityCount(entitystore.EntityQueryOptions{})
+	mustNil(t, err)
+
+	if count != 5 {
+		t.Fatal("Must count all the entities, found:", count)
+	}
+}
+
+func testEntityQueryAttributes(t *testing.T, store entitystore.StoreInterface) {
+	entityCreate(t, store, "post", "a", map[string]string{"status": "draft", "title": "c"})
+	entityCreate(t, store, "post", "b", map[string]string{"status": "published", "title": "a"})
+	entityCreate(t, store, "post", "c", map[string]string{"status": "published", "title": "b"})
+	entityCreate(t, store, "post", "d", map[string]string{"status": "published", "title": "b", "pinned": "yes"})
+	entityCreate(t, store, "page", "e", map[string]string{"status": "published", "title": "a"})
+
+	cases := []struct {
+		name     string
+		options  entitystore.EntityQueryOptions
+		expected string
+	}{
+		{"where", entitystore.EntityQueryOptions{EntityType: "post", Where: map[string]string{"status": "published"}, SortBy: entitystore.COLUMN_ENTITY_HANDLE}, "b,c,d"},
+		{"where all", entitystore.EntityQueryOptions{EntityType: "post", Where: map[string]string{"status": "published", "pinned": "yes"}}, "d"},
+		{"where no match", entitystore.EntityQueryOptions{EntityType: "post", Where: map[string]string{"status": "archived"}}, ""},
+		{"sort by attribute", entitystore.EntityQueryOptions{EntityType: "post", SortByAttribute: "title"}, "b,c,d,a"},
+		{"sort by attribute desc", entitystore.EntityQueryOptions{EntityType: "post", SortByAttribute: "title", SortOrder: "desc"}, "a,c,d,b"},
+		{"where and sort", entitystore.EntityQueryOptions{EntityType: "post", Where: map[string]string{"status": "published"}, SortByAttribute: "title", Limit: 2}, "b,c"},
+	}
+
+	for _, c := range cases {
+		list, err := store.EntityList(c.options)
+		mustNil(t, err)
+
+		if handles(list) != c.expected {
+			t.Fatal("Query", c.name, "must find", c.expected, "found:", handles(list))
+		}
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "post", Where: map[string]string{"status": "published"}, SortByAttribute: "title"})
+	mustNil(t, err)
+
+	if count != 3 {
+		t.Fatal("Must count the entities with the attribute value, found:", count)
+	}
+}
+
+func testAttributeQuery(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", map[string]string{"a": "1", "b": "2", "c": "3"})
+	other := entityCreate(t, store, "post", "other", map[string]string{"a": "4"})
+
+	keys := func(attributes []entitystore.Attribute) string {
+		list := []string{}
+
+		for _, attribute := range attributes {
+			list = append(list, attribute.AttributeKey()+"="+attribute.AttributeValue())
+		}
+
+		return strings.Join(list, ",")
+	}
+
+	cases := []struct {
+		name     string
+		options  entitystore.AttributeQueryOptions
+		expected string
+	}{
+		{"entity", entitystore.AttributeQueryOptions{EntityID: other.ID()}, "a=4"},
+		{"key", entitystore.AttributeQueryOptions{AttributeKey: "a", SortBy: entitystore.COLUMN_ATTRIBUTE_VALUE}, "a=1,a=4"},
+		{"entity and key", entitystore.AttributeQueryOptions{EntityID: entity.ID(), AttributeKey: "b"}, "b=2"},
+		{"sort asc", entitystore.AttributeQueryOptions{EntityID: entity.ID(), SortBy: entitystore.COLUMN_ATTRIBUTE_KEY, SortOrder: "asc"}, "a=1,b=2,c=3"},
+		{"sort desc", entitystore.AttributeQueryOptions{EntityID: entity.ID(), SortBy: entitystore.COLUMN_ATTRIBUTE_KEY, SortOrder: "desc"}, "c=3,b=2,a=1"},
+		{"limit", entitystore.AttributeQueryOptions{EntityID: entity.ID(), SortBy: entitystore.COLUMN_ATTRIBUTE_KEY, Limit: 2}, "a=1,b=2"},
+		{"offset", entitystore.AttributeQueryOptions{EntityID: entity.ID(), SortBy: entitystore.COLUMN_ATTRIBUTE_KEY, Offset: 1, Limit: 1}, "b=2"},
+		{"no match", entitystore.AttributeQueryOptions{AttributeKey: "missing"}, ""},
+	}
+
+	for _, c := range cases {
+		list, err := store.AttributeList(c.options)
+		mustNil(t, err)
+
+		if keys(list) != c.expected {
+			t.Fatal("Query", c.name, "must find", c.expected, "found:", keys(list))
+		}
+	}
+
+	b, err := store.AttributeFind(entity.ID(), "b")
+	mustNil(t, err)
+
+	list, err := store.AttributeList(entitystore.AttributeQueryOptions{ID: b.ID()})
+	mustNil(t, err)
+
+	if keys(list) != "b=2" {
+		t.Fatal("Must find the attribute by ID, found:", keys(list))
+	}
+}
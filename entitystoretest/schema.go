@@ -0,0 +1,53 @@
+package entitystoretest
+
+import (
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testSchemaIntrospection(t *testing.T, store entitystore.StoreInterface) {
+	entityCreate(t, store, "product", "apple", map[string]string{"stock": "10", "price": "1.5"})
+	entityCreate(t, store, "product", "pear", map[string]string{"stock": "", "price": "2"})
+	entityCreate(t, store, "post", "hello", map[string]string{"title": "Hello", "published": "true"})
+	trashed := entityCreate(t, store, "page", "trashed", map[string]string{"title": "Trashed"})
+
+	_, err := store.EntityTrash(trashed.ID())
+	mustNil(t, err)
+
+	types, err := store.EntityTypeList()
+	mustNil(t, err)
+
+	if len(types) != 2 || types[0].EntityType != "post" || types[0].Count != 1 || types[1].EntityType != "product" || types[1].Count != 2 {
+		t.Fatal("Must list the types of the entities not trashed, found:", types)
+	}
+
+	if types[1].FirstCreatedAt.IsZero() || types[1].LastCreatedAt.Before(types[1].FirstCreatedAt) {
+		t.Fatal("Must have the creation times, found:", types[1])
+	}
+
+	keys, err := store.AttributeKeyList("product")
+	mustNil(t, err)
+
+	expected := []entitystore.AttributeKeyInfo{
+		{AttributeKey: "price", Count: 2, SampleValue: "1.5", ValueType: entitystore.VALUE_TYPE_FLOAT, MaxLength: 3},
+		{AttributeKey: "stock", Count: 2, SampleValue: "10", ValueType: entitystore.VALUE_TYPE_INT, MaxLength: 2},
+	}
+
+	if len(keys) != len(expected) {
+		t.Fatal("Must list", len(expected), "keys, found:", keys)
+	}
+
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Fatal("Key", i, "must be", expected[i], "found:", keys[i])
+		}
+	}
+
+	keys, err = store.AttributeKeyList("")
+	mustNil(t, err)
+
+	if len(keys) != 4 || keys[0].AttributeKey != "price" || keys[1].AttributeKey != "published" || keys[1].ValueType != entitystore.VALUE_TYPE_BOOL {
+		t.Fatal("Must list the keys of all the types, found:", keys)
+	}
+}
@@ -0,0 +1,84 @@
+package entitystoretest
+
+import (
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func testEntityTrash(t *testing.T, store entitystore.StoreInterface) {
+	entity := entityCreate(t, store, "post", "hello", map[string]string{"title": "Hello", "body": "World"})
+	other := entityCreate(t, store, "post", "other", nil)
+
+	isTrashed, err := store.EntityTrash(entity.ID())
+	mustNil(t, err)
+
+	if !isTrashed {
+		t.Fatal("Entity must be trashed")
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if found != nil {
+		t.Fatal("A trashed entity must not be found")
+	}
+
+	byHandle, err := store.EntityFindByHandle("post", "hello")
+	mustNil(t, err)
+
+	if byHandle != nil {
+		t.Fatal("A trashed entity must not be found by handle")
+	}
+
+	list, err := store.EntityList(entitystore.EntityQueryOptions{EntityType: "post"})
+	mustNil(t, err)
+
+	if len(list) != 1 || list[0].ID() != other.ID() {
+		t.Fatal("A trashed entity must not be listed, found:", len(list))
+	}
+
+	title, err := store.AttributeFind(entity.ID(), "title")
+	mustNil(t, err)
+
+	if title != nil {
+		t.Fatal("The attributes must be trashed with the entity")
+	}
+
+	_, err = store.EntityTrash(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	isRestored, err := store.EntityRestore(entity.ID())
+	mustNil(t, err)
+
+	if !isRestored {
+		t.Fatal("Entity must be restored")
+	}
+
+	restored, err := store.EntityFindByID(entity.ID())
+	mustNil(t, err)
+
+	if restored == nil || restored.Handle() != "hello" {
+		t.Fatal("The restored entity must be found")
+	}
+
+	attributes, err := store.EntityAttributeList(entity.ID())
+	mustNil(t, err)
+
+	if len(attributes) != 2 {
+		t.Fatal("The attributes must be restored with the entity, found:", len(attributes))
+	}
+
+	_, err = store.EntityRestore(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	_, err = store.EntityTrash("missing")
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+
+	// a trashed entity can be deleted for good only after being restored
+	_, err = store.EntityTrash(entity.ID())
+	mustNil(t, err)
+
+	_, err = store.EntityDelete(entity.ID())
+	mustBe(t, err, entitystore.ErrEntityNotFound)
+}
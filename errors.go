@@ -0,0 +1,100 @@
+package entitystore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrEntityNotFound is returned when the entity does not exist
+// (or is not visible to the tenant of the store view)
+var ErrEntityNotFound = errors.New("entity store: entity not found")
+
+// ErrAttributeNotFound is returned when the attribute does not exist
+var ErrAttributeNotFound = errors.New("entity store: attribute not found")
+
+// ErrInvalidArgument is returned when an argument is empty or malformed
+var ErrInvalidArgument = errors.New("entity store: invalid argument")
+
+// ErrDuplicate is returned when a record with the same ID
+// or unique value already exists
+var ErrDuplicate = errors.New("entity store: duplicate")
+
+// ErrVersionConflict is returned when an entity was modified
+// by someone else since it was read
+var ErrVersionConflict = errors.New("entity store: version conflict")
+
+// ErrNotEnabled is returned when an optional feature
+// (i.e. the outbox, the history, the links) is not enabled
+var ErrNotEnabled = errors.New("entity store: not enabled")
+
+// OpError records the store operation and the entity which caused an error.
+// Use errors.Is with the sentinel errors above to check the cause
+type OpError struct {
+	Op       string
+	EntityID string
+	Err      error
+}
+
+func (e *OpError) Error() string {
+	if e.EntityID == "" {
+		return e.Op + ": " + e.Err.Error()
+	}
+
+	return e.Op + " " + e.EntityID + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// wrapOpError wraps the error of a public method into an OpError.
+// It is deferred with the named error result. An OpError returned
+// by a nested public method is unwrapped, so the outermost operation wins
+func wrapOpError(err *error, op string, entityID string) {
+	if *err == nil {
+		return
+	}
+
+	if opErr, ok := (*err).(*OpError); ok {
+		if entityID == "" {
+			entityID = opErr.EntityID
+		}
+
+		*err = &OpError{Op: op, EntityID: entityID, Err: opErr.Err}
+		return
+	}
+
+	*err = &OpError{Op: op, EntityID: entityID, Err: *err}
+}
+
+// errInvalidArgument returns an error wrapping ErrInvalidArgument
+func errInvalidArgument(message string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidArgument, message)
+}
+
+// errNotEnabled returns an error wrapping ErrNotEnabled for the feature
+func errNotEnabled(feature string) error {
+	return fmt.Errorf("%w: %s", ErrNotEnabled, feature)
+}
+
+// errEntityNotFound returns an error wrapping ErrEntityNotFound
+func errEntityNotFound(entityID string) error {
+	return fmt.Errorf("%w: entity %s does not exist", ErrEntityNotFound, entityID)
+}
+
+// isDuplicateError checks whether the database error is a violation
+// of a primary key or unique constraint (sqlite, mysql and postgres)
+func isDuplicateError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	message := err.Error()
+
+	return strings.Contains(message, "UNIQUE constraint failed") ||
+		strings.Contains(message, "Duplicate entry") ||
+		strings.Contains(message, "Error 1062") ||
+		strings.Contains(message, "duplicate key value") ||
+		strings.Contains(message, "SQLSTATE 23505")
+}
@@ -0,0 +1,106 @@
+package entitystore
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	db := InitDB("test_errors.db")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	_, err = store.EntityTrash("missing")
+
+	if !errors.Is(err, ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound:", err)
+	}
+
+	var opErr *OpError
+
+	if !errors.As(err, &opErr) {
+		t.Fatal("Must be an OpError:", err)
+	}
+
+	if opErr.Op != "EntityTrash" || opErr.EntityID != "missing" {
+		t.Fatal("OpError must record the operation and the entity, found:", opErr.Op, opErr.EntityID)
+	}
+
+	_, err = store.EntityFindByID("")
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument:", err)
+	}
+
+	entity, err := store.EntityCreateWithType("post")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	_, err = store.AttributeDelete(entity.ID(), "title")
+
+	if !errors.Is(err, ErrAttributeNotFound) {
+		t.Fatal("Must be ErrAttributeNotFound:", err)
+	}
+
+	duplicate := store.NewEntity(NewEntityOptions{ID: entity.ID(), Type: "post"})
+	err = store.EntityCreate(&duplicate)
+
+	if !errors.Is(err, ErrDuplicate) {
+		t.Fatal("Must be ErrDuplicate:", err)
+	}
+
+	err = store.EntityUpdateIfVersion(*entity, entity.Version()+1)
+
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatal("Must be ErrVersionConflict:", err)
+	}
+
+	missing := store.NewEntity(NewEntityOptions{ID: "missing", Type: "post"})
+	err = store.EntityUpdate(missing)
+
+	if !errors.Is(err, ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound:", err)
+	}
+
+	_, err = store.ChangesSince(0, 10)
+
+	if !errors.Is(err, ErrNotEnabled) {
+		t.Fatal("Must be ErrNotEnabled:", err)
+	}
+
+	err = store.EntitySetParent(entity.ID(), "")
+
+	if !errors.Is(err, ErrNotEnabled) {
+		t.Fatal("Must be ErrNotEnabled:", err)
+	}
+
+	// the nested operations are flattened into the outer one
+	_, err = store.EntityCreateWithTypeAndAttributes("post", map[string]string{"": "value"})
+
+	if !errors.As(err, &opErr) {
+		t.Fatal("Must be an OpError:", err)
+	}
+
+	if opErr.Op != "EntityCreateWithTypeAndAttributes" {
+		t.Fatal("OpError must record the outer operation, found:", opErr.Op)
+	}
+
+	if errors.As(opErr.Err, new(*OpError)) {
+		t.Fatal("OpError must not wrap another OpError")
+	}
+
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument:", err)
+	}
+}
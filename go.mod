@@ -11,6 +11,7 @@ require (
 require (
 	github.com/dromara/carbon/v2 v2.6.1
 	github.com/georgysavva/scany v1.2.3
+	github.com/klauspost/compress v1.17.11
 )
 
 require (
@@ -20,7 +21,7 @@ require (
 )
 
 require (
-	github.com/gouniverse/maputils v0.7.0 // indirect
+	github.com/gouniverse/maputils v0.7.0
 	github.com/gouniverse/sb v0.8.0
 	github.com/samber/lo v1.49.1 // indirect
 	golang.org/x/text v0.23.0 // indirect
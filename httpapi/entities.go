@@ -0,0 +1,384 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// listLimitDefault the number of entities listed if no limit is given
+const listLimitDefault = 100
+
+// listLimitMax the maximum number of entities listed at once
+const listLimitMax = 1000
+
+// the sort_by query parameter values, mapped to the entity columns
+var sortColumns = map[string]string{
+	"id":         entitystore.COLUMN_ID,
+	"type":       entitystore.COLUMN_ENTITY_TYPE,
+	"handle":     entitystore.COLUMN_ENTITY_HANDLE,
+	"created_at": entitystore.COLUMN_CREATED_AT,
+	"updated_at": entitystore.COLUMN_UPDATED_AT,
+}
+
+// entityListResponse is the body of GET /entities
+type entityListResponse struct {
+	Entities []entitystore.ExportRecord `json:"entities"`
+	Total    int64                      `json:"total"`
+	Limit    uint64                     `json:"limit"`
+	Offset   uint64                     `json:"offset"`
+}
+
+// entityCreateRequest is the body of POST /entities
+type entityCreateRequest struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Handle     string            `json:"handle"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// entityUpdateRequest is the body of PATCH /entities/{id}. The attributes
+// set to null are deleted. With a version the entity is updated only
+// if it was not modified in the meantime
+type entityUpdateRequest struct {
+	Handle     *string            `json:"handle"`
+	Version    *int64             `json:"version"`
+	Attributes map[string]*string `json:"attributes"`
+}
+
+// attributeSetRequest is the body of PUT /entities/{id}/attributes/{key}
+type attributeSetRequest struct {
+	Value string `json:"value"`
+}
+
+// attributeResponse is the body of a set attribute
+type attributeResponse struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// entityList lists the entities matching the query parameters:
+// type, handle, parent_id, sort_by, sort_order, limit, offset,
+// and attributes=true to include the attributes
+func (h *handler) entityList(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	query := r.URL.Query()
+
+	options := entitystore.EntityQueryOptions{
+		EntityType:   query.Get("type"),
+		EntityHandle: query.Get("handle"),
+		ParentID:     query.Get("parent_id"),
+		SortOrder:    query.Get("sort_order"),
+		Limit:        listLimitDefault,
+	}
+
+	if sortBy := query.Get("sort_by"); sortBy != "" {
+		column, exists := sortColumns[sortBy]
+
+		if !exists {
+			writeError(w, http.StatusBadRequest, "unsupported sort_by "+sortBy)
+			return
+		}
+
+		options.SortBy = column
+	}
+
+	if options.SortOrder != "" && options.SortOrder != "asc" && options.SortOrder != "desc" {
+		writeError(w, http.StatusBadRequest, "unsupported sort_order "+options.SortOrder)
+		return
+	}
+
+	var err error
+
+	if limit := query.Get("limit"); limit != "" {
+		options.Limit, err = strconv.ParseUint(limit, 10, 64)
+
+		if err != nil || options.Limit < 1 || options.Limit > listLimitMax {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(listLimitMax))
+			return
+		}
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		options.Offset, err = strconv.ParseUint(offset, 10, 64)
+
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "offset must be a number")
+			return
+		}
+	}
+
+	withAttributes := query.Get("attributes") == "true"
+
+	entities, err := store.EntityList(options)
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	countOptions := options
+	countOptions.Limit = 0
+	countOptions.Offset = 0
+
+	total, err := store.EntityCount(countOptions)
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	response := entityListResponse{
+		Entities: []entitystore.ExportRecord{},
+		Total:    total,
+		Limit:    options.Limit,
+		Offset:   options.Offset,
+	}
+
+	for _, entity := range entities {
+		record, err := entityRecord(store, entity, withAttributes)
+
+		if err != nil {
+			writeStoreError(w, h.options.Logger, err)
+			return
+		}
+
+		response.Entities = append(response.Entities, record)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// entityCreate creates an entity with its attributes, in a transaction
+func (h *handler) entityCreate(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	request := entityCreateRequest{}
+
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	if request.Type == "" {
+		writeError(w, http.StatusBadRequest, "type is required")
+		return
+	}
+
+	entity := store.NewEntity(entitystore.NewEntityOptions{
+		ID:     request.ID,
+		Type:   request.Type,
+		Handle: request.Handle,
+	})
+
+	err := store.Transaction(func(tx entitystore.StoreInterface) error {
+		if err := tx.EntityCreate(&entity); err != nil {
+			return err
+		}
+
+		return tx.AttributesSet(entity.ID(), request.Attributes)
+	})
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	h.entityWrite(w, store, entity.ID(), http.StatusCreated)
+}
+
+// entityGet shows an entity with its attributes
+func (h *handler) entityGet(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	h.entityWrite(w, store, r.PathValue("id"), http.StatusOK)
+}
+
+// entityUpdate updates the handle and the attributes of an entity in a
+// transaction. With a version the entity is updated first, only if its
+// version still matches, which guards the rest of the update
+func (h *handler) entityUpdate(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	request := entityUpdateRequest{}
+
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	entityID := r.PathValue("id")
+
+	err := store.Transaction(func(tx entitystore.StoreInterface) error {
+		entity, err := entityFind(tx, entityID)
+
+		if err != nil {
+			return err
+		}
+
+		isHandleChanged := request.Handle != nil && *request.Handle != entity.Handle()
+
+		if isHandleChanged {
+			entity.SetHandle(*request.Handle)
+		}
+
+		if request.Version != nil {
+			err = tx.EntityUpdateIfVersion(*entity, *request.Version)
+		} else if isHandleChanged {
+			err = tx.EntityUpdate(*entity)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for key, value := range request.Attributes {
+			if value == nil {
+				_, err = tx.AttributeDelete(entityID, key)
+
+				if errors.Is(err, entitystore.ErrAttributeNotFound) {
+					err = nil
+				}
+			} else {
+				err = tx.AttributeSetString(entityID, key, *value)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	h.entityWrite(w, store, entityID, http.StatusOK)
+}
+
+// entityDelete deletes an entity for good
+func (h *handler) entityDelete(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	if _, err := store.EntityDelete(r.PathValue("id")); err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// attributeSet sets an attribute of an existing entity
+func (h *handler) attributeSet(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	request := attributeSetRequest{}
+
+	if !readJSON(w, r, &request) {
+		return
+	}
+
+	entity, err := entityFind(store, r.PathValue("id"))
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	key := r.PathValue("key")
+
+	if err := store.AttributeSetString(entity.ID(), key, request.Value); err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, attributeResponse{Key: key, Value: request.Value})
+}
+
+// entityTrash moves an entity to the trash bin
+func (h *handler) entityTrash(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	if _, err := store.EntityTrash(r.PathValue("id")); err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// entityRestore moves an entity back from the trash bin
+func (h *handler) entityRestore(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface) {
+	if _, err := store.EntityRestore(r.PathValue("id")); err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	h.entityWrite(w, store, r.PathValue("id"), http.StatusOK)
+}
+
+// entityWrite writes the entity with its attributes as the response
+func (h *handler) entityWrite(w http.ResponseWriter, store entitystore.StoreInterface, entityID string, status int) {
+	entity, err := entityFind(store, entityID)
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	record, err := entityRecord(store, *entity, true)
+
+	if err != nil {
+		writeStoreError(w, h.options.Logger, err)
+		return
+	}
+
+	if status == http.StatusCreated {
+		w.Header().Set("Location", "/entities/"+entity.ID())
+	}
+
+	writeJSON(w, status, record)
+}
+
+// entityFind finds an entity, returns ErrEntityNotFound if it does not exist
+func entityFind(store entitystore.StoreInterface, entityID string) (*entitystore.Entity, error) {
+	entity, err := store.EntityFindByID(entityID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if entity == nil {
+		return nil, entitystore.ErrEntityNotFound
+	}
+
+	return entity, nil
+}
+
+// entityRecord converts the entity to its JSON document
+func entityRecord(store entitystore.StoreInterface, entity entitystore.Entity, withAttributes bool) (entitystore.ExportRecord, error) {
+	record := entitystore.ExportRecord{
+		ID:        entity.ID(),
+		Type:      entity.Type(),
+		Handle:    entity.Handle(),
+		ParentID:  entity.ParentID(),
+		TenantID:  entity.TenantID(),
+		Version:   entity.Version(),
+		CreatedAt: entity.CreatedAt(),
+		UpdatedAt: entity.UpdatedAt(),
+	}
+
+	if !withAttributes {
+		return record, nil
+	}
+
+	attributes, err := store.EntityAttributeList(entity.ID())
+
+	if err != nil {
+		return record, err
+	}
+
+	record.Attributes = map[string]string{}
+
+	for _, attribute := range attributes {
+		value, err := attribute.Value()
+
+		if err != nil {
+			return record, err
+		}
+
+		record.Attributes[attribute.AttributeKey()] = value
+	}
+
+	return record, nil
+}
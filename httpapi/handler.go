@@ -0,0 +1,123 @@
+// Package httpapi exposes an entity store as a JSON REST API.
+//
+// The routes are:
+//
+//	GET    /entities                    lists the entities, the query parameters map to EntityQueryOptions
+//	POST   /entities                    creates an entity with its attributes
+//	GET    /entities/{id}               shows an entity with its attributes
+//	PATCH  /entities/{id}               updates the handle and the attributes of an entity
+//	DELETE /entities/{id}               deletes an entity for good
+//	PUT    /entities/{id}/attributes/{key}  sets an attribute of an entity
+//	POST   /entities/{id}/trash         moves an entity to the trash bin
+//	POST   /trash/{id}/restore          moves an entity back from the trash bin
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// the actions passed to Authorize
+const ACTION_LIST = "list"
+const ACTION_CREATE = "create"
+const ACTION_READ = "read"
+const ACTION_UPDATE = "update"
+const ACTION_DELETE = "delete"
+const ACTION_TRASH = "trash"
+const ACTION_RESTORE = "restore"
+
+// maxBodyBytesDefault the maximum size of a request body
+const maxBodyBytesDefault = 1 << 20
+
+// Options configures the handler
+type Options struct {
+	// Authenticate identifies the caller of a request. An error rejects
+	// the request with 401, the returned actor is recorded as the author
+	// of the changes. All the requests are accepted if not set
+	Authenticate func(r *http.Request) (actor string, err error)
+	// Authorize decides whether the caller may run the action on the entity
+	// (empty for list and create). An error rejects the request with 403.
+	// All the actions are allowed if not set
+	Authorize func(r *http.Request, action string, entityID string) error
+	// Middleware wraps the handler, the first one being the outermost,
+	// i.e. for logging, rate limiting or an existing authentication
+	Middleware []func(http.Handler) http.Handler
+	// Logger logs the internal errors, slog.Default() if not set
+	Logger *slog.Logger
+	// MaxBodyBytes the maximum size of a request body, 1MB by default
+	MaxBodyBytes int64
+}
+
+// handler serves the routes of the API
+type handler struct {
+	store   entitystore.StoreInterface
+	options Options
+}
+
+// NewHandler returns an http.Handler exposing the store
+func NewHandler(store entitystore.StoreInterface, opts Options) http.Handler {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	if opts.MaxBodyBytes < 1 {
+		opts.MaxBodyBytes = maxBodyBytesDefault
+	}
+
+	h := &handler{store: store, options: opts}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /entities", h.guard(ACTION_LIST, h.entityList))
+	mux.HandleFunc("POST /entities", h.guard(ACTION_CREATE, h.entityCreate))
+	mux.HandleFunc("GET /entities/{id}", h.guard(ACTION_READ, h.entityGet))
+	mux.HandleFunc("PATCH /entities/{id}", h.guard(ACTION_UPDATE, h.entityUpdate))
+	mux.HandleFunc("DELETE /entities/{id}", h.guard(ACTION_DELETE, h.entityDelete))
+	mux.HandleFunc("PUT /entities/{id}/attributes/{key}", h.guard(ACTION_UPDATE, h.attributeSet))
+	mux.HandleFunc("POST /entities/{id}/trash", h.guard(ACTION_TRASH, h.entityTrash))
+	mux.HandleFunc("POST /trash/{id}/restore", h.guard(ACTION_RESTORE, h.entityRestore))
+
+	var root http.Handler = mux
+
+	for i := len(opts.Middleware) - 1; i >= 0; i-- {
+		root = opts.Middleware[i](root)
+	}
+
+	return root
+}
+
+// storeHandlerFunc handles a request with the store view of the caller
+type storeHandlerFunc func(w http.ResponseWriter, r *http.Request, store entitystore.StoreInterface)
+
+// guard authenticates and authorizes the request, then runs the route
+// with a store view recording the actor and passing the request context
+func (h *handler) guard(action string, next storeHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		store := h.store.WithContext(r.Context())
+
+		if h.options.Authenticate != nil {
+			actor, err := h.options.Authenticate(r)
+
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			if actor != "" {
+				store = store.WithActor(actor)
+			}
+		}
+
+		if h.options.Authorize != nil {
+			if err := h.options.Authorize(r, action, r.PathValue("id")); err != nil {
+				writeError(w, http.StatusForbidden, err.Error())
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, h.options.MaxBodyBytes)
+
+		next(w, r, store)
+	}
+}
@@ -0,0 +1,326 @@
+package httpapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func initStore(t *testing.T, filepath string) entitystore.StoreInterface {
+	_ = os.Remove(filepath)
+	t.Cleanup(func() { _ = os.Remove(filepath) })
+
+	db, err := sql.Open("sqlite3", filepath)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := entitystore.NewStore(entitystore.NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	return store
+}
+
+func request(t *testing.T, handler http.Handler, method string, path string, body string, response any) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, req)
+
+	if response != nil && recorder.Body.Len() > 0 {
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Fatal("Response must be JSON:", err.Error(), recorder.Body.String())
+		}
+	}
+
+	return recorder.Code
+}
+
+func TestHandler(t *testing.T) {
+	store := initStore(t, "test_httpapi_handler.db")
+	handler := NewHandler(store, Options{})
+
+	record := entitystore.ExportRecord{}
+	status := request(t, handler, "POST", "/entities", `{"type":"product","handle":"apple","attributes":{"name":"Apple","price":"1.20"}}`, &record)
+
+	if status != http.StatusCreated {
+		t.Fatal("Create must return 201, found:", status)
+	}
+
+	if record.ID == "" || record.Attributes["name"] != "Apple" {
+		t.Fatal("Create must return the entity with its attributes, found:", record)
+	}
+
+	appleID := record.ID
+
+	if status := request(t, handler, "POST", "/entities", `{"type":"product","handle":"pear"}`, nil); status != http.StatusCreated {
+		t.Fatal("Create must return 201, found:", status)
+	}
+
+	if status := request(t, handler, "POST", "/entities", `{"handle":"plum"}`, nil); status != http.StatusBadRequest {
+		t.Fatal("Create without type must return 400, found:", status)
+	}
+
+	list := entityListResponse{}
+	status = request(t, handler, "GET", "/entities?type=product&sort_by=handle&sort_order=desc&limit=1&attributes=true", "", &list)
+
+	if status != http.StatusOK {
+		t.Fatal("List must return 200, found:", status)
+	}
+
+	if list.Total != 2 || len(list.Entities) != 1 || list.Entities[0].Handle != "pear" {
+		t.Fatal("List must return the first of 2 products sorted by handle, found:", list)
+	}
+
+	if status := request(t, handler, "GET", "/entities?sort_by=password", "", nil); status != http.StatusBadRequest {
+		t.Fatal("List with unsupported sort must return 400, found:", status)
+	}
+
+	record = entitystore.ExportRecord{}
+	status = request(t, handler, "PATCH", "/entities/"+appleID, `{"handle":"green-apple","attributes":{"name":"Green Apple","price":null}}`, &record)
+
+	if status != http.StatusOK {
+		t.Fatal("Update must return 200, found:", status)
+	}
+
+	if record.Handle != "green-apple" || record.Attributes["name"] != "Green Apple" {
+		t.Fatal("Update must change the handle and the attributes, found:", record)
+	}
+
+	if _, exists := record.Attributes["price"]; exists {
+		t.Fatal("Update must delete the null attributes")
+	}
+
+	if status := request(t, handler, "PATCH", "/entities/"+appleID, `{"version":1,"handle":"apple"}`, nil); status != http.StatusConflict {
+		t.Fatal("Update with a stale version must return 409, found:", status)
+	}
+
+	attribute := attributeResponse{}
+	status = request(t, handler, "PUT", "/entities/"+appleID+"/attributes/stock", `{"value":"5"}`, &attribute)
+
+	if status != http.StatusOK || attribute.Value != "5" {
+		t.Fatal("Set attribute must return 200 with the value, found:", status, attribute)
+	}
+
+	if status := request(t, handler, "PUT", "/entities/missing/attributes/stock", `{"value":"5"}`, nil); status != http.StatusNotFound {
+		t.Fatal("Set attribute of a missing entity must return 404, found:", status)
+	}
+
+	if status := request(t, handler, "POST", "/entities/"+appleID+"/trash", "", nil); status != http.StatusNoContent {
+		t.Fatal("Trash must return 204, found:", status)
+	}
+
+	if status := request(t, handler, "GET", "/entities/"+appleID, "", nil); status != http.StatusNotFound {
+		t.Fatal("Get of a trashed entity must return 404, found:", status)
+	}
+
+	record = entitystore.ExportRecord{}
+	status = request(t, handler, "POST", "/trash/"+appleID+"/restore", "", &record)
+
+	if status != http.StatusOK || record.Attributes["stock"] != "5" {
+		t.Fatal("Restore must return 200 with the entity, found:", status, record)
+	}
+
+	if status := request(t, handler, "DELETE", "/entities/"+appleID, "", nil); status != http.StatusNoContent {
+		t.Fatal("Delete must return 204, found:", status)
+	}
+
+	if status := request(t, handler, "DELETE", "/entities/"+appleID, "", nil); status != http.StatusNotFound {
+		t.Fatal("Delete of a missing entity must return 404, found:", status)
+	}
+}
+
+func TestHandlerAuth(t *testing.T) {
+	store := initStore(t, "test_httpapi_auth.db")
+
+	entity, err := store.EntityCreateWithType("product")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	middlewareCalls := 0
+
+	handler := NewHandler(store, Options{
+		Authenticate: func(r *http.Request) (string, error) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return "", errors.New("invalid token")
+			}
+
+			return "admin", nil
+		},
+		Authorize: func(r *http.Request, action string, entityID string) error {
+			if action == ACTION_DELETE {
+				return errors.New("deleting is not allowed")
+			}
+
+			return nil
+		},
+		Middleware: []func(http.Handler) http.Handler{
+			func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					middlewareCalls++
+					next.ServeHTTP(w, r)
+				})
+			},
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/entities/"+entity.ID(), nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatal("Request without a token must return 401, found:", recorder.Code)
+	}
+
+	if status := request(t, handler, "GET", "/entities/"+entity.ID(), "", nil); status != http.StatusOK {
+		t.Fatal("Request with a token must return 200, found:", status)
+	}
+
+	if status := request(t, handler, "DELETE", "/entities/"+entity.ID(), "", nil); status != http.StatusForbidden {
+		t.Fatal("Forbidden action must return 403, found:", status)
+	}
+
+	if middlewareCalls != 3 {
+		t.Fatal("Middleware must wrap every request, found calls:", middlewareCalls)
+	}
+}
+
+func TestHandlerAtomicWrites(t *testing.T) {
+	store := initStore(t, "test_httpapi_atomic.db")
+	handler := NewHandler(store, Options{})
+
+	store.BeforeAttributeSet(func(ctx context.Context, attribute *entitystore.Attribute) error {
+		if attribute.AttributeKey() == "forbidden" {
+			return errors.New("forbidden attribute")
+		}
+
+		return nil
+	})
+
+	if status := request(t, handler, "POST", "/entities", `{"type":"product","handle":"apple","attributes":{"name":"Apple","forbidden":"yes"}}`, nil); status == http.StatusCreated {
+		t.Fatal("Create with a failing attribute must fail")
+	}
+
+	if count, _ := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "product"}); count != 0 {
+		t.Fatal("Failed create must not leave the entity, found:", count)
+	}
+
+	record := entitystore.ExportRecord{}
+
+	if status := request(t, handler, "POST", "/entities", `{"type":"product","handle":"pear","attributes":{"name":"Pear"}}`, &record); status != http.StatusCreated {
+		t.Fatal("Create must return 201, found:", status)
+	}
+
+	pearID := record.ID
+	body := `{"version":` + strconv.FormatInt(record.Version, 10) + `,"handle":"green-pear","attributes":{"name":"Green Pear","forbidden":"yes"}}`
+
+	if status := request(t, handler, "PATCH", "/entities/"+pearID, body, nil); status == http.StatusOK {
+		t.Fatal("Update with a failing attribute must fail")
+	}
+
+	record = entitystore.ExportRecord{}
+	request(t, handler, "GET", "/entities/"+pearID, "", &record)
+
+	if record.Handle != "pear" || record.Attributes["name"] != "Pear" {
+		t.Fatal("Failed update must not change the entity, found:", record)
+	}
+
+	// the version is checked when the update is written
+	if err := store.AttributeSetString(pearID, "name", "Yellow Pear"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	body = `{"version":` + strconv.FormatInt(record.Version, 10) + `,"attributes":{"name":"Green Pear"}}`
+
+	if status := request(t, handler, "PATCH", "/entities/"+pearID, body, nil); status != http.StatusConflict {
+		t.Fatal("Update with a stale version must return 409, found:", status)
+	}
+
+	name, err := store.AttributeFind(pearID, "name")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if name == nil || name.GetString() != "Yellow Pear" {
+		t.Fatal("Update with a stale version must not change the attributes")
+	}
+}
+
+func TestHandlerUndecodableValue(t *testing.T) {
+	filepath := "test_httpapi_undecodable.db"
+	_ = os.Remove(filepath)
+	t.Cleanup(func() { _ = os.Remove(filepath) })
+
+	db, err := sql.Open("sqlite3", filepath)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	newStore := func(key string) entitystore.StoreInterface {
+		cipher, err := entitystore.NewAESGCMCipher(map[string][]byte{"k1": []byte(key)}, "k1")
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		store, err := entitystore.NewStore(entitystore.NewStoreOptions{
+			DB:                     db,
+			EntityTableName:        "cms_entity",
+			AttributeTableName:     "cms_attribute",
+			AttributeCipher:        cipher,
+			EncryptedAttributeKeys: []string{"secret"},
+			AutomigrateEnabled:     true,
+		})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+
+		return store
+	}
+
+	entity, err := newStore("0123456789abcdef0123456789abcdef").EntityCreateWithTypeAndAttributes("product", map[string]string{"secret": "s3cret"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	// the same key ID with another key, the value cannot be opened
+	handler := NewHandler(newStore("fedcba9876543210fedcba9876543210"), Options{})
+
+	if status := request(t, handler, "GET", "/entities/"+entity.ID(), "", nil); status != http.StatusInternalServerError {
+		t.Fatal("Undecodable value must return 500, found:", status)
+	}
+
+	if status := request(t, handler, "GET", "/entities?type=product&attributes=true", "", nil); status != http.StatusInternalServerError {
+		t.Fatal("Undecodable value in a list must return 500, found:", status)
+	}
+
+	if status := request(t, handler, "GET", "/entities?type=product", "", nil); status != http.StatusOK {
+		t.Fatal("List without the attributes must return 200, found:", status)
+	}
+}
@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// errorResponse is the body of the failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes the value as the JSON body of the response
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes the message as the JSON body of a failed response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// writeStoreError maps the errors of the store to the status codes.
+// The internal errors are logged, and not shown to the caller
+func writeStoreError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, entitystore.ErrEntityNotFound), errors.Is(err, entitystore.ErrAttributeNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, entitystore.ErrInvalidArgument):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, entitystore.ErrDuplicate), errors.Is(err, entitystore.ErrVersionConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		logger.Error("entity store request failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// readJSON decodes the JSON body of the request into the value,
+// and writes the error response if it cannot be decoded
+func readJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(value)
+
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+
+	if errors.As(err, &maxBytesErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body is too large")
+		return false
+	}
+
+	writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+	return false
+}
@@ -0,0 +1,61 @@
+package entitystore
+
+import "github.com/gouniverse/sb"
+
+// inTransaction runs fn inside a database transaction.
+// If a transaction is already in progress fn joins it, and committing
+// or rolling back is left to whoever started the surrounding transaction.
+// A new transaction runs on its own view of the store, passed to fn,
+// so the operations of other goroutines do not join it.
+// The change events queued during the transaction are published after commit,
+// and the cache keys invalidated during it are invalidated again
+func (st *storeImplementation) inTransaction(fn func(tx *storeImplementation) error) (err error) {
+	if st.database.Tx() != nil {
+		return fn(st)
+	}
+
+	tx := *st
+	tx.database = sb.NewDatabase(st.database.DB(), st.dbDriverName)
+	tx.changeEvents = &[]ChangeEvent{}
+	tx.cacheInvalidated = &[]string{}
+
+	err = tx.database.BeginTransaction()
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			txErr := tx.database.RollbackTransaction()
+			if txErr != nil {
+				st.logError("rollback failed", txErr)
+			}
+			tx.cacheInvalidatePending()
+			panic(r)
+		}
+	}()
+
+	err = fn(&tx)
+
+	if err != nil {
+		txErr := tx.database.RollbackTransaction()
+		if txErr != nil {
+			st.logError("rollback failed", txErr)
+		}
+		tx.cacheInvalidatePending()
+		return err
+	}
+
+	err = tx.database.CommitTransaction()
+
+	if err != nil {
+		tx.cacheInvalidatePending()
+		return err
+	}
+
+	tx.cacheInvalidatePending()
+	st.changeEventsPublish(*tx.changeEvents)
+
+	return nil
+}
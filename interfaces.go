@@ -1,21 +1,37 @@
 package entitystore
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"io"
+	"time"
+)
 
 type StoreInterface interface {
 	AutoMigrate() error
 
+	AckChanges(changeIDs []string) error
+	ChangesSince(afterSequence int64, limit uint64) ([]Change, error)
+
+	GetAttributeHistoryTableName() string
 	GetAttributeTableName() string
 	GetAttributeTrashTableName() string
 	GetDB() *sql.DB
 	GetEntityTableName() string
 	GetEntityTrashTableName() string
+	GetLinkTableName() string
+	GetOutboxTableName() string
 
+	AttributeAggregate(entityType string, attributeKey string, options AggregateOptions) ([]AggregateResult, error)
 	// AttributeCount(entityID string) uint64
 	AttributeCreate(attr *Attribute) error
 	AttributeCreateWithKeyAndValue(entityID string, attributeKey string, attributeValue string) (*Attribute, error)
+	AttributeDelete(entityID string, attributeKey string) (bool, error)
+	AttributeFacets(entityType string, attributeKeys []string, options FacetOptions) (map[string][]FacetValue, error)
 	AttributeFind(entityID string, attributeKey string) (*Attribute, error)
 	AttributeFindByHandle(entityID string, attributeKey string, attributeValue string) (*Attribute, error)
+	AttributeHistory(entityID string, attributeKey string) ([]AttributeHistoryEntry, error)
+	AttributeKeyList(entityType string) ([]AttributeKeyInfo, error)
 	AttributeList(options AttributeQueryOptions) ([]Attribute, error)
 	AttributesSet(entityID string, attributes map[string]string) error
 	AttributeSetFloat(entityID string, attributeKey string, attributeValue float64) error
@@ -23,23 +39,78 @@ type StoreInterface interface {
 	AttributeSetString(entityID string, attributeKey string, attributeValue string) error
 	// AttributeTrash(attr *Attribute) error
 
+	CreateTypeView(entityType string, attributeKeys []string, viewName string) error
+	RebuildProjection(entityType string) (int64, error)
+	RefreshTypeViews() error
+
+	EntityAncestors(entityID string) ([]Entity, error)
+	EntityAsOf(entityID string, t time.Time) (*EntitySnapshot, error)
 	EntityAttributeList(entityID string) ([]Attribute, error)
+	EntityChildren(entityID string) ([]Entity, error)
 	EntityCount(options EntityQueryOptions) (int64, error)
 	EntityCreate(entity *Entity) error
 	EntityCreateWithType(entityType string) (*Entity, error)
 	EntityCreateWithTypeAndAttributes(entityType string, attributes map[string]string) (*Entity, error)
 	EntityDelete(entityID string) (bool, error)
+	EntityDescendants(entityID string, maxDepth int) ([]Entity, error)
+	EntityDiff(entityID string, t1 time.Time, t2 time.Time) (*EntityDiffResult, error)
 	EntityFindByAttribute(entityType string, attributeKey string, attributeValue string) (*Entity, error)
 	EntityFindByHandle(entityType string, entityHandle string) (*Entity, error)
 	EntityFindByID(entityID string) (*Entity, error)
+	EntityHistory(entityID string, options EntityHistoryOptions) ([]AttributeHistoryEntry, error)
+	EntityLink(fromEntityID string, toEntityID string, relation string, options EntityLinkOptions) (*Link, error)
+	EntityLinks(entityID string, relation string, direction string) ([]Link, error)
 	EntityList(options EntityQueryOptions) ([]Entity, error)
 	EntityListByAttribute(entityType string, attributeKey string, attributeValue string) ([]Entity, error)
+	EntityListRelated(entityID string, relation string, options EntityQueryOptions) ([]Entity, error)
+	EntityRestore(entityID string) (bool, error)
+	EntitySetParent(entityID string, parentID string) error
 	EntityTrash(entityID string) (bool, error)
+	EntityTypeList() ([]EntityTypeInfo, error)
+	EntityUnlink(fromEntityID string, toEntityID string, relation string) (bool, error)
 	EntityUpdate(entity Entity) error
+	EntityUpdateIfVersion(entity Entity, expectedVersion int64) error
 
 	NewAttribute(opts NewAttributeOptions) Attribute
 	NewAttributeFromMap(entityMap map[string]string) Attribute
 
 	NewEntity(opts NewEntityOptions) Entity
 	NewEntityFromMap(entityMap map[string]string) Entity
+
+	// CacheStats returns the hits and misses of the cache
+	CacheStats() CacheStats
+	// Export writes the entities with their attributes as JSON Lines
+	Export(ctx context.Context, w io.Writer, options ExportOptions) error
+	// Import recreates the entities written by Export
+	Import(ctx context.Context, r io.Reader, options ImportOptions) (ImportResult, error)
+	// ExportCSV writes the entities of a type as CSV, a column per attribute
+	ExportCSV(ctx context.Context, w io.Writer, entityType string, options CSVOptions) error
+	// ImportCSV creates or updates the entities of a type from CSV rows
+	ImportCSV(ctx context.Context, r io.Reader, entityType string, mapping CSVMapping) (CSVImportResult, error)
+	// ReencryptAll seals the encrypted attribute values again with the current key
+	ReencryptAll() (int64, error)
+	// Subscribe returns a channel receiving the committed changes matching the filter
+	Subscribe(filter ChangeFilter) (<-chan ChangeEvent, func())
+	// Transaction runs fn in a transaction, with the store passed to fn
+	Transaction(fn func(tx StoreInterface) error) error
+
+	// ForTenant returns a view of the store scoped to a single tenant
+	ForTenant(tenantID string) StoreInterface
+	// WithActor returns a view of the store recording the actor as the author of changes
+	WithActor(actor string) StoreInterface
+	// WithContext returns a view of the store passing the context to the hooks
+	WithContext(ctx context.Context) StoreInterface
+
+	BeforeEntityCreate(hook EntityHook)
+	AfterEntityCreate(hook EntityHook)
+	BeforeEntityUpdate(hook EntityHook)
+	AfterEntityUpdate(hook EntityHook)
+	BeforeEntityTrash(hook EntityHook)
+	AfterEntityTrash(hook EntityHook)
+	BeforeEntityDelete(hook EntityHook)
+	AfterEntityDelete(hook EntityHook)
+	BeforeAttributeSet(hook AttributeHook)
+	AfterAttributeSet(hook AttributeHook)
+	BeforeAttributeDelete(hook AttributeHook)
+	AfterAttributeDelete(hook AttributeHook)
 }
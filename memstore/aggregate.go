@@ -0,0 +1,151 @@
+package memstore
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// aggregateNumberPattern matches the values the SQL store treats as numbers
+var aggregateNumberPattern = regexp.MustCompile(`^\s*[-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?\s*$`)
+
+// AttributeAggregate aggregates the numeric values of an attribute over the
+// entities of a type, optionally grouped by the value of another attribute
+func (st *Store) AttributeAggregate(entityType string, attributeKey string, options entitystore.AggregateOptions) (results []entitystore.AggregateResult, err error) {
+	defer wrapOpError(&err, "AttributeAggregate", "")
+
+	if entityType == "" {
+		return nil, errInvalidArgument("entity type cannot be empty")
+	}
+
+	if attributeKey == "" {
+		return nil, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	switch options.Func {
+	case entitystore.AGGREGATE_SUM, entitystore.AGGREGATE_AVG, entitystore.AGGREGATE_MIN, entitystore.AGGREGATE_MAX, entitystore.AGGREGATE_COUNT:
+	default:
+		return nil, errInvalidArgument("unsupported aggregate function " + options.Func)
+	}
+
+	type group struct {
+		numbers []float64
+		count   int64
+	}
+
+	groups := map[string]*group{}
+
+	st.data.mu.RLock()
+
+	for entityID, entity := range st.data.entities {
+		if entity.Type() != entityType {
+			continue
+		}
+
+		if st.tenantScoped && entity.TenantID() != st.tenantID {
+			continue
+		}
+
+		attributes := attributesByKey(st.data.attributes[entityID])
+
+		attribute, exists := attributes[attributeKey]
+
+		if !exists || !attributesMatch(attributes, options.Where) {
+			continue
+		}
+
+		groupValue := ""
+
+		if options.GroupByAttribute != "" {
+			groupAttribute := attributes[options.GroupByAttribute]
+			groupValue = groupAttribute.AttributeValue()
+		}
+
+		if groups[groupValue] == nil {
+			groups[groupValue] = &group{}
+		}
+
+		groups[groupValue].count++
+
+		value := attribute.AttributeValue()
+
+		if aggregateNumberPattern.MatchString(value) {
+			number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+			if err == nil {
+				groups[groupValue].numbers = append(groups[groupValue].numbers, number)
+			}
+		}
+	}
+
+	st.data.mu.RUnlock()
+
+	// like SQL, an aggregate without grouping always has a result
+	if options.GroupByAttribute == "" && groups[""] == nil {
+		groups[""] = &group{}
+	}
+
+	results = []entitystore.AggregateResult{}
+
+	for groupValue, group := range groups {
+		result := entitystore.AggregateResult{Group: groupValue, Count: group.count}
+
+		switch options.Func {
+		case entitystore.AGGREGATE_COUNT:
+			result.Value = float64(group.count)
+		case entitystore.AGGREGATE_SUM, entitystore.AGGREGATE_AVG:
+			for _, number := range group.numbers {
+				result.Value += number
+			}
+
+			if options.Func == entitystore.AGGREGATE_AVG && len(group.numbers) > 0 {
+				result.Value /= float64(len(group.numbers))
+			}
+		case entitystore.AGGREGATE_MIN, entitystore.AGGREGATE_MAX:
+			for i, number := range group.numbers {
+				if i == 0 || (options.Func == entitystore.AGGREGATE_MIN && number < result.Value) || (options.Func == entitystore.AGGREGATE_MAX && number > result.Value) {
+					result.Value = number
+				}
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Group < results[j].Group
+	})
+
+	return results, nil
+}
+
+// attributesMatch returns true if the attributes have all the values
+func attributesMatch(attributes map[string]entitystore.Attribute, values map[string]string) bool {
+	for key, value := range values {
+		attribute, exists := attributes[key]
+
+		if !exists {
+			return false
+		}
+
+		if attribute.AttributeValue() != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// attributesByKey indexes the attributes of an entity by their key
+func attributesByKey(attributes map[string]entitystore.Attribute) map[string]entitystore.Attribute {
+	byKey := make(map[string]entitystore.Attribute, len(attributes))
+
+	for _, attribute := range attributes {
+		byKey[attribute.AttributeKey()] = attribute
+	}
+
+	return byKey
+}
@@ -0,0 +1,378 @@
+package memstore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+	"github.com/gouniverse/uid"
+)
+
+// AttributeCreate creates a new attribute
+func (st *Store) AttributeCreate(attr *entitystore.Attribute) (err error) {
+	defer func() {
+		entityID := ""
+		if attr != nil {
+			entityID = attr.EntityID()
+		}
+		wrapOpError(&err, "AttributeCreate", entityID)
+	}()
+
+	if attr == nil {
+		return errInvalidArgument("attribute is required")
+	}
+
+	if attr.AttributeKey() == "" {
+		return errInvalidArgument("attribute key is required")
+	}
+
+	if attr.ID() == "" {
+		attr.SetID(uid.HumanUid())
+	}
+
+	if attr.CreatedAt().IsZero() {
+		attr.SetCreatedAt(time.Now())
+	}
+
+	if attr.UpdatedAt().IsZero() {
+		attr.SetUpdatedAt(time.Now())
+	}
+
+	return st.inTransaction(func(tx *Store) error {
+		if err := tx.tenantEntityCheck(attr.EntityID()); err != nil {
+			return err
+		}
+
+		if err := tx.attributeHooksRun(hookBeforeAttributeSet, attr); err != nil {
+			return err
+		}
+
+		if err := tx.attributeInsert(*attr); err != nil {
+			return err
+		}
+
+		tx.attributeHistoryRecord(attr.EntityID(), attr.AttributeKey(), entitystore.OPERATION_CREATE, "", attr.AttributeValue())
+		tx.entityVersionIncrement(attr.EntityID())
+
+		if err := tx.changeRecordAttribute(entitystore.CHANGE_ATTRIBUTE_SET, attr); err != nil {
+			return err
+		}
+
+		return tx.attributeHooksRun(hookAfterAttributeSet, attr)
+	})
+}
+
+// AttributeCreateWithKeyAndValue shortcut to create a new attribute
+// by providing only the key and value
+// NN. The ID will be auto-assigned
+func (st *Store) AttributeCreateWithKeyAndValue(entityID string, attributeKey string, attributeValue string) (attr *entitystore.Attribute, err error) {
+	defer wrapOpError(&err, "AttributeCreateWithKeyAndValue", entityID)
+
+	newAttribute := st.NewAttribute(entitystore.NewAttributeOptions{
+		ID:             uid.HumanUid(),
+		EntityID:       entityID,
+		AttributeKey:   attributeKey,
+		AttributeValue: attributeValue,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	})
+
+	if err := st.AttributeCreate(&newAttribute); err != nil {
+		return nil, err
+	}
+
+	return &newAttribute, nil
+}
+
+// attributeUpdate updates an attribute
+func (st *Store) attributeUpdate(attr entitystore.Attribute) error {
+	attr.SetUpdatedAt(time.Now())
+
+	return st.inTransaction(func(tx *Store) error {
+		if err := tx.tenantEntityCheck(attr.EntityID()); err != nil {
+			return err
+		}
+
+		if err := tx.attributeHooksRun(hookBeforeAttributeSet, &attr); err != nil {
+			return err
+		}
+
+		oldValue := tx.attributeReplace(attr)
+
+		tx.attributeHistoryRecord(attr.EntityID(), attr.AttributeKey(), entitystore.OPERATION_UPDATE, oldValue, attr.AttributeValue())
+		tx.entityVersionIncrement(attr.EntityID())
+
+		if err := tx.changeRecordAttribute(entitystore.CHANGE_ATTRIBUTE_SET, &attr); err != nil {
+			return err
+		}
+
+		return tx.attributeHooksRun(hookAfterAttributeSet, &attr)
+	})
+}
+
+// AttributeDelete deletes an attribute of an entity.
+// Returns ErrAttributeNotFound if the attribute does not exist
+func (st *Store) AttributeDelete(entityID string, attributeKey string) (isDeleted bool, err error) {
+	defer wrapOpError(&err, "AttributeDelete", entityID)
+
+	if entityID == "" {
+		return false, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if attributeKey == "" {
+		return false, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	attr, err := st.AttributeFind(entityID, attributeKey)
+
+	if err != nil {
+		return false, err
+	}
+
+	if attr == nil {
+		return false, fmt.Errorf("%w: attribute %s does not exist", entitystore.ErrAttributeNotFound, attributeKey)
+	}
+
+	err = st.inTransaction(func(tx *Store) error {
+		if err := tx.attributeHooksRun(hookBeforeAttributeDelete, attr); err != nil {
+			return err
+		}
+
+		tx.attributeRemove(attr.ID())
+		tx.attributeHistoryRecord(entityID, attributeKey, entitystore.OPERATION_DELETE, attr.AttributeValue(), "")
+		tx.entityVersionIncrement(entityID)
+
+		if err := tx.changeRecordAttribute(entitystore.CHANGE_ATTRIBUTE_DELETE, attr); err != nil {
+			return err
+		}
+
+		return tx.attributeHooksRun(hookAfterAttributeDelete, attr)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AttributeFind finds an attribute of an entity by key
+func (st *Store) AttributeFind(entityID string, attributeKey string) (attr *entitystore.Attribute, err error) {
+	defer wrapOpError(&err, "AttributeFind", entityID)
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if attributeKey == "" {
+		return nil, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	list, err := st.attributeQuery(entitystore.AttributeQueryOptions{
+		EntityID:     entityID,
+		AttributeKey: attributeKey,
+		Limit:        1,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+// AttributeFindByHandle finds an attribute of the entity with the type and handle
+func (st *Store) AttributeFindByHandle(entityType string, entityHandle string, attributeKey string) (attr *entitystore.Attribute, err error) {
+	defer wrapOpError(&err, "AttributeFindByHandle", "")
+
+	if entityType == "" {
+		return nil, errInvalidArgument("entity type cannot be empty")
+	}
+
+	if entityHandle == "" {
+		return nil, errInvalidArgument("entity handle cannot be empty")
+	}
+
+	if attributeKey == "" {
+		return nil, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	list, err := st.attributeQuery(entitystore.AttributeQueryOptions{
+		EntityType:   entityType,
+		EntityHandle: entityHandle,
+		AttributeKey: attributeKey,
+		Limit:        1,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+// AttributeList lists attributes
+func (st *Store) AttributeList(options entitystore.AttributeQueryOptions) (attributeList []entitystore.Attribute, err error) {
+	defer wrapOpError(&err, "AttributeList", options.EntityID)
+
+	return st.attributeQuery(options)
+}
+
+// EntityAttributeList list all attributes of an entity
+func (st *Store) EntityAttributeList(entityID string) (attributes []entitystore.Attribute, err error) {
+	defer wrapOpError(&err, "EntityAttributeList", entityID)
+
+	return st.attributeQuery(entitystore.AttributeQueryOptions{
+		EntityID: entityID,
+	})
+}
+
+// AttributesSet upserts the attributes of an entity in a single transaction
+func (st *Store) AttributesSet(entityID string, attributes map[string]string) (err error) {
+	defer wrapOpError(&err, "AttributesSet", entityID)
+
+	return st.inTransaction(func(tx *Store) error {
+		for key, value := range attributes {
+			if err := tx.AttributeSetString(entityID, key, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AttributeSetFloat creates a new attribute or updates existing
+func (st *Store) AttributeSetFloat(entityID string, attributeKey string, attributeValue float64) (err error) {
+	defer wrapOpError(&err, "AttributeSetFloat", entityID)
+
+	return st.AttributeSetString(entityID, attributeKey, strconv.FormatFloat(attributeValue, 'f', 30, 64))
+}
+
+// AttributeSetInt creates a new attribute or updates existing
+func (st *Store) AttributeSetInt(entityID string, attributeKey string, attributeValue int64) (err error) {
+	defer wrapOpError(&err, "AttributeSetInt", entityID)
+
+	return st.AttributeSetString(entityID, attributeKey, strconv.FormatInt(attributeValue, 10))
+}
+
+// AttributeSetString creates a new attribute or updates existing
+func (st *Store) AttributeSetString(entityID string, attributeKey string, attributeValue string) (err error) {
+	defer wrapOpError(&err, "AttributeSetString", entityID)
+
+	attr, err := st.AttributeFind(entityID, attributeKey)
+
+	if err != nil {
+		return err
+	}
+
+	if attr == nil {
+		_, err := st.AttributeCreateWithKeyAndValue(entityID, attributeKey, attributeValue)
+		return err
+	}
+
+	attr.SetString(attributeValue)
+
+	return st.attributeUpdate(*attr)
+}
+
+// attributeInsert adds the attribute, failing with ErrDuplicate if its ID is taken
+func (st *Store) attributeInsert(attr entitystore.Attribute) error {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	if _, exists := st.data.attributeEntityIDs[attr.ID()]; exists {
+		return fmt.Errorf("%w: attribute %s already exists", entitystore.ErrDuplicate, attr.ID())
+	}
+
+	st.attributeSet(attr)
+
+	return nil
+}
+
+// attributeReplace replaces the stored attribute with the same ID,
+// returns the value it had. Does nothing if the attribute does not exist
+func (st *Store) attributeReplace(attr entitystore.Attribute) string {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	entityID, exists := st.data.attributeEntityIDs[attr.ID()]
+
+	if !exists {
+		return ""
+	}
+
+	old := st.data.attributes[entityID][attr.ID()]
+	oldValue := old.AttributeValue()
+
+	mapDelete(st, st.data.attributes[entityID], attr.ID())
+	st.attributeSet(attr)
+
+	return oldValue
+}
+
+// attributeSet stores the attribute, the caller holds the write lock
+func (st *Store) attributeSet(attr entitystore.Attribute) {
+	if st.data.attributes[attr.EntityID()] == nil {
+		st.data.attributes[attr.EntityID()] = map[string]entitystore.Attribute{}
+	}
+
+	mapSet(st, st.data.attributes[attr.EntityID()], attr.ID(), attr)
+	mapSet(st, st.data.attributeEntityIDs, attr.ID(), attr.EntityID())
+}
+
+// attributeRemove removes the attribute with the ID
+func (st *Store) attributeRemove(attributeID string) {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	entityID, exists := st.data.attributeEntityIDs[attributeID]
+
+	if !exists {
+		return
+	}
+
+	mapDelete(st, st.data.attributes[entityID], attributeID)
+	mapDelete(st, st.data.attributeEntityIDs, attributeID)
+}
+
+// tenantEntityCheck returns an error if the store is scoped to a tenant
+// and the entity does not exist, or belongs to another tenant
+func (st *Store) tenantEntityCheck(entityID string) error {
+	if !st.tenantScoped {
+		return nil
+	}
+
+	entity, err := st.EntityFindByID(entityID)
+
+	if err != nil {
+		return err
+	}
+
+	if entity == nil {
+		return errEntityNotFound(entityID)
+	}
+
+	return nil
+}
+
+// tenantEntityVisible returns false if the store is scoped to a tenant and
+// the entity, including the trashed ones, belongs to another tenant
+func (st *Store) tenantEntityVisible(entityID string) bool {
+	if !st.tenantScoped {
+		return true
+	}
+
+	entity, _ := st.entityFindIncludingTrash(entityID)
+
+	return entity != nil
+}
@@ -0,0 +1,281 @@
+package memstore
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+)
+
+const subscriptionBufferSizeDefault = 100
+
+type subscription struct {
+	filter entitystore.ChangeFilter
+	events chan entitystore.ChangeEvent
+	done   chan struct{}
+
+	// mu guards the sends to events against its closing by cancel
+	mu     sync.Mutex
+	closed bool
+
+	// tenantScoped subscriptions receive only the events of their tenant
+	tenantScoped bool
+	tenantID     string
+}
+
+// ChangesSince lists the changes in the outbox committed after the change
+// with the given Sequence, oldest first. Start from 0, and continue
+// from the Sequence of the last returned change
+func (st *Store) ChangesSince(afterSequence int64, limit uint64) (changes []entitystore.Change, err error) {
+	defer wrapOpError(&err, "ChangesSince", "")
+
+	if !st.data.options.OutboxEnabled {
+		return nil, errNotEnabled("outbox")
+	}
+
+	if st.tenantScoped {
+		return nil, errors.New("the outbox spans all tenants, it is not available in a tenant view")
+	}
+
+	st.data.mu.RLock()
+	defer st.data.mu.RUnlock()
+
+	// the outbox is ordered by Sequence
+	for _, change := range st.data.outbox {
+		if change.Sequence <= afterSequence {
+			continue
+		}
+
+		changes = append(changes, change)
+
+		if limit > 0 && uint64(len(changes)) >= limit {
+			break
+		}
+	}
+
+	return changes, nil
+}
+
+// AckChanges removes the processed changes from the outbox
+func (st *Store) AckChanges(changeIDs []string) (err error) {
+	defer wrapOpError(&err, "AckChanges", "")
+
+	if !st.data.options.OutboxEnabled {
+		return errNotEnabled("outbox")
+	}
+
+	if st.tenantScoped {
+		return errors.New("the outbox spans all tenants, it is not available in a tenant view")
+	}
+
+	if len(changeIDs) < 1 {
+		return nil
+	}
+
+	acked := stringSet(changeIDs)
+
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	outbox := []entitystore.Change{}
+
+	for _, change := range st.data.outbox {
+		if !acked[change.ID] {
+			outbox = append(outbox, change)
+		}
+	}
+
+	st.data.outbox = outbox
+
+	return nil
+}
+
+// Subscribe returns a channel receiving the committed changes matching
+// the filter, and a function to cancel the subscription. When the buffer
+// of the channel is full events are dropped, or the writer is blocked,
+// according to the SubscriptionPolicy of the store. A subscription made
+// through a tenant view receives only the events of the tenant
+func (st *Store) Subscribe(filter entitystore.ChangeFilter) (<-chan entitystore.ChangeEvent, func()) {
+	sub := &subscription{
+		filter: filter,
+		events: make(chan entitystore.ChangeEvent, st.data.options.SubscriptionBufferSize),
+		done:   make(chan struct{}),
+
+		tenantScoped: st.tenantScoped,
+		tenantID:     st.tenantID,
+	}
+
+	st.data.subscribersMu.Lock()
+	st.data.subscribers[sub] = true
+	st.data.subscribersMu.Unlock()
+
+	var once sync.Once
+
+	cancel := func() {
+		once.Do(func() {
+			// unblocks a publisher waiting on a full buffer
+			close(sub.done)
+
+			st.data.subscribersMu.Lock()
+			delete(st.data.subscribers, sub)
+			st.data.subscribersMu.Unlock()
+
+			sub.mu.Lock()
+			sub.closed = true
+			close(sub.events)
+			sub.mu.Unlock()
+		})
+	}
+
+	return sub.events, cancel
+}
+
+// hasSubscribers returns true if anybody listens for changes
+func (st *Store) hasSubscribers() bool {
+	st.data.subscribersMu.RLock()
+	defer st.data.subscribersMu.RUnlock()
+
+	return len(st.data.subscribers) > 0
+}
+
+// changeRecordEntity records a change of an entity
+func (st *Store) changeRecordEntity(operation string, entity *entitystore.Entity, changedKeys []string) error {
+	return st.changeRecord(operation, entity.Type(), entity.ID(), entity.TenantID(), changedKeys, entity.ToMap())
+}
+
+// changeRecordAttribute records a change of an attribute,
+// looking up the type of the entity it belongs to
+func (st *Store) changeRecordAttribute(operation string, attr *entitystore.Attribute) error {
+	entityType := ""
+	tenantID := st.tenantID
+
+	st.data.mu.RLock()
+	entity, exists := st.data.entities[attr.EntityID()]
+	st.data.mu.RUnlock()
+
+	if exists {
+		entityType = entity.Type()
+		tenantID = entity.TenantID()
+	}
+
+	return st.changeRecord(operation, entityType, attr.EntityID(), tenantID, []string{attr.AttributeKey()}, attr.ToMap())
+}
+
+// changeRecord queues the change for the subscribers,
+// and writes it to the outbox if the outbox is enabled
+func (st *Store) changeRecord(operation string, entityType string, entityID string, tenantID string, changedKeys []string, payload map[string]any) error {
+	if changedKeys == nil {
+		changedKeys = []string{}
+	}
+
+	if st.hasSubscribers() {
+		st.tx.events = append(st.tx.events, entitystore.ChangeEvent{
+			Operation:   operation,
+			EntityType:  entityType,
+			EntityID:    entityID,
+			TenantID:    tenantID,
+			ChangedKeys: changedKeys,
+			OccurredAt:  time.Now(),
+		})
+	}
+
+	if !st.data.options.OutboxEnabled {
+		return nil
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	// numbered under the lock, so the outbox stays ordered by Sequence
+	sequence := st.nextSequence()
+
+	change := entitystore.Change{
+		ID:          sequenceID(sequence),
+		Operation:   operation,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		ChangedKeys: changedKeys,
+		Payload:     string(payloadJSON),
+		Sequence:    sequence,
+		CreatedAt:   time.Now(),
+	}
+
+	st.data.outbox = append(st.data.outbox, change)
+
+	st.tx.undo = append(st.tx.undo, func() {
+		for i, queued := range st.data.outbox {
+			if queued.ID == change.ID {
+				st.data.outbox = append(st.data.outbox[:i:i], st.data.outbox[i+1:]...)
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// changeEventsPublish delivers the events of a completed operation
+func (st *Store) changeEventsPublish(events []entitystore.ChangeEvent) {
+	if len(events) < 1 {
+		return
+	}
+
+	// the subscribers are copied, so the sends blocked on a full
+	// buffer do not hold the lock wanted by Subscribe and cancel
+	st.data.subscribersMu.RLock()
+
+	subscribers := make([]*subscription, 0, len(st.data.subscribers))
+
+	for sub := range st.data.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+
+	st.data.subscribersMu.RUnlock()
+
+	for _, event := range events {
+		for _, sub := range subscribers {
+			if !sub.filter.Matches(event) {
+				continue
+			}
+
+			if sub.tenantScoped && sub.tenantID != event.TenantID {
+				continue
+			}
+
+			sub.send(event, st.data.options.SubscriptionPolicy)
+		}
+	}
+}
+
+// send delivers the event, unless the subscription was cancelled
+func (sub *subscription) send(event entitystore.ChangeEvent, policy string) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if sub.closed {
+		return
+	}
+
+	if policy == entitystore.SUBSCRIPTION_POLICY_BLOCK {
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+		}
+		return
+	}
+
+	select {
+	case sub.events <- event:
+	case <-sub.done:
+	default:
+		// buffer is full, the event is dropped
+	}
+}
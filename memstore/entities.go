@@ -0,0 +1,417 @@
+package memstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+	"github.com/gouniverse/uid"
+)
+
+// EntityCreate creates a new entity
+func (st *Store) EntityCreate(entity *entitystore.Entity) (err error) {
+	// the ID may be assigned below, so it is read when returning
+	defer func() {
+		entityID := ""
+		if entity != nil {
+			entityID = entity.ID()
+		}
+		wrapOpError(&err, "EntityCreate", entityID)
+	}()
+
+	if entity == nil {
+		return errInvalidArgument("entity cannot be nil")
+	}
+
+	if entity.ID() == "" {
+		entity.SetID(uid.HumanUid())
+	}
+
+	if st.tenantScoped {
+		entity.SetTenantID(st.tenantID)
+	}
+
+	if entity.Version() < 1 {
+		entity.SetVersion(1)
+	}
+
+	if entity.CreatedAt().IsZero() {
+		entity.SetCreatedAt(time.Now())
+	}
+
+	if entity.UpdatedAt().IsZero() {
+		entity.SetUpdatedAt(time.Now())
+	}
+
+	return st.inTransaction(func(tx *Store) error {
+		if err := tx.entityHooksRun(hookBeforeEntityCreate, entity); err != nil {
+			return err
+		}
+
+		if err := tx.entityInsert(*entity); err != nil {
+			return err
+		}
+
+		if err := tx.changeRecordEntity(entitystore.CHANGE_ENTITY_CREATE, entity, nil); err != nil {
+			return err
+		}
+
+		return tx.entityHooksRun(hookAfterEntityCreate, entity)
+	})
+}
+
+// EntityCreateWithType quick shortcut method
+// to create an entity by providing only the type
+// NB. The ID will be auto-assigned
+func (st *Store) EntityCreateWithType(entityType string) (newEntity *entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityCreateWithType", "")
+
+	entity := st.NewEntity(entitystore.NewEntityOptions{
+		ID:        uid.HumanUid(),
+		Type:      entityType,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	})
+
+	err = st.EntityCreate(&entity)
+
+	return &entity, err
+}
+
+// EntityCreateWithTypeAndAttributes quick shortcut method
+// to create an entity by providing only the type as string
+// and the attributes as map
+// NB. The IDs will be auto-assigned
+func (st *Store) EntityCreateWithTypeAndAttributes(entityType string, attributes map[string]string) (newEntity *entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityCreateWithTypeAndAttributes", "")
+
+	err = st.inTransaction(func(tx *Store) error {
+		var err error
+
+		newEntity, err = tx.EntityCreateWithType(entityType)
+
+		if err != nil {
+			return err
+		}
+
+		for key, value := range attributes {
+			if _, err := tx.AttributeCreateWithKeyAndValue(newEntity.ID(), key, value); err != nil {
+				return err
+			}
+		}
+
+		// read again, each attribute has bumped the version
+		entityID := newEntity.ID()
+		newEntity, err = tx.EntityFindByID(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		if newEntity == nil {
+			return errEntityNotFound(entityID)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newEntity, nil
+}
+
+// EntityFindByID finds an entity by ID
+func (st *Store) EntityFindByID(entityID string) (entity *entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityFindByID", entityID)
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	st.data.mu.RLock()
+	found, exists := st.data.entities[entityID]
+	st.data.mu.RUnlock()
+
+	if !exists || (st.tenantScoped && found.TenantID() != st.tenantID) {
+		return nil, nil
+	}
+
+	found = st.bind(found)
+
+	return &found, nil
+}
+
+// EntityFindByHandle finds an entity by handle
+func (st *Store) EntityFindByHandle(entityType string, entityHandle string) (entity *entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityFindByHandle", "")
+
+	if entityType == "" {
+		return nil, errInvalidArgument("entity type cannot be empty")
+	}
+
+	if entityHandle == "" {
+		return nil, errInvalidArgument("entity handle cannot be empty")
+	}
+
+	list, err := st.entityQuery(entitystore.EntityQueryOptions{
+		EntityType:   entityType,
+		EntityHandle: entityHandle,
+		Limit:        1,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		return &list[0], nil
+	}
+
+	return nil, nil
+}
+
+// EntityFindByAttribute finds an entity by attribute
+func (st *Store) EntityFindByAttribute(entityType string, attributeKey string, attributeValue string) (entity *entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityFindByAttribute", "")
+
+	entityIDs := st.entityIDsByAttribute(entityType, attributeKey, attributeValue)
+
+	if len(entityIDs) < 1 {
+		return nil, nil
+	}
+
+	return st.EntityFindByID(entityIDs[0])
+}
+
+// EntityListByAttribute finds an entity by attribute
+func (st *Store) EntityListByAttribute(entityType string, attributeKey string, attributeValue string) (entityList []entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityListByAttribute", "")
+
+	entityIDs := st.entityIDsByAttribute(entityType, attributeKey, attributeValue)
+
+	if len(entityIDs) < 1 {
+		return entityList, nil
+	}
+
+	return st.EntityList(entitystore.EntityQueryOptions{
+		EntityType: entityType,
+		IDs:        entityIDs,
+		SortBy:     entitystore.COLUMN_ID,
+	})
+}
+
+// entityIDsByAttribute lists the IDs of the entities of the type
+// having the attribute value, sorted
+func (st *Store) entityIDsByAttribute(entityType string, attributeKey string, attributeValue string) []string {
+	attributes, _ := st.attributeQuery(entitystore.AttributeQueryOptions{
+		AttributeKey: attributeKey,
+		SortBy:       entitystore.COLUMN_ENTITY_ID,
+	})
+
+	st.data.mu.RLock()
+	defer st.data.mu.RUnlock()
+
+	entityIDs := []string{}
+
+	for _, attribute := range attributes {
+		if attribute.AttributeValue() != attributeValue {
+			continue
+		}
+
+		if entity, exists := st.data.entities[attribute.EntityID()]; exists && entity.Type() == entityType {
+			entityIDs = append(entityIDs, attribute.EntityID())
+		}
+	}
+
+	return entityIDs
+}
+
+// EntityList lists entities
+func (st *Store) EntityList(options entitystore.EntityQueryOptions) (entityList []entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityList", "")
+
+	return st.entityQuery(options)
+}
+
+// EntityCount counts entities
+func (st *Store) EntityCount(options entitystore.EntityQueryOptions) (count int64, err error) {
+	defer wrapOpError(&err, "EntityCount", "")
+
+	options.CountOnly = true
+
+	list, err := st.entityQuery(options)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(list)), nil
+}
+
+// EntityUpdate updates an entity.
+// Returns ErrEntityNotFound if the entity does not exist
+func (st *Store) EntityUpdate(ent entitystore.Entity) (err error) {
+	defer wrapOpError(&err, "EntityUpdate", ent.ID())
+
+	return st.entityUpdate(ent, false, 0)
+}
+
+// EntityUpdateIfVersion updates an entity only if its version in the store
+// still equals the expected version. Returns ErrVersionConflict otherwise,
+// or ErrEntityNotFound if the entity does not exist
+func (st *Store) EntityUpdateIfVersion(ent entitystore.Entity, expectedVersion int64) (err error) {
+	defer wrapOpError(&err, "EntityUpdateIfVersion", ent.ID())
+
+	return st.entityUpdate(ent, true, expectedVersion)
+}
+
+// entityUpdate updates an entity, with versionCheck only
+// if its version in the store equals the expected version
+func (st *Store) entityUpdate(ent entitystore.Entity, versionCheck bool, expectedVersion int64) error {
+	ent.SetUpdatedAt(time.Now())
+
+	if st.tenantScoped {
+		ent.SetTenantID(st.tenantID)
+	}
+
+	return st.inTransaction(func(tx *Store) error {
+		if err := tx.entityHooksRun(hookBeforeEntityUpdate, &ent); err != nil {
+			return err
+		}
+
+		if err := tx.entityReplace(ent, versionCheck, expectedVersion); err != nil {
+			return err
+		}
+
+		if err := tx.changeRecordEntity(entitystore.CHANGE_ENTITY_UPDATE, &ent, nil); err != nil {
+			return err
+		}
+
+		return tx.entityHooksRun(hookAfterEntityUpdate, &ent)
+	})
+}
+
+// EntityDelete deletes an entity and all attributes.
+// Returns ErrEntityNotFound if the entity does not exist
+func (st *Store) EntityDelete(entityID string) (isDeleted bool, err error) {
+	defer wrapOpError(&err, "EntityDelete", entityID)
+
+	if entityID == "" {
+		return false, errInvalidArgument("entity id cannot be empty")
+	}
+
+	err = st.inTransaction(func(tx *Store) error {
+		ent, err := tx.EntityFindByID(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		// the entities of other tenants are not found too
+		if ent == nil {
+			return errEntityNotFound(entityID)
+		}
+
+		if err := tx.entityHooksRun(hookBeforeEntityDelete, ent); err != nil {
+			return err
+		}
+
+		attrs, err := tx.EntityAttributeList(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		attributeKeys := []string{}
+
+		for _, attr := range attrs {
+			attributeKeys = append(attributeKeys, attr.AttributeKey())
+			tx.attributeHistoryRecord(entityID, attr.AttributeKey(), entitystore.OPERATION_DELETE, attr.AttributeValue(), "")
+		}
+
+		tx.entityLinksDelete(entityID)
+		tx.entityRemove(entityID)
+
+		if err := tx.changeRecordEntity(entitystore.CHANGE_ENTITY_DELETE, ent, attributeKeys); err != nil {
+			return err
+		}
+
+		return tx.entityHooksRun(hookAfterEntityDelete, ent)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// entityInsert adds the entity, failing with ErrDuplicate if its ID is taken
+func (st *Store) entityInsert(entity entitystore.Entity) error {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	if _, exists := st.data.entities[entity.ID()]; exists {
+		return fmt.Errorf("%w: entity %s already exists", entitystore.ErrDuplicate, entity.ID())
+	}
+
+	entity.SetStore(nil)
+	mapSet(st, st.data.entities, entity.ID(), entity)
+
+	return nil
+}
+
+// entityReplace replaces the stored entity, incrementing its version.
+// With versionCheck it fails with ErrVersionConflict if the stored
+// version does not equal the expected version
+func (st *Store) entityReplace(entity entitystore.Entity, versionCheck bool, expectedVersion int64) error {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	stored, exists := st.data.entities[entity.ID()]
+
+	if !exists || (st.tenantScoped && stored.TenantID() != st.tenantID) {
+		return errEntityNotFound(entity.ID())
+	}
+
+	if versionCheck && stored.Version() != expectedVersion {
+		return entitystore.ErrVersionConflict
+	}
+
+	entity.SetVersion(stored.Version() + 1)
+	entity.SetStore(nil)
+	mapSet(st, st.data.entities, entity.ID(), entity)
+
+	return nil
+}
+
+// entityVersionIncrement bumps the version of an entity,
+// used when one of its attributes is modified
+func (st *Store) entityVersionIncrement(entityID string) {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	entity, exists := st.data.entities[entityID]
+
+	if !exists {
+		return
+	}
+
+	entity.SetVersion(entity.Version() + 1)
+	mapSet(st, st.data.entities, entityID, entity)
+}
+
+// entityRemove removes an entity and its attributes
+func (st *Store) entityRemove(entityID string) {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	for attributeID := range st.data.attributes[entityID] {
+		mapDelete(st, st.data.attributes[entityID], attributeID)
+		mapDelete(st, st.data.attributeEntityIDs, attributeID)
+	}
+
+	mapDelete(st, st.data.entities, entityID)
+}
@@ -0,0 +1,42 @@
+package memstore
+
+import (
+	"fmt"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// wrapOpError wraps the error of a public method into an entitystore.OpError.
+// It is deferred with the named error result. An OpError returned
+// by a nested public method is unwrapped, so the outermost operation wins
+func wrapOpError(err *error, op string, entityID string) {
+	if *err == nil {
+		return
+	}
+
+	if opErr, ok := (*err).(*entitystore.OpError); ok {
+		if entityID == "" {
+			entityID = opErr.EntityID
+		}
+
+		*err = &entitystore.OpError{Op: op, EntityID: entityID, Err: opErr.Err}
+		return
+	}
+
+	*err = &entitystore.OpError{Op: op, EntityID: entityID, Err: *err}
+}
+
+// errNotEnabled returns an error wrapping ErrNotEnabled for the feature
+func errNotEnabled(feature string) error {
+	return fmt.Errorf("%w: %s", entitystore.ErrNotEnabled, feature)
+}
+
+// errInvalidArgument returns an error wrapping ErrInvalidArgument
+func errInvalidArgument(message string) error {
+	return fmt.Errorf("%w: %s", entitystore.ErrInvalidArgument, message)
+}
+
+// errEntityNotFound returns an error wrapping ErrEntityNotFound
+func errEntityNotFound(entityID string) error {
+	return fmt.Errorf("%w: entity %s does not exist", entitystore.ErrEntityNotFound, entityID)
+}
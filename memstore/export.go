@@ -0,0 +1,759 @@
+package memstore
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+	"github.com/gouniverse/uid"
+)
+
+// importBatchSizeDefault the number of entities imported per transaction
+const importBatchSizeDefault = 100
+
+// Export writes the entities with their attributes to w as JSON Lines,
+// one entitystore.ExportRecord per line, like the SQL store
+func (st *Store) Export(ctx context.Context, w io.Writer, options entitystore.ExportOptions) (err error) {
+	defer wrapOpError(&err, "Export", "")
+
+	if w == nil {
+		return errInvalidArgument("writer cannot be nil")
+	}
+
+	entities, err := st.entityQuery(entitystore.EntityQueryOptions{
+		EntityType: options.EntityType,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+
+	for _, entity := range entities {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := st.exportRecord(entity, false)
+
+		if err != nil {
+			return err
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	if !options.IncludeTrash {
+		return nil
+	}
+
+	trashed := st.trashList(func(entry trashEntry) bool {
+		return options.EntityType == "" || entry.entity.Type() == options.EntityType
+	})
+
+	for _, entry := range trashed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, err := st.exportRecord(entry.entity, true)
+
+		if err != nil {
+			return err
+		}
+
+		deletedAt := entry.deletedAt
+		record.DeletedAt = &deletedAt
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// exportRecord reads the attributes of the entity into an export record
+func (st *Store) exportRecord(entity entitystore.Entity, isTrashed bool) (entitystore.ExportRecord, error) {
+	record := entitystore.ExportRecord{
+		ID:         entity.ID(),
+		Type:       entity.Type(),
+		Handle:     entity.Handle(),
+		ParentID:   entity.ParentID(),
+		TenantID:   entity.TenantID(),
+		Version:    entity.Version(),
+		CreatedAt:  entity.CreatedAt(),
+		UpdatedAt:  entity.UpdatedAt(),
+		Attributes: map[string]string{},
+	}
+
+	attributes, err := st.entityAttributeListIncludingTrash(entity.ID(), isTrashed)
+
+	if err != nil {
+		return record, err
+	}
+
+	for _, attribute := range attributes {
+		record.Attributes[attribute.AttributeKey()] = attribute.AttributeValue()
+	}
+
+	return record, nil
+}
+
+// Import reads the JSON Lines written by Export, and recreates the entities
+// with their attributes. Each batch of records is imported atomically,
+// if a record fails its batch is rolled back, while the previous batches stay
+func (st *Store) Import(ctx context.Context, r io.Reader, options entitystore.ImportOptions) (result entitystore.ImportResult, err error) {
+	defer wrapOpError(&err, "Import", "")
+
+	if r == nil {
+		return result, errInvalidArgument("reader cannot be nil")
+	}
+
+	if options.OnConflict == "" {
+		options.OnConflict = entitystore.IMPORT_CONFLICT_FAIL
+	}
+
+	if options.OnConflict != entitystore.IMPORT_CONFLICT_FAIL && options.OnConflict != entitystore.IMPORT_CONFLICT_SKIP && options.OnConflict != entitystore.IMPORT_CONFLICT_OVERWRITE {
+		return result, errInvalidArgument("unsupported conflict policy " + options.OnConflict)
+	}
+
+	if options.BatchSize < 1 {
+		options.BatchSize = importBatchSizeDefault
+	}
+
+	// the hooks run during the import receive the context
+	view := st.WithContext(ctx).(*Store)
+
+	decoder := json.NewDecoder(r)
+	newIDs := map[string]string{}
+	// the parent IDs of the records, by the ID of their entity
+	parentIDs := map[string]string{}
+	batch := []entitystore.ExportRecord{}
+	line := 0
+
+	importBatch := func() error {
+		if len(batch) < 1 {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batchResult := entitystore.ImportResult{}
+
+		err := view.inTransaction(func(tx *Store) error {
+			for _, record := range batch {
+				if err := tx.importRecord(record, options, newIDs, parentIDs, &batchResult); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		result.Created += batchResult.Created
+		result.Updated += batchResult.Updated
+		result.Skipped += batchResult.Skipped
+		batch = []entitystore.ExportRecord{}
+
+		return nil
+	}
+
+	for {
+		record := entitystore.ExportRecord{}
+		err := decoder.Decode(&record)
+
+		if err == io.EOF {
+			break
+		}
+
+		line++
+
+		if err != nil {
+			return result, fmt.Errorf("%w: record %d: %v", entitystore.ErrInvalidArgument, line, err)
+		}
+
+		if record.Type == "" {
+			return result, fmt.Errorf("%w: record %d: entity type cannot be empty", entitystore.ErrInvalidArgument, line)
+		}
+
+		batch = append(batch, record)
+
+		if len(batch) >= options.BatchSize {
+			if err := importBatch(); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := importBatch(); err != nil {
+		return result, err
+	}
+
+	if options.PreserveIDs {
+		return result, nil
+	}
+
+	return result, view.importParentsLink(parentIDs, newIDs, options.BatchSize)
+}
+
+// importParentsLink sets the parents of the imported entities,
+// mapping the parent IDs of the records to the new IDs. A parent
+// which was not imported must already be in the store
+func (st *Store) importParentsLink(parentIDs map[string]string, newIDs map[string]string, batchSize int) error {
+	entityIDs := make([]string, 0, len(parentIDs))
+
+	for entityID := range parentIDs {
+		entityIDs = append(entityIDs, entityID)
+	}
+
+	sort.Strings(entityIDs)
+
+	for start := 0; start < len(entityIDs); start += batchSize {
+		end := min(start+batchSize, len(entityIDs))
+
+		err := st.inTransaction(func(tx *Store) error {
+			for _, entityID := range entityIDs[start:end] {
+				parentID, isImported := newIDs[parentIDs[entityID]]
+
+				if !isImported {
+					parent, _ := tx.entityFindIncludingTrash(parentIDs[entityID])
+
+					if parent == nil {
+						return fmt.Errorf("%w: parent entity %s of entity %s is neither imported nor in the store", entitystore.ErrEntityNotFound, parentIDs[entityID], entityID)
+					}
+
+					parentID = parent.ID()
+				}
+
+				if err := tx.importParentSet(entityID, parentID); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importParentSet sets the parent of an imported entity, in the trash bin too
+func (st *Store) importParentSet(entityID string, parentID string) error {
+	entity, isTrashed := st.entityFindIncludingTrash(entityID)
+
+	if entity == nil {
+		return errEntityNotFound(entityID)
+	}
+
+	if !isTrashed {
+		return st.EntitySetParent(entityID, parentID)
+	}
+
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	entry := st.data.trash[entityID]
+	entry.entity.SetParentID(parentID)
+	mapSet(st, st.data.trash, entityID, entry)
+
+	return nil
+}
+
+// importRecord creates the entity of the record, or resolves
+// the conflict with the existing entity according to the policy
+func (st *Store) importRecord(record entitystore.ExportRecord, options entitystore.ImportOptions, newIDs map[string]string, parentIDs map[string]string, result *entitystore.ImportResult) error {
+	var existing *entitystore.Entity
+	existingTrashed := false
+	var err error
+
+	if options.PreserveIDs && record.ID != "" {
+		existing, existingTrashed = st.entityFindIncludingTrash(record.ID)
+	} else if record.Handle != "" {
+		existing, err = st.EntityFindByHandle(record.Type, record.Handle)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// without the IDs preserved, the parents are linked after the import
+	parentID := record.ParentID
+
+	if !options.PreserveIDs {
+		parentID = ""
+	}
+
+	if existing != nil {
+		newIDs[record.ID] = existing.ID()
+
+		switch options.OnConflict {
+		case entitystore.IMPORT_CONFLICT_SKIP:
+			result.Skipped++
+			return nil
+		case entitystore.IMPORT_CONFLICT_FAIL:
+			return fmt.Errorf("%w: entity %s already exists", entitystore.ErrDuplicate, existing.ID())
+		}
+
+		if !options.PreserveIDs && record.ParentID != "" {
+			parentIDs[existing.ID()] = record.ParentID
+		}
+
+		// a trashed entity is replaced by the record
+		if existingTrashed {
+			st.trashPurge(existing.ID())
+
+			if err := st.importEntityCreate(existing.ID(), parentID, record); err != nil {
+				return err
+			}
+
+			result.Updated++
+			return nil
+		}
+
+		if err := st.importEntityOverwrite(*existing, parentID, record); err != nil {
+			return err
+		}
+
+		result.Updated++
+		return nil
+	}
+
+	entityID := uid.HumanUid()
+
+	if options.PreserveIDs && record.ID != "" {
+		entityID = record.ID
+	}
+
+	newIDs[record.ID] = entityID
+
+	if !options.PreserveIDs && record.ParentID != "" {
+		parentIDs[entityID] = record.ParentID
+	}
+
+	if err := st.importEntityCreate(entityID, parentID, record); err != nil {
+		return err
+	}
+
+	result.Created++
+	return nil
+}
+
+// importEntityCreate creates the entity of the record with its attributes,
+// and moves it to the trash bin if the record was trashed
+func (st *Store) importEntityCreate(entityID string, parentID string, record entitystore.ExportRecord) error {
+	entity := st.NewEntity(entitystore.NewEntityOptions{
+		ID:        entityID,
+		Type:      record.Type,
+		Handle:    record.Handle,
+		ParentID:  parentID,
+		TenantID:  record.TenantID,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	})
+
+	if err := st.EntityCreate(&entity); err != nil {
+		return err
+	}
+
+	for _, key := range importAttributeKeys(record) {
+		attribute := st.NewAttribute(entitystore.NewAttributeOptions{
+			EntityID:       entityID,
+			AttributeKey:   key,
+			AttributeValue: record.Attributes[key],
+			CreatedAt:      record.CreatedAt,
+			UpdatedAt:      record.UpdatedAt,
+		})
+
+		if err := st.AttributeCreate(&attribute); err != nil {
+			return err
+		}
+	}
+
+	if record.DeletedAt == nil {
+		return nil
+	}
+
+	_, err := st.entityTrash(entityID, *record.DeletedAt)
+
+	return err
+}
+
+// importEntityOverwrite updates the existing entity to match the record,
+// the attributes missing from the record are deleted
+func (st *Store) importEntityOverwrite(entity entitystore.Entity, parentID string, record entitystore.ExportRecord) error {
+	entity.SetType(record.Type)
+	entity.SetHandle(record.Handle)
+	entity.SetParentID(parentID)
+
+	if err := st.EntityUpdate(entity); err != nil {
+		return err
+	}
+
+	attributes, err := st.EntityAttributeList(entity.ID())
+
+	if err != nil {
+		return err
+	}
+
+	for _, attribute := range attributes {
+		if _, exists := record.Attributes[attribute.AttributeKey()]; exists {
+			continue
+		}
+
+		if _, err := st.AttributeDelete(entity.ID(), attribute.AttributeKey()); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range importAttributeKeys(record) {
+		if err := st.AttributeSetString(entity.ID(), key, record.Attributes[key]); err != nil {
+			return err
+		}
+	}
+
+	if record.DeletedAt == nil {
+		return nil
+	}
+
+	_, err = st.entityTrash(entity.ID(), *record.DeletedAt)
+
+	return err
+}
+
+// importAttributeKeys the attribute keys of the record, sorted
+// so the attributes are always created in the same order
+func importAttributeKeys(record entitystore.ExportRecord) []string {
+	keys := make([]string, 0, len(record.Attributes))
+
+	for key := range record.Attributes {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ExportCSV writes the entities of a type to w as CSV, one row per entity
+// with its id, handle and timestamps, followed by a column per attribute,
+// like the SQL store
+func (st *Store) ExportCSV(ctx context.Context, w io.Writer, entityType string, options entitystore.CSVOptions) (err error) {
+	defer wrapOpError(&err, "ExportCSV", "")
+
+	if w == nil {
+		return errInvalidArgument("writer cannot be nil")
+	}
+
+	if entityType == "" {
+		return errInvalidArgument("entity type cannot be empty")
+	}
+
+	entities, err := st.entityQuery(entitystore.EntityQueryOptions{
+		EntityType: entityType,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	records := []entitystore.ExportRecord{}
+	keys := map[string]bool{}
+
+	for _, entity := range entities {
+		record, err := st.exportRecord(entity, false)
+
+		if err != nil {
+			return err
+		}
+
+		for key := range record.Attributes {
+			keys[key] = true
+		}
+
+		records = append(records, record)
+	}
+
+	columns := options.Columns
+
+	if len(columns) < 1 {
+		for key := range keys {
+			columns = append(columns, key)
+		}
+
+		sort.Strings(columns)
+	}
+
+	writer := csv.NewWriter(w)
+
+	if options.Comma != 0 {
+		writer.Comma = options.Comma
+	}
+
+	header := []string{entitystore.CSV_COLUMN_ID, entitystore.CSV_COLUMN_HANDLE, entitystore.CSV_COLUMN_CREATED_AT, entitystore.CSV_COLUMN_UPDATED_AT}
+
+	for _, column := range columns {
+		if contains(header, column) {
+			return errInvalidArgument("attribute " + column + " has the name of a fixed column, list the columns to export without it")
+		}
+	}
+
+	header = append(header, columns...)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		row := []string{
+			csvCellEscape(record.ID),
+			csvCellEscape(record.Handle),
+			record.CreatedAt.UTC().Format(time.DateTime),
+			record.UpdatedAt.UTC().Format(time.DateTime),
+		}
+
+		for _, column := range columns {
+			row = append(row, csvCellEscape(record.Attributes[column]))
+		}
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// csvNumberPattern matches the plain numbers, not escaped by csvCellEscape
+var csvNumberPattern = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?$`)
+
+// csvCellEscape prefixes with a quote the cells a spreadsheet could take
+// for a formula, except the plain numbers, and the cells starting with
+// a quote, like the SQL store
+func csvCellEscape(value string) string {
+	if value == "" || csvNumberPattern.MatchString(value) {
+		return value
+	}
+
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r', '\'':
+		return "'" + value
+	}
+
+	return value
+}
+
+// csvCellUnescape removes the prefix added by csvCellEscape. A quote
+// typed by hand, which csvCellEscape would not have added, is kept
+func csvCellUnescape(value string) string {
+	if strings.HasPrefix(value, "'") && csvCellEscape(value[1:]) == value {
+		return value[1:]
+	}
+
+	return value
+}
+
+// ImportCSV creates or updates the entities of a type from the rows of a CSV
+// file with a header, like the SQL store. Each row is imported atomically,
+// the failed rows are reported in the result and the import goes on
+func (st *Store) ImportCSV(ctx context.Context, r io.Reader, entityType string, mapping entitystore.CSVMapping) (result entitystore.CSVImportResult, err error) {
+	defer wrapOpError(&err, "ImportCSV", "")
+
+	if r == nil {
+		return result, errInvalidArgument("reader cannot be nil")
+	}
+
+	if entityType == "" {
+		return result, errInvalidArgument("entity type cannot be empty")
+	}
+
+	if mapping.MatchBy == "" {
+		mapping.MatchBy = entitystore.CSV_MATCH_ID
+	}
+
+	if mapping.MatchBy != entitystore.CSV_MATCH_ID && mapping.MatchBy != entitystore.CSV_MATCH_HANDLE {
+		return result, errInvalidArgument("unsupported match column " + mapping.MatchBy)
+	}
+
+	reader := csv.NewReader(r)
+
+	if mapping.Comma != 0 {
+		reader.Comma = mapping.Comma
+	}
+
+	header, err := reader.Read()
+
+	if err == io.EOF {
+		return result, nil
+	}
+
+	if err != nil {
+		return result, errInvalidArgument("header cannot be read: " + err.Error())
+	}
+
+	columnIndexes := map[string]int{}
+
+	for index, column := range header {
+		columnIndexes[column] = index
+	}
+
+	if _, exists := columnIndexes[mapping.MatchBy]; !exists && mapping.MatchBy == entitystore.CSV_MATCH_HANDLE {
+		return result, errInvalidArgument("handle column is required to match by handle")
+	}
+
+	attributeColumns := map[int]string{}
+
+	for index, column := range header {
+		if len(mapping.Attributes) > 0 {
+			if key, exists := mapping.Attributes[column]; exists && key != "" {
+				attributeColumns[index] = key
+			}
+
+			continue
+		}
+
+		switch column {
+		case entitystore.CSV_COLUMN_ID, entitystore.CSV_COLUMN_HANDLE, entitystore.CSV_COLUMN_CREATED_AT, entitystore.CSV_COLUMN_UPDATED_AT:
+			continue
+		}
+
+		attributeColumns[index] = column
+	}
+
+	// the hooks run during the import receive the context
+	view := st.WithContext(ctx).(*Store)
+
+	for row := 2; ; row++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		values, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if errors.Is(err, csv.ErrFieldCount) {
+			result.Errors = append(result.Errors, entitystore.CSVRowError{Row: row, Err: errInvalidArgument("wrong number of fields")})
+			continue
+		}
+
+		if err != nil {
+			return result, errInvalidArgument("row " + strconv.Itoa(row) + " cannot be read: " + err.Error())
+		}
+
+		cell := func(column string) string {
+			if index, exists := columnIndexes[column]; exists {
+				return csvCellUnescape(values[index])
+			}
+
+			return ""
+		}
+
+		attributes := map[string]string{}
+
+		for index, key := range attributeColumns {
+			if values[index] != "" {
+				attributes[key] = csvCellUnescape(values[index])
+			}
+		}
+
+		isCreated := false
+
+		err = view.inTransaction(func(tx *Store) error {
+			var err error
+			isCreated, err = tx.importCSVRow(entityType, cell(entitystore.CSV_COLUMN_ID), cell(entitystore.CSV_COLUMN_HANDLE), attributes, mapping.MatchBy)
+			return err
+		})
+
+		if err != nil {
+			result.Errors = append(result.Errors, entitystore.CSVRowError{Row: row, Err: err})
+			continue
+		}
+
+		if isCreated {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	return result, nil
+}
+
+// importCSVRow creates or updates the entity of a row, returns true if created
+func (st *Store) importCSVRow(entityType string, entityID string, entityHandle string, attributes map[string]string, matchBy string) (bool, error) {
+	var existing *entitystore.Entity
+	var err error
+
+	if matchBy == entitystore.CSV_MATCH_HANDLE {
+		if entityHandle == "" {
+			return false, errInvalidArgument("handle cannot be empty")
+		}
+
+		existing, err = st.EntityFindByHandle(entityType, entityHandle)
+	} else if entityID != "" {
+		existing, err = st.EntityFindByID(entityID)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	if existing == nil {
+		entity := st.NewEntity(entitystore.NewEntityOptions{
+			ID:     entityID,
+			Type:   entityType,
+			Handle: entityHandle,
+		})
+
+		if err := st.EntityCreate(&entity); err != nil {
+			return false, err
+		}
+
+		return true, st.AttributesSet(entity.ID(), attributes)
+	}
+
+	if existing.Type() != entityType {
+		return false, errInvalidArgument("entity " + existing.ID() + " is not of type " + entityType)
+	}
+
+	if matchBy == entitystore.CSV_MATCH_ID && entityHandle != "" && entityHandle != existing.Handle() {
+		existing.SetHandle(entityHandle)
+
+		if err := st.EntityUpdate(*existing); err != nil {
+			return false, err
+		}
+	}
+
+	return false, st.AttributesSet(existing.ID(), attributes)
+}
@@ -0,0 +1,77 @@
+package memstore
+
+import (
+	"sort"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// AttributeFacets counts the entities of a type having each distinct value
+// of the attributes, among the entities matching the filter
+func (st *Store) AttributeFacets(entityType string, attributeKeys []string, options entitystore.FacetOptions) (facets map[string][]entitystore.FacetValue, err error) {
+	defer wrapOpError(&err, "AttributeFacets", "")
+
+	if entityType == "" {
+		return nil, errInvalidArgument("entity type cannot be empty")
+	}
+
+	for _, key := range attributeKeys {
+		if key == "" {
+			return nil, errInvalidArgument("attribute key cannot be empty")
+		}
+	}
+
+	filter := options.Filter
+	filter.EntityType = entityType
+	filter.CountOnly = true
+
+	entities, err := st.entityQuery(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]map[string]int64{}
+
+	for _, key := range attributeKeys {
+		counts[key] = map[string]int64{}
+	}
+
+	st.data.mu.RLock()
+
+	for _, entity := range entities {
+		for _, attribute := range st.data.attributes[entity.ID()] {
+			if keyCounts, exists := counts[attribute.AttributeKey()]; exists {
+				keyCounts[attribute.AttributeValue()]++
+			}
+		}
+	}
+
+	st.data.mu.RUnlock()
+
+	facets = map[string][]entitystore.FacetValue{}
+
+	for key, keyCounts := range counts {
+		values := []entitystore.FacetValue{}
+
+		for value, count := range keyCounts {
+			values = append(values, entitystore.FacetValue{Value: value, Count: count})
+		}
+
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+
+			return values[i].Value < values[j].Value
+		})
+
+		if options.Limit > 0 && uint64(len(values)) > options.Limit {
+			values = values[:options.Limit]
+		}
+
+		facets[key] = values
+	}
+
+	return facets, nil
+}
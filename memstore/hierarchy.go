@@ -0,0 +1,192 @@
+package memstore
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// hierarchyDepthLimit guards the tree walks against runaway recursion,
+// like the recursive queries of the SQL store
+const hierarchyDepthLimit = 1000
+
+// EntitySetParent moves an entity under a parent entity.
+// An empty parent ID makes the entity a root entity
+func (st *Store) EntitySetParent(entityID string, parentID string) (err error) {
+	defer wrapOpError(&err, "EntitySetParent", entityID)
+
+	if entityID == "" {
+		return errInvalidArgument("entity id cannot be empty")
+	}
+
+	if entityID == parentID {
+		return errInvalidArgument("entity cannot be its own parent")
+	}
+
+	return st.inTransaction(func(tx *Store) error {
+		entity, err := tx.EntityFindByID(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		if entity == nil {
+			return errEntityNotFound(entityID)
+		}
+
+		if parentID != "" {
+			parent, err := tx.EntityFindByID(parentID)
+
+			if err != nil {
+				return err
+			}
+
+			if parent == nil {
+				return fmt.Errorf("%w: parent entity %s does not exist", entitystore.ErrEntityNotFound, parentID)
+			}
+
+			if contains(tx.entityTreeIDs(parentID, true, 0), entityID) {
+				return errInvalidArgument("entity cannot be moved under its own descendant")
+			}
+		}
+
+		entity.SetParentID(parentID)
+
+		return tx.EntityUpdate(*entity)
+	})
+}
+
+// EntityChildren lists the direct children of an entity
+func (st *Store) EntityChildren(entityID string) (children []entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityChildren", entityID)
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	return st.EntityList(entitystore.EntityQueryOptions{
+		ParentID: entityID,
+	})
+}
+
+// EntityAncestors lists the ancestors of an entity, starting with its parent
+func (st *Store) EntityAncestors(entityID string) (ancestors []entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityAncestors", entityID)
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	return st.entityListInOrder(st.entityTreeIDs(entityID, true, 0))
+}
+
+// EntityDescendants lists the descendants of an entity, nearest first,
+// up to maxDepth levels deep (0 for all levels)
+func (st *Store) EntityDescendants(entityID string, maxDepth int) (descendants []entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityDescendants", entityID)
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	return st.entityListInOrder(st.entityTreeIDs(entityID, false, maxDepth))
+}
+
+// entityTreeIDs walks the entity hierarchy upwards (ancestors)
+// or downwards (descendants), returning the IDs of the found
+// entities ordered by depth, nearest first
+func (st *Store) entityTreeIDs(entityID string, upwards bool, maxDepth int) []string {
+	if maxDepth <= 0 || maxDepth > hierarchyDepthLimit {
+		maxDepth = hierarchyDepthLimit
+	}
+
+	st.data.mu.RLock()
+	defer st.data.mu.RUnlock()
+
+	visible := func(id string) bool {
+		entity, exists := st.data.entities[id]
+		return exists && (!st.tenantScoped || entity.TenantID() == st.tenantID)
+	}
+
+	if !visible(entityID) {
+		return []string{}
+	}
+
+	childIDs := map[string][]string{}
+
+	if !upwards {
+		for id, entity := range st.data.entities {
+			if entity.ParentID() != "" && visible(id) {
+				childIDs[entity.ParentID()] = append(childIDs[entity.ParentID()], id)
+			}
+		}
+	}
+
+	ids := []string{}
+	visited := map[string]bool{entityID: true}
+	level := []string{entityID}
+
+	for depth := 0; depth < maxDepth && len(level) > 0; depth++ {
+		next := []string{}
+
+		for _, id := range level {
+			if upwards {
+				entity := st.data.entities[id]
+				parentID := entity.ParentID()
+
+				if parentID != "" && visible(parentID) && !visited[parentID] {
+					next = append(next, parentID)
+				}
+
+				continue
+			}
+
+			for _, childID := range childIDs[id] {
+				if !visited[childID] {
+					next = append(next, childID)
+				}
+			}
+		}
+
+		sort.Strings(next)
+
+		for _, id := range next {
+			visited[id] = true
+		}
+
+		ids = append(ids, next...)
+		level = next
+	}
+
+	return ids
+}
+
+// entityListInOrder lists the entities with the IDs, keeping the order of the IDs
+func (st *Store) entityListInOrder(ids []string) ([]entitystore.Entity, error) {
+	if len(ids) < 1 {
+		return []entitystore.Entity{}, nil
+	}
+
+	list, err := st.EntityList(entitystore.EntityQueryOptions{IDs: ids})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByID := map[string]entitystore.Entity{}
+
+	for _, entity := range list {
+		entitiesByID[entity.ID()] = entity
+	}
+
+	entities := []entitystore.Entity{}
+
+	for _, id := range ids {
+		if entity, exists := entitiesByID[id]; exists {
+			entities = append(entities, entity)
+		}
+	}
+
+	return entities, nil
+}
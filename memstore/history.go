@@ -0,0 +1,249 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// AttributeHistory lists the changes of an entity attribute, oldest first
+func (st *Store) AttributeHistory(entityID string, attributeKey string) (entries []entitystore.AttributeHistoryEntry, err error) {
+	defer wrapOpError(&err, "AttributeHistory", entityID)
+
+	if attributeKey == "" {
+		return nil, errInvalidArgument("attribute key cannot be empty")
+	}
+
+	return st.EntityHistory(entityID, entitystore.EntityHistoryOptions{
+		AttributeKey: attributeKey,
+	})
+}
+
+// EntityHistory lists the attribute changes of an entity,
+// oldest first unless SortOrder is desc
+func (st *Store) EntityHistory(entityID string, options entitystore.EntityHistoryOptions) (entries []entitystore.AttributeHistoryEntry, err error) {
+	defer wrapOpError(&err, "EntityHistory", entityID)
+
+	if !st.data.options.AttributeHistoryEnabled {
+		return nil, errNotEnabled("attribute history")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if !st.tenantEntityVisible(entityID) {
+		return []entitystore.AttributeHistoryEntry{}, nil
+	}
+
+	st.data.mu.RLock()
+
+	for _, entry := range st.data.history[entityID] {
+		if options.AttributeKey != "" && entry.AttributeKey != options.AttributeKey {
+			continue
+		}
+
+		if !options.Since.IsZero() && entry.ChangedAt.Before(options.Since) {
+			continue
+		}
+
+		if !options.Until.IsZero() && entry.ChangedAt.After(options.Until) {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	st.data.mu.RUnlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if options.SortOrder == "desc" {
+			i, j = j, i
+		}
+
+		if !entries[i].ChangedAt.Equal(entries[j].ChangedAt) {
+			return entries[i].ChangedAt.Before(entries[j].ChangedAt)
+		}
+
+		return entries[i].ID < entries[j].ID
+	})
+
+	return paginate(entries, options.Offset, options.Limit), nil
+}
+
+// EntityAsOf returns the entity with the attribute values it had at
+// the specified moment, replayed from the attribute history.
+// Entities in the trash bin are included. Returns nil if the entity
+// does not exist, or did not exist yet at that moment
+func (st *Store) EntityAsOf(entityID string, t time.Time) (snapshot *entitystore.EntitySnapshot, err error) {
+	defer wrapOpError(&err, "EntityAsOf", entityID)
+
+	if !st.data.options.AttributeHistoryEnabled {
+		return nil, errNotEnabled("attribute history")
+	}
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	entity, isTrashed := st.entityFindIncludingTrash(entityID)
+
+	if entity == nil || entity.CreatedAt().After(t) {
+		return nil, nil
+	}
+
+	history, err := st.EntityHistory(entityID, entitystore.EntityHistoryOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]string{}
+	keysWithHistory := map[string]bool{}
+	keysReplayed := map[string]bool{}
+	firstEntriesAfter := map[string]entitystore.AttributeHistoryEntry{}
+
+	for _, entry := range history {
+		keysWithHistory[entry.AttributeKey] = true
+
+		if entry.ChangedAt.After(t) {
+			if _, exists := firstEntriesAfter[entry.AttributeKey]; !exists {
+				firstEntriesAfter[entry.AttributeKey] = entry
+			}
+
+			continue
+		}
+
+		keysReplayed[entry.AttributeKey] = true
+
+		if entry.Operation == entitystore.OPERATION_DELETE {
+			delete(attributes, entry.AttributeKey)
+		} else {
+			attributes[entry.AttributeKey] = entry.NewValue
+		}
+	}
+
+	// Attributes without entries up to the moment, but changed
+	// after it, had the old value of their first change
+	for key, entry := range firstEntriesAfter {
+		if keysReplayed[key] || entry.Operation == entitystore.OPERATION_CREATE {
+			continue
+		}
+
+		attributes[key] = entry.OldValue
+	}
+
+	// Attributes imported with their own timestamps may have no entries,
+	// their current value is the one they always had
+	currentAttributes, err := st.entityAttributeListIncludingTrash(entityID, isTrashed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attr := range currentAttributes {
+		if keysWithHistory[attr.AttributeKey()] || attr.CreatedAt().After(t) {
+			continue
+		}
+
+		attributes[attr.AttributeKey()] = attr.AttributeValue()
+	}
+
+	return &entitystore.EntitySnapshot{
+		Entity:     *entity,
+		Attributes: attributes,
+		AsOf:       t,
+	}, nil
+}
+
+// EntityDiff compares the attributes of an entity at two moments in time
+func (st *Store) EntityDiff(entityID string, t1 time.Time, t2 time.Time) (diff *entitystore.EntityDiffResult, err error) {
+	defer wrapOpError(&err, "EntityDiff", entityID)
+
+	before, err := st.EntityAsOf(entityID, t1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := st.EntityAsOf(entityID, t2)
+
+	if err != nil {
+		return nil, err
+	}
+
+	beforeAttributes := map[string]string{}
+	afterAttributes := map[string]string{}
+
+	if before != nil {
+		beforeAttributes = before.Attributes
+	}
+
+	if after != nil {
+		afterAttributes = after.Attributes
+	}
+
+	diff = &entitystore.EntityDiffResult{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []string{},
+	}
+
+	for key, afterValue := range afterAttributes {
+		beforeValue, exists := beforeAttributes[key]
+
+		if !exists {
+			diff.Added = append(diff.Added, key)
+		} else if beforeValue != afterValue {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+
+	for key := range beforeAttributes {
+		if _, exists := afterAttributes[key]; !exists {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+
+	return diff, nil
+}
+
+// attributeHistoryRecord records a change of an attribute value.
+// Does nothing if the attribute history is not enabled
+func (st *Store) attributeHistoryRecord(entityID string, attributeKey string, operation string, oldValue string, newValue string) {
+	if !st.data.options.AttributeHistoryEnabled {
+		return
+	}
+
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	entry := entitystore.AttributeHistoryEntry{
+		ID:           st.nextSequenceID(),
+		EntityID:     entityID,
+		AttributeKey: attributeKey,
+		Operation:    operation,
+		OldValue:     oldValue,
+		NewValue:     newValue,
+		ChangedAt:    time.Now(),
+		ChangedBy:    st.actor,
+	}
+
+	st.data.history[entityID] = append(st.data.history[entityID], entry)
+
+	st.tx.undo = append(st.tx.undo, func() {
+		entries := st.data.history[entityID]
+
+		for i := range entries {
+			if entries[i].ID == entry.ID {
+				st.data.history[entityID] = append(entries[:i:i], entries[i+1:]...)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package memstore
+
+import (
+	"github.com/gouniverse/entitystore"
+)
+
+const hookBeforeEntityCreate = "before_entity_create"
+const hookAfterEntityCreate = "after_entity_create"
+const hookBeforeEntityUpdate = "before_entity_update"
+const hookAfterEntityUpdate = "after_entity_update"
+const hookBeforeEntityTrash = "before_entity_trash"
+const hookAfterEntityTrash = "after_entity_trash"
+const hookBeforeEntityDelete = "before_entity_delete"
+const hookAfterEntityDelete = "after_entity_delete"
+const hookBeforeAttributeSet = "before_attribute_set"
+const hookAfterAttributeSet = "after_attribute_set"
+const hookBeforeAttributeDelete = "before_attribute_delete"
+const hookAfterAttributeDelete = "after_attribute_delete"
+
+// BeforeEntityCreate registers a hook called before an entity is created
+func (st *Store) BeforeEntityCreate(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookBeforeEntityCreate, hook)
+}
+
+// AfterEntityCreate registers a hook called after an entity is created
+func (st *Store) AfterEntityCreate(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookAfterEntityCreate, hook)
+}
+
+// BeforeEntityUpdate registers a hook called before an entity is updated
+func (st *Store) BeforeEntityUpdate(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookBeforeEntityUpdate, hook)
+}
+
+// AfterEntityUpdate registers a hook called after an entity is updated
+func (st *Store) AfterEntityUpdate(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookAfterEntityUpdate, hook)
+}
+
+// BeforeEntityTrash registers a hook called before an entity is moved to the trash bin
+func (st *Store) BeforeEntityTrash(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookBeforeEntityTrash, hook)
+}
+
+// AfterEntityTrash registers a hook called after an entity is moved to the trash bin
+func (st *Store) AfterEntityTrash(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookAfterEntityTrash, hook)
+}
+
+// BeforeEntityDelete registers a hook called before an entity is deleted
+func (st *Store) BeforeEntityDelete(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookBeforeEntityDelete, hook)
+}
+
+// AfterEntityDelete registers a hook called after an entity is deleted
+func (st *Store) AfterEntityDelete(hook entitystore.EntityHook) {
+	st.entityHookAdd(hookAfterEntityDelete, hook)
+}
+
+// BeforeAttributeSet registers a hook called before an attribute is created or updated
+func (st *Store) BeforeAttributeSet(hook entitystore.AttributeHook) {
+	st.attributeHookAdd(hookBeforeAttributeSet, hook)
+}
+
+// AfterAttributeSet registers a hook called after an attribute is created or updated
+func (st *Store) AfterAttributeSet(hook entitystore.AttributeHook) {
+	st.attributeHookAdd(hookAfterAttributeSet, hook)
+}
+
+// BeforeAttributeDelete registers a hook called before an attribute is deleted
+func (st *Store) BeforeAttributeDelete(hook entitystore.AttributeHook) {
+	st.attributeHookAdd(hookBeforeAttributeDelete, hook)
+}
+
+// AfterAttributeDelete registers a hook called after an attribute is deleted
+func (st *Store) AfterAttributeDelete(hook entitystore.AttributeHook) {
+	st.attributeHookAdd(hookAfterAttributeDelete, hook)
+}
+
+func (st *Store) entityHookAdd(event string, hook entitystore.EntityHook) {
+	st.data.hooksMu.Lock()
+	defer st.data.hooksMu.Unlock()
+
+	st.data.entityHooks[event] = append(st.data.entityHooks[event], hook)
+}
+
+func (st *Store) attributeHookAdd(event string, hook entitystore.AttributeHook) {
+	st.data.hooksMu.Lock()
+	defer st.data.hooksMu.Unlock()
+
+	st.data.attributeHooks[event] = append(st.data.attributeHooks[event], hook)
+}
+
+// entityHooksRun runs the entity hooks registered for the event,
+// stopping at the first error. No lock is held, so the hooks may use the store
+func (st *Store) entityHooksRun(event string, entity *entitystore.Entity) error {
+	st.data.hooksMu.RLock()
+	hooks := st.data.entityHooks[event]
+	st.data.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(st.context(), entity); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attributeHooksRun runs the attribute hooks registered for the event,
+// stopping at the first error. No lock is held, so the hooks may use the store
+func (st *Store) attributeHooksRun(event string, attribute *entitystore.Attribute) error {
+	st.data.hooksMu.RLock()
+	hooks := st.data.attributeHooks[event]
+	st.data.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(st.context(), attribute); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
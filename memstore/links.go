@@ -0,0 +1,253 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+	"github.com/gouniverse/uid"
+)
+
+// EntityLink links two existing entities with the specified relation.
+// If the link already exists its position and metadata are updated
+func (st *Store) EntityLink(fromEntityID string, toEntityID string, relation string, options entitystore.EntityLinkOptions) (link *entitystore.Link, err error) {
+	defer wrapOpError(&err, "EntityLink", fromEntityID)
+
+	if fromEntityID == "" || toEntityID == "" {
+		return nil, errInvalidArgument("entity ids cannot be empty")
+	}
+
+	if relation == "" {
+		return nil, errInvalidArgument("relation cannot be empty")
+	}
+
+	err = st.inTransaction(func(tx *Store) error {
+		for _, entityID := range []string{fromEntityID, toEntityID} {
+			entity, err := tx.EntityFindByID(entityID)
+
+			if err != nil {
+				return err
+			}
+
+			if entity == nil {
+				return errEntityNotFound(entityID)
+			}
+		}
+
+		existing := tx.linkList(func(candidate entitystore.Link) bool {
+			return candidate.FromID == fromEntityID && candidate.ToID == toEntityID && candidate.Relation == relation
+		})
+
+		if len(existing) > 0 {
+			link = &existing[0]
+			link.Position = options.Position
+			link.Metadata = options.Metadata
+			link.UpdatedAt = time.Now()
+		} else {
+			link = &entitystore.Link{
+				ID:        uid.HumanUid(),
+				FromID:    fromEntityID,
+				ToID:      toEntityID,
+				Relation:  relation,
+				Position:  options.Position,
+				Metadata:  options.Metadata,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+		}
+
+		if link.Metadata == nil {
+			link.Metadata = map[string]string{}
+		}
+
+		tx.data.mu.Lock()
+		defer tx.data.mu.Unlock()
+
+		mapSet(tx, tx.data.links, link.ID, *link)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// EntityLinks lists the links of an entity, ordered by position.
+// The direction is one of LINK_DIRECTION_OUTGOING (default),
+// LINK_DIRECTION_INCOMING or LINK_DIRECTION_BOTH. An empty relation
+// matches all relations
+func (st *Store) EntityLinks(entityID string, relation string, direction string) (links []entitystore.Link, err error) {
+	defer wrapOpError(&err, "EntityLinks", entityID)
+
+	if entityID == "" {
+		return nil, errInvalidArgument("entity id cannot be empty")
+	}
+
+	if !st.tenantEntityVisible(entityID) {
+		return []entitystore.Link{}, nil
+	}
+
+	var matches func(link entitystore.Link) bool
+
+	switch direction {
+	case entitystore.LINK_DIRECTION_INCOMING:
+		matches = func(link entitystore.Link) bool { return link.ToID == entityID }
+	case entitystore.LINK_DIRECTION_BOTH:
+		matches = func(link entitystore.Link) bool { return link.FromID == entityID || link.ToID == entityID }
+	case entitystore.LINK_DIRECTION_OUTGOING, "":
+		matches = func(link entitystore.Link) bool { return link.FromID == entityID }
+	default:
+		return nil, errInvalidArgument("unsupported link direction " + direction)
+	}
+
+	return st.linkList(func(link entitystore.Link) bool {
+		return matches(link) && (relation == "" || link.Relation == relation)
+	}), nil
+}
+
+// EntityUnlink removes the link with the specified relation between two entities.
+// Returns false if the entities were not linked
+func (st *Store) EntityUnlink(fromEntityID string, toEntityID string, relation string) (isUnlinked bool, err error) {
+	defer wrapOpError(&err, "EntityUnlink", fromEntityID)
+
+	if fromEntityID == "" || toEntityID == "" {
+		return false, errInvalidArgument("entity ids cannot be empty")
+	}
+
+	if relation == "" {
+		return false, errInvalidArgument("relation cannot be empty")
+	}
+
+	if !st.tenantEntityVisible(fromEntityID) {
+		return false, nil
+	}
+
+	err = st.inTransaction(func(tx *Store) error {
+		isUnlinked = tx.linksDelete(func(link entitystore.Link) bool {
+			return link.FromID == fromEntityID && link.ToID == toEntityID && link.Relation == relation
+		}) > 0
+
+		return nil
+	})
+
+	return isUnlinked, err
+}
+
+// EntityListRelated lists the entities linked from the specified entity
+// with the relation. Unless options.SortBy is set, the entities are
+// returned in the order of the link positions
+func (st *Store) EntityListRelated(entityID string, relation string, options entitystore.EntityQueryOptions) (entities []entitystore.Entity, err error) {
+	defer wrapOpError(&err, "EntityListRelated", entityID)
+
+	links, err := st.EntityLinks(entityID, relation, entitystore.LINK_DIRECTION_OUTGOING)
+
+	if err != nil {
+		return nil, err
+	}
+
+	relatedIDs := []string{}
+
+	for _, link := range links {
+		if len(options.IDs) > 0 && !contains(options.IDs, link.ToID) {
+			continue
+		}
+
+		relatedIDs = append(relatedIDs, link.ToID)
+	}
+
+	if len(relatedIDs) < 1 {
+		return []entitystore.Entity{}, nil
+	}
+
+	options.IDs = relatedIDs
+
+	if options.SortBy != "" {
+		return st.EntityList(options)
+	}
+
+	// Keep the link order, paginate after sorting
+	limit := options.Limit
+	offset := options.Offset
+	options.Limit = 0
+	options.Offset = 0
+
+	list, err := st.EntityList(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entitiesByID := map[string]entitystore.Entity{}
+
+	for _, entity := range list {
+		entitiesByID[entity.ID()] = entity
+	}
+
+	related := []entitystore.Entity{}
+	added := map[string]bool{}
+
+	for _, relatedID := range relatedIDs {
+		entity, exists := entitiesByID[relatedID]
+
+		if !exists || added[relatedID] {
+			continue
+		}
+
+		added[relatedID] = true
+		related = append(related, entity)
+	}
+
+	return paginate(related, offset, limit), nil
+}
+
+// linkList lists the links matching the filter, ordered by position
+func (st *Store) linkList(filter func(link entitystore.Link) bool) []entitystore.Link {
+	st.data.mu.RLock()
+
+	links := []entitystore.Link{}
+
+	for _, link := range st.data.links {
+		if filter(link) {
+			links = append(links, link)
+		}
+	}
+
+	st.data.mu.RUnlock()
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Position != links[j].Position {
+			return links[i].Position < links[j].Position
+		}
+
+		return links[i].ID < links[j].ID
+	})
+
+	return links
+}
+
+// entityLinksDelete removes all the links from and to an entity
+func (st *Store) entityLinksDelete(entityID string) {
+	st.linksDelete(func(link entitystore.Link) bool {
+		return link.FromID == entityID || link.ToID == entityID
+	})
+}
+
+// linksDelete removes the links matching the filter, returns how many
+func (st *Store) linksDelete(filter func(link entitystore.Link) bool) int {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	count := 0
+
+	for linkID, link := range st.data.links {
+		if filter(link) {
+			mapDelete(st, st.data.links, linkID)
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,285 @@
+package memstore
+
+import (
+	"cmp"
+	"sort"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// entityQuery lists the entities matching the options, filtered, sorted
+// and paginated like the SQL store. The Search option is ignored,
+// as it is by the SQL store
+func (st *Store) entityQuery(options entitystore.EntityQueryOptions) ([]entitystore.Entity, error) {
+	sortBy := entitystore.COLUMN_ID
+
+	if options.SortBy != "" {
+		sortBy = options.SortBy
+	}
+
+	if _, supported := entityColumnValue(entitystore.Entity{}, sortBy); !supported {
+		return nil, errInvalidArgument("unsupported sort column " + sortBy)
+	}
+
+	ids := stringSet(options.IDs)
+
+	// the values of the SortByAttribute, by entity ID
+	attributeValues := map[string]string{}
+
+	st.data.mu.RLock()
+
+	var entities []entitystore.Entity
+
+	for _, entity := range st.data.entities {
+		if len(ids) > 0 && !ids[entity.ID()] {
+			continue
+		}
+
+		if options.ID != "" && entity.ID() != options.ID {
+			continue
+		}
+
+		if options.EntityType != "" && entity.Type() != options.EntityType {
+			continue
+		}
+
+		if options.EntityHandle != "" && entity.Handle() != options.EntityHandle {
+			continue
+		}
+
+		if st.tenantScoped && entity.TenantID() != st.tenantID {
+			continue
+		}
+
+		if options.ParentID != "" && entity.ParentID() != options.ParentID {
+			continue
+		}
+
+		if len(options.Where) > 0 || options.SortByAttribute != "" {
+			attributes := attributesByKey(st.data.attributes[entity.ID()])
+
+			if !attributesMatch(attributes, options.Where) {
+				continue
+			}
+
+			attribute := attributes[options.SortByAttribute]
+			attributeValues[entity.ID()] = attribute.AttributeValue()
+		}
+
+		entities = append(entities, entity)
+	}
+
+	st.data.mu.RUnlock()
+
+	sortRecords(entities, options.SortOrder, func(entity entitystore.Entity) string {
+		return entity.ID()
+	}, func(entity entitystore.Entity) any {
+		// the missing attributes sort first, like NULL in SQLite and MySQL
+		if options.SortByAttribute != "" {
+			return attributeValues[entity.ID()]
+		}
+
+		value, _ := entityColumnValue(entity, sortBy)
+		return value
+	})
+
+	if !options.CountOnly {
+		entities = paginate(entities, options.Offset, options.Limit)
+	}
+
+	for i := range entities {
+		entities[i] = st.bind(entities[i])
+	}
+
+	return entities, nil
+}
+
+// attributeQuery lists the attributes matching the options,
+// filtered, sorted and paginated like the SQL store
+func (st *Store) attributeQuery(options entitystore.AttributeQueryOptions) ([]entitystore.Attribute, error) {
+	sortBy := entitystore.COLUMN_ID
+
+	if options.SortBy != "" {
+		sortBy = options.SortBy
+	}
+
+	if _, supported := attributeColumnValue(entitystore.Attribute{}, sortBy); !supported {
+		return nil, errInvalidArgument("unsupported sort column " + sortBy)
+	}
+
+	ids := stringSet(options.IDs)
+
+	st.data.mu.RLock()
+
+	var attributes []entitystore.Attribute
+
+	candidates := st.data.attributes
+
+	if options.EntityID != "" {
+		candidates = map[string]map[string]entitystore.Attribute{
+			options.EntityID: st.data.attributes[options.EntityID],
+		}
+	}
+
+	for entityID, entityAttributes := range candidates {
+		entity, exists := st.data.entities[entityID]
+
+		// the type and handle select the entity only when both are given
+		if options.EntityType != "" && options.EntityHandle != "" {
+			if !exists || entity.Type() != options.EntityType || entity.Handle() != options.EntityHandle {
+				continue
+			}
+		}
+
+		if st.tenantScoped && (!exists || entity.TenantID() != st.tenantID) {
+			continue
+		}
+
+		for _, attribute := range entityAttributes {
+			if len(ids) > 0 && !ids[attribute.ID()] {
+				continue
+			}
+
+			if options.ID != "" && attribute.ID() != options.ID {
+				continue
+			}
+
+			if options.AttributeKey != "" && attribute.AttributeKey() != options.AttributeKey {
+				continue
+			}
+
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	st.data.mu.RUnlock()
+
+	sortRecords(attributes, options.SortOrder, func(attribute entitystore.Attribute) string {
+		return attribute.ID()
+	}, func(attribute entitystore.Attribute) any {
+		value, _ := attributeColumnValue(attribute, sortBy)
+		return value
+	})
+
+	if !options.CountOnly {
+		attributes = paginate(attributes, options.Offset, options.Limit)
+	}
+
+	return attributes, nil
+}
+
+// entityColumnValue returns the value of the entity column,
+// false if there is no such column
+func entityColumnValue(entity entitystore.Entity, column string) (any, bool) {
+	switch column {
+	case entitystore.COLUMN_ID:
+		return entity.ID(), true
+	case entitystore.COLUMN_ENTITY_TYPE:
+		return entity.Type(), true
+	case entitystore.COLUMN_ENTITY_HANDLE:
+		return entity.Handle(), true
+	case entitystore.COLUMN_PARENT_ID:
+		return entity.ParentID(), true
+	case entitystore.COLUMN_TENANT_ID:
+		return entity.TenantID(), true
+	case entitystore.COLUMN_VERSION:
+		return entity.Version(), true
+	case entitystore.COLUMN_CREATED_AT:
+		return entity.CreatedAt(), true
+	case entitystore.COLUMN_UPDATED_AT:
+		return entity.UpdatedAt(), true
+	}
+
+	return nil, false
+}
+
+// attributeColumnValue returns the value of the attribute column,
+// false if there is no such column
+func attributeColumnValue(attribute entitystore.Attribute, column string) (any, bool) {
+	switch column {
+	case entitystore.COLUMN_ID:
+		return attribute.ID(), true
+	case entitystore.COLUMN_ENTITY_ID:
+		return attribute.EntityID(), true
+	case entitystore.COLUMN_ATTRIBUTE_KEY:
+		return attribute.AttributeKey(), true
+	case entitystore.COLUMN_ATTRIBUTE_VALUE:
+		return attribute.AttributeValue(), true
+	case entitystore.COLUMN_CREATED_AT:
+		return attribute.CreatedAt(), true
+	case entitystore.COLUMN_UPDATED_AT:
+		return attribute.UpdatedAt(), true
+	}
+
+	return nil, false
+}
+
+// sortRecords sorts the records by the column value, ascending if the
+// order is empty or "asc" and descending otherwise, like the SQL store.
+// The records with equal values are ordered by ID
+func sortRecords[T any](records []T, sortOrder string, id func(T) string, value func(T) any) {
+	sort.Slice(records, func(i, j int) bool {
+		return id(records[i]) < id(records[j])
+	})
+
+	descending := sortOrder != "" && sortOrder != "asc"
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if descending {
+			return compareValues(value(records[j]), value(records[i])) < 0
+		}
+
+		return compareValues(value(records[i]), value(records[j])) < 0
+	})
+}
+
+// compareValues compares two column values of the same type
+func compareValues(a any, b any) int {
+	switch a := a.(type) {
+	case string:
+		return cmp.Compare(a, b.(string))
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+
+	return 0
+}
+
+// paginate skips offset records and keeps limit of them (all if 0)
+func paginate[T any](records []T, offset uint64, limit uint64) []T {
+	if offset >= uint64(len(records)) {
+		return records[:0]
+	}
+
+	records = records[offset:]
+
+	if limit > 0 && limit < uint64(len(records)) {
+		records = records[:limit]
+	}
+
+	return records
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stringSet returns the set of the values
+func stringSet(values []string) map[string]bool {
+	set := map[string]bool{}
+
+	for _, value := range values {
+		set[value] = true
+	}
+
+	return set
+}
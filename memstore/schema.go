@@ -0,0 +1,155 @@
+package memstore
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// attributeKeySampleLength the sample values are cut to this number of characters
+const attributeKeySampleLength = 100
+
+// EntityTypeList lists the entity types in use, sorted by type,
+// with the number of entities and the creation time of the oldest
+// and of the newest. The trashed entities are not counted
+func (st *Store) EntityTypeList() (types []entitystore.EntityTypeInfo, err error) {
+	defer wrapOpError(&err, "EntityTypeList", "")
+
+	entities, err := st.entityQuery(entitystore.EntityQueryOptions{CountOnly: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	typesByName := map[string]*entitystore.EntityTypeInfo{}
+
+	for _, entity := range entities {
+		info := typesByName[entity.Type()]
+
+		if info == nil {
+			info = &entitystore.EntityTypeInfo{
+				EntityType:     entity.Type(),
+				FirstCreatedAt: entity.CreatedAt(),
+				LastCreatedAt:  entity.CreatedAt(),
+			}
+
+			typesByName[entity.Type()] = info
+		}
+
+		info.Count++
+
+		if entity.CreatedAt().Before(info.FirstCreatedAt) {
+			info.FirstCreatedAt = entity.CreatedAt()
+		}
+
+		if entity.CreatedAt().After(info.LastCreatedAt) {
+			info.LastCreatedAt = entity.CreatedAt()
+		}
+	}
+
+	types = []entitystore.EntityTypeInfo{}
+
+	for _, info := range typesByName {
+		types = append(types, *info)
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].EntityType < types[j].EntityType
+	})
+
+	return types, nil
+}
+
+// AttributeKeyList lists the attribute keys used by the entities
+// of a type (all the types if empty), sorted by key
+func (st *Store) AttributeKeyList(entityType string) (keys []entitystore.AttributeKeyInfo, err error) {
+	defer wrapOpError(&err, "AttributeKeyList", "")
+
+	entities, err := st.entityQuery(entitystore.EntityQueryOptions{EntityType: entityType, CountOnly: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []entitystore.Attribute
+
+	st.data.mu.RLock()
+
+	for _, entity := range entities {
+		for _, attribute := range st.data.attributes[entity.ID()] {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	st.data.mu.RUnlock()
+
+	// the oldest attribute first, like the SQL store
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].ID() < attributes[j].ID()
+	})
+
+	keysByName := map[string]*entitystore.AttributeKeyInfo{}
+
+	for _, attribute := range attributes {
+		info := keysByName[attribute.AttributeKey()]
+
+		if info == nil {
+			info = &entitystore.AttributeKeyInfo{AttributeKey: attribute.AttributeKey()}
+			keysByName[attribute.AttributeKey()] = info
+		}
+
+		attributeKeyAdd(info, attribute.AttributeValue())
+	}
+
+	keys = []entitystore.AttributeKeyInfo{}
+
+	for _, info := range keysByName {
+		if info.ValueType == "" {
+			info.ValueType = entitystore.VALUE_TYPE_STRING
+		}
+
+		keys = append(keys, *info)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].AttributeKey < keys[j].AttributeKey
+	})
+
+	return keys, nil
+}
+
+// attributeKeyAdd accounts for one more value of the attribute key,
+// like the SQL store. The first value added is kept as the sample
+func attributeKeyAdd(info *entitystore.AttributeKeyInfo, value string) {
+	if info.Count == 0 {
+		info.SampleValue = value
+
+		if utf8.RuneCountInString(value) > attributeKeySampleLength {
+			info.SampleValue = string([]rune(value)[:attributeKeySampleLength])
+		}
+	}
+
+	info.Count++
+
+	if length := int64(utf8.RuneCountInString(value)); length > info.MaxLength {
+		info.MaxLength = length
+	}
+
+	// the empty values fit any type
+	if value == "" {
+		return
+	}
+
+	valueType := entitystore.InferValueType(value)
+
+	switch {
+	case info.ValueType == "" || info.ValueType == valueType:
+		info.ValueType = valueType
+	case info.ValueType == entitystore.VALUE_TYPE_INT && valueType == entitystore.VALUE_TYPE_FLOAT:
+		info.ValueType = entitystore.VALUE_TYPE_FLOAT
+	case info.ValueType == entitystore.VALUE_TYPE_FLOAT && valueType == entitystore.VALUE_TYPE_INT:
+	default:
+		info.ValueType = entitystore.VALUE_TYPE_STRING
+	}
+}
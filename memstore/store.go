@@ -0,0 +1,286 @@
+// Package memstore is an in-memory implementation of entitystore.StoreInterface,
+// meant to replace the SQL store in the tests of the code depending on it.
+//
+// The store is safe for concurrent use. Every operation is atomic, if a hook
+// fails the changes of its operation are rolled back, but the operations running
+// concurrently are not isolated from each other. The entity query options filter,
+// sort and paginate like the SQL store. The links, the hierarchy and the tenant
+// views are always available, the attribute history and the outbox are optional.
+// The attribute values are kept as they are, without encryption, compression
+// or blob offloading, and there is no cache. There are no type views nor
+// projection tables, their methods return an error.
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/entitystore"
+)
+
+// NewStoreOptions define the options for creating a new in-memory store
+type NewStoreOptions struct {
+	// AttributeHistoryEnabled records the changes of the attribute values,
+	// enabling the history methods
+	AttributeHistoryEnabled bool
+	// OutboxEnabled records the changes in the outbox, see ChangesSince
+	OutboxEnabled bool
+	// HierarchyTrashCascade trashes and restores the descendants of an entity together with it
+	HierarchyTrashCascade bool
+	// SubscriptionBufferSize the buffer size of the Subscribe channels, defaults to 100
+	SubscriptionBufferSize int
+	// SubscriptionPolicy what to do when a subscriber buffer is full,
+	// entitystore.SUBSCRIPTION_POLICY_DROP (default) or entitystore.SUBSCRIPTION_POLICY_BLOCK
+	SubscriptionPolicy string
+}
+
+// Store implements entitystore.StoreInterface in memory
+type Store struct {
+	// data is shared by all views of the store
+	data *data
+
+	// tenantScoped and tenantID scope all operations to a single tenant, see ForTenant
+	tenantScoped bool
+	tenantID     string
+
+	// actor is recorded as the author of changes, see WithActor
+	actor string
+
+	// ctx is passed to the hooks, see WithContext
+	ctx context.Context
+
+	// tx is the operation in progress, see inTransaction
+	tx *transaction
+}
+
+var _ entitystore.StoreInterface = (*Store)(nil)
+
+// data holds the records, the hooks and the subscribers of a store
+type data struct {
+	options NewStoreOptions
+
+	// mu guards the records below
+	mu         sync.RWMutex
+	entities   map[string]entitystore.Entity
+	attributes map[string]map[string]entitystore.Attribute // by entity ID, then by attribute ID
+	// attributeEntityIDs the entity ID of each attribute, by attribute ID
+	attributeEntityIDs map[string]string
+	trash              map[string]trashEntry
+	history            map[string][]entitystore.AttributeHistoryEntry // by entity ID
+	outbox             []entitystore.Change
+	links              map[string]entitystore.Link
+
+	// sequence numbers the history entries and the changes, in order
+	sequence atomic.Int64
+
+	hooksMu        sync.RWMutex
+	entityHooks    map[string][]entitystore.EntityHook
+	attributeHooks map[string][]entitystore.AttributeHook
+
+	subscribersMu sync.RWMutex
+	subscribers   map[*subscription]bool
+}
+
+// trashEntry is an entity in the trash bin, with its attributes
+type trashEntry struct {
+	entity     entitystore.Entity
+	attributes []entitystore.Attribute
+	deletedAt  time.Time
+	deletedBy  string
+}
+
+// NewStore creates a new, empty, in-memory entity store
+func NewStore(opts NewStoreOptions) (*Store, error) {
+	if opts.SubscriptionPolicy != "" && opts.SubscriptionPolicy != entitystore.SUBSCRIPTION_POLICY_DROP && opts.SubscriptionPolicy != entitystore.SUBSCRIPTION_POLICY_BLOCK {
+		return nil, errors.New("entity store: unsupported subscription policy " + opts.SubscriptionPolicy)
+	}
+
+	if opts.SubscriptionBufferSize < 1 {
+		opts.SubscriptionBufferSize = subscriptionBufferSizeDefault
+	}
+
+	if opts.SubscriptionPolicy == "" {
+		opts.SubscriptionPolicy = entitystore.SUBSCRIPTION_POLICY_DROP
+	}
+
+	return &Store{
+		data: &data{
+			options:            opts,
+			entities:           map[string]entitystore.Entity{},
+			attributes:         map[string]map[string]entitystore.Attribute{},
+			attributeEntityIDs: map[string]string{},
+			trash:              map[string]trashEntry{},
+			history:            map[string][]entitystore.AttributeHistoryEntry{},
+			links:              map[string]entitystore.Link{},
+			entityHooks:        map[string][]entitystore.EntityHook{},
+			attributeHooks:     map[string][]entitystore.AttributeHook{},
+			subscribers:        map[*subscription]bool{},
+		},
+	}, nil
+}
+
+// AutoMigrate does nothing, there are no tables to create
+func (st *Store) AutoMigrate() error {
+	return nil
+}
+
+// The in-memory store has no tables and no database
+func (st *Store) GetAttributeHistoryTableName() string { return "" }
+func (st *Store) GetAttributeTableName() string        { return "" }
+func (st *Store) GetAttributeTrashTableName() string   { return "" }
+func (st *Store) GetDB() *sql.DB                       { return nil }
+func (st *Store) GetEntityTableName() string           { return "" }
+func (st *Store) GetEntityTrashTableName() string      { return "" }
+func (st *Store) GetLinkTableName() string             { return "" }
+func (st *Store) GetOutboxTableName() string           { return "" }
+
+// CacheStats returns zero stats, the in-memory store has no cache
+func (st *Store) CacheStats() entitystore.CacheStats {
+	return entitystore.CacheStats{}
+}
+
+// ReencryptAll fails, the in-memory store does not encrypt the attribute values
+func (st *Store) ReencryptAll() (count int64, err error) {
+	defer wrapOpError(&err, "ReencryptAll", "")
+
+	return 0, errNotEnabled("attribute encryption")
+}
+
+// CreateTypeView fails, the in-memory store has no database views
+func (st *Store) CreateTypeView(entityType string, attributeKeys []string, viewName string) (err error) {
+	defer wrapOpError(&err, "CreateTypeView", "")
+
+	return errors.New("type views are not supported by the in-memory store")
+}
+
+// RebuildProjection fails, the in-memory store has no projection tables
+func (st *Store) RebuildProjection(entityType string) (count int64, err error) {
+	defer wrapOpError(&err, "RebuildProjection", "")
+
+	return 0, errors.New("projections are not supported by the in-memory store")
+}
+
+// RefreshTypeViews fails, the in-memory store has no database views
+func (st *Store) RefreshTypeViews() (err error) {
+	defer wrapOpError(&err, "RefreshTypeViews", "")
+
+	return errors.New("type views are not supported by the in-memory store")
+}
+
+// ForTenant returns a view of the store scoped to a single tenant.
+// Entities created through the view belong to the tenant, while the
+// entities of other tenants (and their attributes, history and links)
+// are invisible to it
+func (st *Store) ForTenant(tenantID string) entitystore.StoreInterface {
+	view := *st
+	view.tenantScoped = true
+	view.tenantID = tenantID
+	return &view
+}
+
+// WithActor returns a view of the store which records
+// the given actor (i.e. user ID) as the author of changes
+func (st *Store) WithActor(actor string) entitystore.StoreInterface {
+	view := *st
+	view.actor = actor
+	return &view
+}
+
+// WithContext returns a view of the store which passes
+// the given context to the registered hooks
+func (st *Store) WithContext(ctx context.Context) entitystore.StoreInterface {
+	view := *st
+	view.ctx = ctx
+	return &view
+}
+
+// context returns the context of the store view
+func (st *Store) context() context.Context {
+	if st.ctx == nil {
+		return context.Background()
+	}
+
+	return st.ctx
+}
+
+func (st *Store) NewEntity(opts entitystore.NewEntityOptions) entitystore.Entity {
+	entity := entitystore.Entity{}
+	entity.SetID(opts.ID)
+	entity.SetType(opts.Type)
+	entity.SetHandle(opts.Handle)
+	entity.SetParentID(opts.ParentID)
+	entity.SetTenantID(opts.TenantID)
+	entity.SetVersion(opts.Version)
+	entity.SetCreatedAt(opts.CreatedAt)
+	entity.SetUpdatedAt(opts.UpdatedAt)
+	return st.bind(entity)
+}
+
+func (st *Store) NewEntityFromMap(entityMap map[string]string) entitystore.Entity {
+	opts := entitystore.NewEntityOptions{
+		ID:       entityMap[entitystore.COLUMN_ID],
+		Type:     entityMap[entitystore.COLUMN_ENTITY_TYPE],
+		Handle:   entityMap[entitystore.COLUMN_ENTITY_HANDLE],
+		ParentID: entityMap[entitystore.COLUMN_PARENT_ID],
+		TenantID: entityMap[entitystore.COLUMN_TENANT_ID],
+	}
+
+	if version, exists := entityMap[entitystore.COLUMN_VERSION]; exists {
+		opts.Version, _ = strconv.ParseInt(version, 10, 64)
+	}
+
+	if createdAt, exists := entityMap[entitystore.COLUMN_CREATED_AT]; exists {
+		opts.CreatedAt = carbon.Parse(createdAt, carbon.UTC).StdTime()
+	}
+
+	if updatedAt, exists := entityMap[entitystore.COLUMN_UPDATED_AT]; exists {
+		opts.UpdatedAt = carbon.Parse(updatedAt, carbon.UTC).StdTime()
+	}
+
+	return st.NewEntity(opts)
+}
+
+func (st *Store) NewAttribute(opts entitystore.NewAttributeOptions) entitystore.Attribute {
+	attribute := entitystore.Attribute{}
+	attribute.SetID(opts.ID)
+	attribute.SetEntityID(opts.EntityID)
+	attribute.SetAttributeKey(opts.AttributeKey)
+	attribute.SetAttributeValue(opts.AttributeValue)
+	attribute.SetCreatedAt(opts.CreatedAt)
+	attribute.SetUpdatedAt(opts.UpdatedAt)
+	return attribute
+}
+
+func (st *Store) NewAttributeFromMap(attributeMap map[string]string) entitystore.Attribute {
+	opts := entitystore.NewAttributeOptions{
+		ID:             attributeMap[entitystore.COLUMN_ID],
+		EntityID:       attributeMap[entitystore.COLUMN_ENTITY_ID],
+		AttributeKey:   attributeMap[entitystore.COLUMN_ATTRIBUTE_KEY],
+		AttributeValue: attributeMap[entitystore.COLUMN_ATTRIBUTE_VALUE],
+	}
+
+	if createdAt, exists := attributeMap[entitystore.COLUMN_CREATED_AT]; exists {
+		opts.CreatedAt = carbon.Parse(createdAt, carbon.UTC).StdTime()
+	}
+
+	if updatedAt, exists := attributeMap[entitystore.COLUMN_UPDATED_AT]; exists {
+		opts.UpdatedAt = carbon.Parse(updatedAt, carbon.UTC).StdTime()
+	}
+
+	return st.NewAttribute(opts)
+}
+
+// bind binds the entity to the store view, so its attribute helpers work.
+// The entities outlive the operation in progress, they are not bound to it
+func (st *Store) bind(entity entitystore.Entity) entitystore.Entity {
+	view := *st
+	view.tx = nil
+	entity.SetStore(&view)
+	return entity
+}
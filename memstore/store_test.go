@@ -0,0 +1,297 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gouniverse/entitystore"
+)
+
+func initStore(t *testing.T, opts NewStoreOptions) *Store {
+	store, err := NewStore(opts)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	return store
+}
+
+func TestStoreEntityQuery(t *testing.T) {
+	store := initStore(t, NewStoreOptions{})
+
+	for _, title := range []string{"Charlie", "Alpha", "Bravo"} {
+		_, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": title})
+
+		if err != nil {
+			t.Fatal("Must be NIL:", err.Error())
+		}
+	}
+
+	if _, err := store.EntityCreateWithType("page"); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{EntityType: "post", Offset: 2, Limit: 1})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 3 {
+		t.Fatal("Count must be 3, found:", count)
+	}
+
+	posts, err := store.EntityList(entitystore.EntityQueryOptions{
+		EntityType: "post",
+		SortBy:     entitystore.COLUMN_CREATED_AT,
+		SortOrder:  "desc",
+		Offset:     1,
+		Limit:      1,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(posts) != 1 {
+		t.Fatal("Must find 1 post, found:", len(posts))
+	}
+
+	// the entities are bound to the store
+	title, err := posts[0].GetString("title", "")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if title != "Alpha" {
+		t.Fatal("Title must be Alpha, found:", title)
+	}
+
+	_, err = store.EntityList(entitystore.EntityQueryOptions{SortBy: "unknown"})
+
+	if !errors.Is(err, entitystore.ErrInvalidArgument) {
+		t.Fatal("Must be ErrInvalidArgument, found:", err)
+	}
+
+	page, err := store.EntityFindByID("missing")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if page != nil {
+		t.Fatal("Entity must be NIL")
+	}
+}
+
+func TestStoreEntityTrash(t *testing.T) {
+	store := initStore(t, NewStoreOptions{HierarchyTrashCascade: true})
+
+	parent, err := store.EntityCreateWithTypeAndAttributes("folder", map[string]string{"name": "Docs"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	child, err := store.EntityCreateWithType("file")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := store.EntitySetParent(child.ID(), parent.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, err := store.EntityTrash(parent.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 0 {
+		t.Fatal("The entities must be trashed, found:", count)
+	}
+
+	if _, err := store.EntityTrash(parent.ID()); !errors.Is(err, entitystore.ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound, found:", err)
+	}
+
+	if _, err := store.EntityRestore(parent.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	children, err := store.EntityChildren(parent.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(children) != 1 {
+		t.Fatal("The child must be restored, found:", len(children))
+	}
+
+	name, err := store.AttributeFind(parent.ID(), "name")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if name == nil || name.AttributeValue() != "Docs" {
+		t.Fatal("The attributes must be restored")
+	}
+}
+
+func TestStoreHookRollback(t *testing.T) {
+	store := initStore(t, NewStoreOptions{AttributeHistoryEnabled: true, OutboxEnabled: true})
+
+	store.AfterAttributeSet(func(ctx context.Context, attribute *entitystore.Attribute) error {
+		if attribute.AttributeValue() == "invalid" {
+			return errors.New("invalid value")
+		}
+
+		return nil
+	})
+
+	entity, err := store.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Valid"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	err = store.AttributeSetString(entity.ID(), "title", "invalid")
+
+	if err == nil {
+		t.Fatal("Must return the hook error")
+	}
+
+	attributes, err := store.EntityAttributeList(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(attributes) != 1 || attributes[0].AttributeValue() != "Valid" {
+		t.Fatal("The attributes must be rolled back, found:", len(attributes))
+	}
+
+	history, err := store.EntityHistory(entity.ID(), entitystore.EntityHistoryOptions{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(history) != 1 {
+		t.Fatal("The history must be rolled back, found:", len(history))
+	}
+
+	changes, err := store.ChangesSince(0, 0)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if len(changes) != 2 {
+		t.Fatal("The outbox must be rolled back, found:", len(changes))
+	}
+}
+
+func TestStoreForTenant(t *testing.T) {
+	store := initStore(t, NewStoreOptions{})
+	acme := store.ForTenant("acme")
+	globex := store.ForTenant("globex")
+
+	entity, err := acme.EntityCreateWithType("post")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if entity.TenantID() != "acme" {
+		t.Fatal("Tenant must be acme, found:", entity.TenantID())
+	}
+
+	found, err := globex.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if found != nil {
+		t.Fatal("The entity of another tenant must not be found")
+	}
+
+	if err := globex.AttributeSetString(entity.ID(), "title", "Hello"); !errors.Is(err, entitystore.ErrEntityNotFound) {
+		t.Fatal("Must be ErrEntityNotFound, found:", err)
+	}
+
+	count, err := store.EntityCount(entitystore.EntityQueryOptions{})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if count != 1 {
+		t.Fatal("The unscoped store must see all the entities, found:", count)
+	}
+}
+
+func TestStoreExportImport(t *testing.T) {
+	source := initStore(t, NewStoreOptions{})
+
+	kept, err := source.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Kept"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	trashed, err := source.EntityCreateWithTypeAndAttributes("post", map[string]string{"title": "Trashed"})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if _, err := source.EntityTrash(trashed.ID()); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	buffer := bytes.Buffer{}
+
+	if err := source.Export(context.Background(), &buffer, entitystore.ExportOptions{IncludeTrash: true}); err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	target := initStore(t, NewStoreOptions{})
+
+	result, err := target.Import(context.Background(), &buffer, entitystore.ImportOptions{PreserveIDs: true})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if result.Created != 2 {
+		t.Fatal("Must create 2 entities, found:", result.Created)
+	}
+
+	title, err := target.AttributeFind(kept.ID(), "title")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if title == nil || title.AttributeValue() != "Kept" {
+		t.Fatal("The attributes must be imported")
+	}
+
+	if _, err := target.EntityRestore(trashed.ID()); err != nil {
+		t.Fatal("The trashed entity must be imported to the trash bin:", err.Error())
+	}
+}
@@ -0,0 +1,115 @@
+package memstore
+
+import (
+	"fmt"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// transaction collects the undo steps and the change events of an operation
+type transaction struct {
+	undo   []func()
+	events []entitystore.ChangeEvent
+}
+
+// inTransaction runs fn with a view of the store recording the changes of
+// the operation. If an operation is already in progress fn joins it.
+// If fn fails its changes are undone, otherwise the change events
+// are published to the subscribers
+func (st *Store) inTransaction(fn func(tx *Store) error) (err error) {
+	if st.tx != nil {
+		return fn(st)
+	}
+
+	tx := *st
+	tx.tx = &transaction{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(&tx); err != nil {
+		tx.rollback()
+		return err
+	}
+
+	st.changeEventsPublish(tx.tx.events)
+
+	return nil
+}
+
+// Transaction runs fn as a single operation. The changes made through
+// the store passed to fn are undone if fn returns an error or panics
+func (st *Store) Transaction(fn func(tx entitystore.StoreInterface) error) (err error) {
+	defer wrapOpError(&err, "Transaction", "")
+
+	if fn == nil {
+		return errInvalidArgument("transaction function cannot be nil")
+	}
+
+	return st.inTransaction(func(tx *Store) error {
+		return fn(tx)
+	})
+}
+
+// rollback undoes the changes of the operation, newest first
+func (st *Store) rollback() {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	for i := len(st.tx.undo) - 1; i >= 0; i-- {
+		st.tx.undo[i]()
+	}
+
+	st.tx.undo = nil
+	st.tx.events = nil
+}
+
+// mapSet sets the value of the key, recording how to undo it.
+// The caller holds the write lock
+func mapSet[V any](st *Store, records map[string]V, key string, value V) {
+	old, existed := records[key]
+	records[key] = value
+
+	st.tx.undo = append(st.tx.undo, func() {
+		if existed {
+			records[key] = old
+		} else {
+			delete(records, key)
+		}
+	})
+}
+
+// mapDelete deletes the key, recording how to undo it.
+// The caller holds the write lock
+func mapDelete[V any](st *Store, records map[string]V, key string) {
+	old, existed := records[key]
+
+	if !existed {
+		return
+	}
+
+	delete(records, key)
+
+	st.tx.undo = append(st.tx.undo, func() {
+		records[key] = old
+	})
+}
+
+// nextSequence returns a number greater than all the previous ones
+func (st *Store) nextSequence() int64 {
+	return st.data.sequence.Add(1)
+}
+
+// nextSequenceID returns an ID greater than all the previous ones
+func (st *Store) nextSequenceID() string {
+	return sequenceID(st.nextSequence())
+}
+
+// sequenceID formats a sequence number as an ID, sorting as the numbers do
+func sequenceID(sequence int64) string {
+	return fmt.Sprintf("%020d", sequence)
+}
@@ -0,0 +1,304 @@
+package memstore
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gouniverse/entitystore"
+)
+
+// EntityTrash moves an entity and all attributes to the trash bin.
+// With HierarchyTrashCascade the descendants are moved too.
+// Returns ErrEntityNotFound if the entity does not exist
+func (st *Store) EntityTrash(entityID string) (isTrashed bool, err error) {
+	defer wrapOpError(&err, "EntityTrash", entityID)
+
+	if entityID == "" {
+		return false, errInvalidArgument("entity id cannot be empty")
+	}
+
+	err = st.inTransaction(func(tx *Store) error {
+		descendantIDs := []string{}
+
+		if tx.data.options.HierarchyTrashCascade {
+			descendantIDs = tx.entityTreeIDs(entityID, false, 0)
+		}
+
+		// the same deletion time marks the entities trashed together
+		deletedAt := time.Now()
+
+		isTrashed, err := tx.entityTrash(entityID, deletedAt)
+
+		if err != nil {
+			return err
+		}
+
+		if !isTrashed {
+			return errEntityNotFound(entityID)
+		}
+
+		for _, descendantID := range descendantIDs {
+			if _, err := tx.entityTrash(descendantID, deletedAt); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// entityTrash moves a single entity and its attributes to the trash bin
+func (st *Store) entityTrash(entityID string, deletedAt time.Time) (isTrashed bool, err error) {
+	err = st.inTransaction(func(tx *Store) error {
+		ent, err := tx.EntityFindByID(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		if ent == nil {
+			return nil
+		}
+
+		if err := tx.entityHooksRun(hookBeforeEntityTrash, ent); err != nil {
+			return err
+		}
+
+		attrs, err := tx.EntityAttributeList(entityID)
+
+		if err != nil {
+			return err
+		}
+
+		if err := tx.trashInsert(*ent, attrs, deletedAt); err != nil {
+			return err
+		}
+
+		tx.entityRemove(entityID)
+		tx.entityLinksDelete(entityID)
+
+		attributeKeys := []string{}
+
+		for _, attr := range attrs {
+			attributeKeys = append(attributeKeys, attr.AttributeKey())
+		}
+
+		if err := tx.changeRecordEntity(entitystore.CHANGE_ENTITY_TRASH, ent, attributeKeys); err != nil {
+			return err
+		}
+
+		if err := tx.entityHooksRun(hookAfterEntityTrash, ent); err != nil {
+			return err
+		}
+
+		isTrashed = true
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return isTrashed, nil
+}
+
+// EntityRestore moves an entity and all attributes back from the trash bin.
+// With HierarchyTrashCascade the descendants trashed together with the
+// entity are restored too. Returns ErrEntityNotFound if the entity is not in the trash bin
+func (st *Store) EntityRestore(entityID string) (isRestored bool, err error) {
+	defer wrapOpError(&err, "EntityRestore", entityID)
+
+	if entityID == "" {
+		return false, errInvalidArgument("entity id cannot be empty")
+	}
+
+	err = st.inTransaction(func(tx *Store) error {
+		entry, exists := tx.trashFind(entityID)
+
+		if !exists {
+			return errEntityNotFound(entityID)
+		}
+
+		if err := tx.entityRestore(entry); err != nil {
+			return err
+		}
+
+		if !tx.data.options.HierarchyTrashCascade {
+			return nil
+		}
+
+		parentIDs := []string{entityID}
+
+		for len(parentIDs) > 0 {
+			children := tx.trashList(func(child trashEntry) bool {
+				return contains(parentIDs, child.entity.ParentID())
+			})
+
+			parentIDs = []string{}
+
+			for _, child := range children {
+				// children trashed on their own stay in the trash bin
+				if !child.deletedAt.Equal(entry.deletedAt) {
+					continue
+				}
+
+				if err := tx.entityRestore(child); err != nil {
+					return err
+				}
+
+				parentIDs = append(parentIDs, child.entity.ID())
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// entityRestore moves a single trashed entity and its attributes back
+func (st *Store) entityRestore(entry trashEntry) error {
+	entity := entry.entity
+
+	err := func() error {
+		st.data.mu.Lock()
+		defer st.data.mu.Unlock()
+
+		if _, exists := st.data.entities[entity.ID()]; exists {
+			return fmt.Errorf("%w: entity %s already exists", entitystore.ErrDuplicate, entity.ID())
+		}
+
+		for _, attr := range entry.attributes {
+			if _, exists := st.data.attributeEntityIDs[attr.ID()]; exists {
+				return fmt.Errorf("%w: attribute %s already exists", entitystore.ErrDuplicate, attr.ID())
+			}
+		}
+
+		mapSet(st, st.data.entities, entity.ID(), entity)
+
+		for _, attr := range entry.attributes {
+			st.attributeSet(attr)
+		}
+
+		mapDelete(st, st.data.trash, entity.ID())
+
+		return nil
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	return st.changeRecordEntity(entitystore.CHANGE_ENTITY_RESTORE, &entity, nil)
+}
+
+// trashInsert adds the entity with its attributes to the trash bin
+func (st *Store) trashInsert(entity entitystore.Entity, attributes []entitystore.Attribute, deletedAt time.Time) error {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	if _, exists := st.data.trash[entity.ID()]; exists {
+		return fmt.Errorf("%w: entity %s is already in the trash bin", entitystore.ErrDuplicate, entity.ID())
+	}
+
+	entity.SetStore(nil)
+
+	mapSet(st, st.data.trash, entity.ID(), trashEntry{
+		entity:     entity,
+		attributes: attributes,
+		deletedAt:  deletedAt,
+		deletedBy:  st.actor,
+	})
+
+	return nil
+}
+
+// trashFind finds an entity in the trash bin
+func (st *Store) trashFind(entityID string) (trashEntry, bool) {
+	st.data.mu.RLock()
+	defer st.data.mu.RUnlock()
+
+	entry, exists := st.data.trash[entityID]
+
+	if !exists || (st.tenantScoped && entry.entity.TenantID() != st.tenantID) {
+		return trashEntry{}, false
+	}
+
+	return entry, true
+}
+
+// trashList lists the entities in the trash bin matching the filter, sorted by ID
+func (st *Store) trashList(filter func(entry trashEntry) bool) []trashEntry {
+	st.data.mu.RLock()
+	defer st.data.mu.RUnlock()
+
+	entries := []trashEntry{}
+
+	for _, entry := range st.data.trash {
+		if st.tenantScoped && entry.entity.TenantID() != st.tenantID {
+			continue
+		}
+
+		if filter(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].entity.ID() < entries[j].entity.ID()
+	})
+
+	return entries
+}
+
+// trashPurge removes a trashed entity and its attributes
+// from the trash bin for good
+func (st *Store) trashPurge(entityID string) {
+	st.data.mu.Lock()
+	defer st.data.mu.Unlock()
+
+	mapDelete(st, st.data.trash, entityID)
+}
+
+// entityFindIncludingTrash finds an entity by ID, looking in the trash bin
+// if it is not found in the entities, returns true if it is trashed
+func (st *Store) entityFindIncludingTrash(entityID string) (*entitystore.Entity, bool) {
+	entity, _ := st.EntityFindByID(entityID)
+
+	if entity != nil {
+		return entity, false
+	}
+
+	entry, exists := st.trashFind(entityID)
+
+	if !exists {
+		return nil, false
+	}
+
+	trashed := st.bind(entry.entity)
+
+	return &trashed, true
+}
+
+// entityAttributeListIncludingTrash lists the attributes of an entity,
+// from the trash bin if the entity is trashed
+func (st *Store) entityAttributeListIncludingTrash(entityID string, isTrashed bool) ([]entitystore.Attribute, error) {
+	if !isTrashed {
+		return st.EntityAttributeList(entityID)
+	}
+
+	entry, _ := st.trashFind(entityID)
+
+	return entry.attributes, nil
+}
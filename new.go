@@ -3,6 +3,9 @@ package entitystore
 import (
 	"database/sql"
 	"errors"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/gouniverse/sb"
 )
@@ -38,11 +41,65 @@ type NewStoreOptions struct {
 	AttributeTableName      string
 	EntityTrashTableName    string
 	AttributeTrashTableName string
-	DB                      *sql.DB
-	Database                sb.DatabaseInterface
-	DbDriverName            string
-	AutomigrateEnabled      bool
-	DebugEnabled            bool
+	// AttributeHistoryTableName optional, enables the attribute change history
+	AttributeHistoryTableName string
+	// OutboxTableName optional, enables the transactional outbox (change feed)
+	OutboxTableName string
+	// LinkTableName optional, enables the entity links (relationships)
+	LinkTableName      string
+	DB                 *sql.DB
+	Database           sb.DatabaseInterface
+	DbDriverName       string
+	AutomigrateEnabled bool
+	DebugEnabled       bool
+
+	// Logger optional, receives the queries at debug level, the slow ones
+	// at warn level and the failed ones at error level. With DebugEnabled
+	// and no Logger the queries are logged to the default logger
+	Logger *slog.Logger
+	// QueryObserver optional, notified of every query
+	QueryObserver QueryObserver
+	// SlowQueryThreshold optional, the queries taking longer are reported as slow
+	SlowQueryThreshold time.Duration
+
+	// HierarchyEnabled adds a parent_id column to the entity tables,
+	// enabling the parent / child methods
+	HierarchyEnabled bool
+	// HierarchyTrashCascade trashes and restores the descendants of an entity together with it
+	HierarchyTrashCascade bool
+
+	// TenancyEnabled adds a tenant_id column to the entity tables,
+	// enabling the tenant scoped views, see ForTenant
+	TenancyEnabled bool
+
+	// AttributeCipher optional, encrypts the values of the attribute keys
+	// listed in EncryptedAttributeKeys or matched by EncryptAttributeKey
+	AttributeCipher        AttributeCipher
+	EncryptedAttributeKeys []string
+	EncryptAttributeKey    func(attributeKey string) bool
+
+	// ValueCompression optional, compresses the attribute values longer
+	// than ValueCompressionThreshold (defaults to 1024 bytes),
+	// VALUE_COMPRESSION_GZIP or VALUE_COMPRESSION_ZSTD
+	ValueCompression          string
+	ValueCompressionThreshold int
+
+	// BlobStore optional, offloads the attribute values longer than
+	// BlobThreshold (defaults to 32KB) keeping a reference in their place
+	BlobStore     BlobStore
+	BlobThreshold int
+
+	// Cache optional, caches the entities and attributes found by ID and key
+	Cache Cache
+
+	// SubscriptionBufferSize the buffer size of the Subscribe channels, defaults to 100
+	SubscriptionBufferSize int
+	// SubscriptionPolicy what to do when a subscriber buffer is full,
+	// SUBSCRIPTION_POLICY_DROP (default) or SUBSCRIPTION_POLICY_BLOCK
+	SubscriptionPolicy string
+
+	// Projections optional, the projection tables kept in sync, see Projection
+	Projections []Projection
 }
 
 func NewStore(opts NewStoreOptions) (StoreInterface, error) {
@@ -64,15 +121,39 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 	}
 
 	store := &storeImplementation{
-		entityTableName:         opts.EntityTableName,
-		attributeTableName:      opts.AttributeTableName,
-		entityTrashTableName:    opts.EntityTrashTableName,
-		attributeTrashTableName: opts.AttributeTrashTableName,
-		automigrateEnabled:      opts.AutomigrateEnabled,
-		db:                      opts.DB,
-		database:                opts.Database,
-		dbDriverName:            opts.DbDriverName,
-		debugEnabled:            opts.DebugEnabled,
+		entityTableName:           opts.EntityTableName,
+		attributeTableName:        opts.AttributeTableName,
+		entityTrashTableName:      opts.EntityTrashTableName,
+		attributeTrashTableName:   opts.AttributeTrashTableName,
+		attributeHistoryTableName: opts.AttributeHistoryTableName,
+		outboxTableName:           opts.OutboxTableName,
+		linkTableName:             opts.LinkTableName,
+		automigrateEnabled:        opts.AutomigrateEnabled,
+		db:                        opts.DB,
+		database:                  opts.Database,
+		dbDriverName:              opts.DbDriverName,
+		debugEnabled:              opts.DebugEnabled,
+		logger:                    opts.Logger,
+		queryObserver:             opts.QueryObserver,
+		slowQueryThreshold:        opts.SlowQueryThreshold,
+		hierarchyEnabled:          opts.HierarchyEnabled,
+		hierarchyTrashCascade:     opts.HierarchyTrashCascade,
+		tenancyEnabled:            opts.TenancyEnabled,
+		attributeCipher:           opts.AttributeCipher,
+		encryptedAttributeKeys:    opts.EncryptedAttributeKeys,
+		encryptAttributeKey:       opts.EncryptAttributeKey,
+		valueCompression:          opts.ValueCompression,
+		valueCompressionThreshold: opts.ValueCompressionThreshold,
+		blobStore:                 opts.BlobStore,
+		blobThreshold:             opts.BlobThreshold,
+		hooks:                     newStoreHooks(),
+		subscriptions:             newStoreSubscriptions(opts.SubscriptionBufferSize, opts.SubscriptionPolicy),
+		projections:               opts.Projections,
+		projectionsBuilt:          &sync.Map{},
+	}
+
+	if opts.Cache != nil {
+		store.cache = &storeCache{cache: opts.Cache}
 	}
 
 	if store.entityTableName == "" {
@@ -91,6 +172,30 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 		store.attributeTrashTableName = store.attributeTableName + "_trash"
 	}
 
+	if opts.SubscriptionPolicy != "" && opts.SubscriptionPolicy != SUBSCRIPTION_POLICY_DROP && opts.SubscriptionPolicy != SUBSCRIPTION_POLICY_BLOCK {
+		return nil, errors.New("entity store: unsupported subscription policy " + opts.SubscriptionPolicy)
+	}
+
+	if opts.AttributeCipher != nil && len(opts.EncryptedAttributeKeys) < 1 && opts.EncryptAttributeKey == nil {
+		return nil, errors.New("entity store: EncryptedAttributeKeys or EncryptAttributeKey is required with AttributeCipher")
+	}
+
+	if opts.ValueCompression != "" && opts.ValueCompression != VALUE_COMPRESSION_GZIP && opts.ValueCompression != VALUE_COMPRESSION_ZSTD {
+		return nil, errors.New("entity store: unsupported value compression " + opts.ValueCompression)
+	}
+
+	if err := store.projectionValidate(opts.Projections); err != nil {
+		return nil, err
+	}
+
+	if store.valueCompressionThreshold < 1 {
+		store.valueCompressionThreshold = valueCompressionThresholdDefault
+	}
+
+	if store.blobThreshold < 1 {
+		store.blobThreshold = blobThresholdDefault
+	}
+
 	if store.automigrateEnabled {
 		err := store.AutoMigrate()
 
@@ -99,5 +204,9 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 		}
 	}
 
+	if err := store.projectionsAdopt(); err != nil {
+		return nil, err
+	}
+
 	return store, nil
 }
@@ -0,0 +1,59 @@
+package entitystore
+
+import (
+	"context"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+
+	"github.com/georgysavva/scany/sqlscan"
+	"github.com/gouniverse/maputils"
+)
+
+// selectToMapString selects rows as string maps. Unlike the database
+// helper, it reads through the transaction in progress (if any),
+// so changes made earlier in the same transaction are visible
+func (st *storeImplementation) selectToMapString(operation string, sqlStr string) (rows []map[string]string, err error) {
+	start := time.Now()
+
+	defer func() {
+		st.queryObserve(operation, sqlStr, nil, start, int64(len(rows)), err)
+	}()
+
+	tx := st.database.Tx()
+
+	if tx == nil {
+		return st.database.SelectToMapString(sqlStr)
+	}
+
+	listMapAny := []map[string]any{}
+
+	err = sqlscan.Select(context.Background(), tx, &listMapAny, sqlStr)
+
+	if err != nil {
+		if sqlscan.NotFound(err) {
+			return []map[string]string{}, nil
+		}
+
+		return []map[string]string{}, err
+	}
+
+	listMapString := []map[string]string{}
+
+	for _, mapAny := range listMapAny {
+		listMapString = append(listMapString, maputils.MapStringAnyToMapStringString(mapAny))
+	}
+
+	return listMapString, nil
+}
+
+// selectToMapStringFrom selects the rows of a table matching the expression
+func (st *storeImplementation) selectToMapStringFrom(operation string, tableName string, where goqu.Expression) ([]map[string]string, error) {
+	sqlStr, _, errSql := goqu.Dialect(st.dbDriverName).From(tableName).Where(where).Select().ToSQL()
+
+	if errSql != nil {
+		return nil, errSql
+	}
+
+	return st.selectToMapString(operation, sqlStr)
+}
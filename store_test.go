@@ -63,3 +63,119 @@ func TestStoreAutomigrate(t *testing.T) {
 		t.Fatal("Automigrate failed: ", err.Error())
 	}
 }
+
+func TestStoreAutomigrateFails(t *testing.T) {
+	db := InitDB("test_entity_automigrate_fails.db")
+
+	// the name of the link table is taken by an index
+	_, err := db.Exec(`CREATE TABLE "cms_entity" ("id" varchar(40) NOT NULL PRIMARY KEY); CREATE INDEX "cms_link" ON "cms_entity" ("id")`)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		LinkTableName:      "cms_link",
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if err := store.AutoMigrate(); err == nil {
+		t.Fatal("Automigrate must return the error of the link table")
+	}
+}
+
+func TestStoreAutoMigrateAddsVersionColumn(t *testing.T) {
+	db := InitDB("test_store_automigrate_version.db")
+
+	// the entity table as created before the version column
+	_, err := db.Exec(`CREATE TABLE "cms_entity" (
+		"id" varchar(40) NOT NULL PRIMARY KEY,
+		"entity_type" varchar(40) NOT NULL,
+		"entity_handle" varchar(60) DEFAULT '',
+		"created_at" datetime NOT NULL,
+		"updated_at" datetime NOT NULL
+	)`)
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	entity, err := store.EntityCreateWithType("post")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	err = entity.SetString("title", "Hello world")
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	found, err := store.EntityFindByID(entity.ID())
+
+	if err != nil {
+		t.Fatal("Must be NIL:", err.Error())
+	}
+
+	if found == nil || found.Version() != 2 {
+		t.Fatal("Version must be 2, found: ", found)
+	}
+}
+
+func TestStoreAutomigrateAddsColumns(t *testing.T) {
+	db := InitDB("test_entity_automigrate_columns.db")
+
+	_, err := db.Exec(`CREATE TABLE "cms_entity" ("id" varchar(40) NOT NULL PRIMARY KEY, "entity_type" varchar(40) NOT NULL, "entity_handle" varchar(60) DEFAULT '', "created_at" datetime NOT NULL, "updated_at" datetime NOT NULL)`)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		EntityTableName:    "cms_entity",
+		AttributeTableName: "cms_attribute",
+		HierarchyEnabled:   true,
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatal("Store could not be created: " + err.Error())
+	}
+
+	parent, err := store.EntityCreateWithType("folder")
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	child, err := store.EntityCreateWithType("folder")
+
+	if err != nil {
+		t.Fatal("Entity could not be created: " + err.Error())
+	}
+
+	err = store.EntitySetParent(child.ID(), parent.ID())
+
+	if err != nil {
+		t.Fatal("Parent could not be set: " + err.Error())
+	}
+}